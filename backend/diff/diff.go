@@ -4,31 +4,98 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"woodybriggs/justmigrate/backend/schema"
 	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
 	"woodybriggs/justmigrate/prompt"
 )
 
-type Diff struct{}
+type Diff struct {
+	// Unattended disables prompting to resolve renamed tables and columns,
+	// anything missing from the target is dropped and anything new is created.
+	Unattended bool
+
+	TypeComparison TypeComparison
+	// IncludeTemporary diffs temporary tables, views, triggers and indexes,
+	// which are otherwise left out as they never persist in a database.
+	IncludeTemporary bool
+	// Warnings are the changes that were found but deliberately not migrated.
+	Warnings []report.Report
+}
 
 var (
 	ErrArgumentMismatch error = errors.New("arguments a and b do not match")
 )
 
-func filterForCreateTable(value ast.Statement) (*ast.CreateTable, bool) {
-	result, ok := value.(*ast.CreateTable)
+// WithoutTemporary leaves out the statements that create temporary objects,
+// see ast.IsTemporary.
+func WithoutTemporary(statements []ast.Statement) []ast.Statement {
+	return slices.DeleteFunc(slices.Clone(statements), ast.IsTemporary)
+}
+
+func filterForStatement[T ast.Statement](value ast.Statement) (T, bool) {
+	result, ok := value.(T)
 	return result, ok
 }
 
-func isSameCreateTable(a, b *ast.CreateTable) bool {
-	return a.TableIdentifier.Eq(b.TableIdentifier)
+func isSameCreateView(a, b *ast.CreateView) bool {
+	return a.ViewIdentifier.Eq(&b.ViewIdentifier)
+}
+
+func isSameCreateTrigger(a, b *ast.CreateTrigger) bool {
+	return a.TriggerIdentifier.Eq(&b.TriggerIdentifier)
 }
 
-func resolveMissingColumns(
+// diffObjects compares the statements of type T that cannot be altered, a
+// modified statement is dropped and then created again.
+func diffObjects[T ast.Statement](
+	src, tgt []ast.Statement,
+	isSame func(a, b T) bool,
+	del func(T) Op,
+	add func(T) Op,
+) []Op {
+	ops := []Op{}
+
+	a := slices.Collect(filterThenMap(slices.Values(src), filterForStatement[T]))
+	b := slices.Collect(filterThenMap(slices.Values(tgt), filterForStatement[T]))
+
+	removed, added := symmetricDifference(a, b, isSame)
+
+	for _, object := range removed {
+		ops = append(ops, del(object))
+	}
+
+	for _, pair := range intersection(a, b, isSame) {
+		if !pair.A.Eq(pair.B) {
+			ops = append(ops, del(pair.A), add(pair.B))
+		}
+	}
+
+	for _, object := range added {
+		ops = append(ops, add(object))
+	}
+
+	return ops
+}
+
+func isSameTable(a, b *schema.Table) bool {
+	return a.Schema == b.Schema && a.Name == b.Name
+}
+
+func isSameIndex(a, b *schema.Index) bool {
+	return a.Schema == b.Schema && a.Name == b.Name
+}
+
+func isSameVirtualTable(a, b *schema.VirtualTable) bool {
+	return a.Schema == b.Schema && a.Name == b.Name
+}
+
+func (diff *Diff) resolveMissingColumns(
 	table *ast.CatalogObjectIdentifier,
-	removed []ast.ColumnDefinition,
-	added []ast.ColumnDefinition,
-) (finalRemoved []ast.ColumnDefinition, finalAdded []ast.ColumnDefinition, ops []Op) {
-	if len(removed) == 0 {
+	removed []*schema.Column,
+	added []*schema.Column,
+) (finalRemoved []*schema.Column, finalAdded []*schema.Column, ops []Op) {
+	if len(removed) == 0 || diff.Unattended {
 		return removed, added, nil
 	}
 
@@ -52,20 +119,20 @@ func resolveMissingColumns(
 
 		options := []prompt.SelectOption{
 			{
-				Label: fmt.Sprintf("new column: %s", newCol.ColumnName.Text),
-				Value: &NewColOp{Table: table, Col: &newCol},
+				Label: fmt.Sprintf("new column: %s", newCol.ColumnDefinition.ColumnName.Text),
+				Value: &NewColOp{Table: table, Col: newCol.ColumnDefinition},
 			},
 		}
 
 		for _, unresolvedCol := range unresolvedRemovedCols {
 			options = append(options, prompt.SelectOption{
-				Label: fmt.Sprintf("renamed from: %s", unresolvedCol.ColumnName.Text),
-				Value: &RenameColOp{Table: table, FromCol: &unresolvedCol.ColumnName, ToCol: &newCol.ColumnName},
+				Label: fmt.Sprintf("renamed from: %s", unresolvedCol.ColumnDefinition.ColumnName.Text),
+				Value: &RenameColOp{Table: table, FromCol: &unresolvedCol.ColumnDefinition.ColumnName, ToCol: &newCol.ColumnDefinition.ColumnName},
 			})
 		}
 
 		sel := prompt.Select{}
-		title := fmt.Sprintf("Resolve column %s.%s: Is this column new or renamed?", table.ObjectName.Text, newCol.ColumnName.Text)
+		title := fmt.Sprintf("Resolve column %s.%s: Is this column new or renamed?", table.ObjectName.Text, newCol.ColumnDefinition.ColumnName.Text)
 		choiceIndex, err := sel.Do(&terminal, title, options)
 		if err != nil {
 			panic(err)
@@ -74,15 +141,15 @@ func resolveMissingColumns(
 		switch typ := op.Value.(type) {
 		case *RenameColOp:
 			// remove the From column from the unresolved columns as it is now resolved
-			unresolvedRemovedCols = slices.DeleteFunc(unresolvedRemovedCols, func(col ast.ColumnDefinition) bool {
-				return col.ColumnName.Eq(typ.FromCol)
+			unresolvedRemovedCols = slices.DeleteFunc(unresolvedRemovedCols, func(col *schema.Column) bool {
+				return col.Name == typ.FromCol.Canonical()
 			})
 
 			// add the rename op to the output
 			ops = append(ops, typ)
 		case *NewColOp:
 			// add the new column to final added
-			finalAdded = append(finalAdded, *typ.Col)
+			finalAdded = append(finalAdded, newCol)
 		}
 	}
 	// any unresolved removed columns are now final as removed
@@ -90,12 +157,12 @@ func resolveMissingColumns(
 	return
 }
 
-func resolveMissingTables(
-	removed []*ast.CreateTable,
-	added []*ast.CreateTable,
-) (finalRemoved []*ast.CreateTable, finalAdded []*ast.CreateTable, ops []Op) {
+func (diff *Diff) resolveMissingTables(
+	removed []*schema.Table,
+	added []*schema.Table,
+) (finalRemoved []*schema.Table, finalAdded []*schema.Table, ops []Op) {
 
-	if len(removed) == 0 {
+	if len(removed) == 0 || diff.Unattended {
 		return removed, added, nil
 	}
 
@@ -118,20 +185,20 @@ func resolveMissingTables(
 
 		options := []prompt.SelectOption{
 			{
-				Label: fmt.Sprintf("new table: %s", newTable.TableIdentifier.ObjectName.Text),
-				Value: &NewTableOp{newTable},
+				Label: fmt.Sprintf("new table: %s", newTable.Node.TableIdentifier.ObjectName.Text),
+				Value: &NewTableOp{newTable.Node},
 			},
 		}
 
 		for _, unresolved := range unresolvedRemovedTables {
 			options = append(options, prompt.SelectOption{
-				Label: fmt.Sprintf("renamed from:  %s", unresolved.TableIdentifier.ObjectName.Text),
-				Value: &RenameTableOp{From: unresolved.TableIdentifier, To: newTable.TableIdentifier},
+				Label: fmt.Sprintf("renamed from:  %s", unresolved.Node.TableIdentifier.ObjectName.Text),
+				Value: &RenameTableOp{From: unresolved.Node.TableIdentifier, To: newTable.Node.TableIdentifier},
 			})
 		}
 
 		sel := prompt.Select{}
-		title := fmt.Sprintf("Resolve table %s: Is this table new or renamed?", newTable.TableIdentifier.ObjectName.Text)
+		title := fmt.Sprintf("Resolve table %s: Is this table new or renamed?", newTable.Node.TableIdentifier.ObjectName.Text)
 		choiceIndex, err := sel.Do(&terminal, title, options)
 		if err != nil {
 			panic(err)
@@ -140,15 +207,15 @@ func resolveMissingTables(
 		switch typ := op.Value.(type) {
 		case *RenameTableOp:
 			// remove the From table from the unresolved table as it is now resolved
-			unresolvedRemovedTables = slices.DeleteFunc(unresolvedRemovedTables, func(table *ast.CreateTable) bool {
-				return table.TableIdentifier.Eq(typ.From)
+			unresolvedRemovedTables = slices.DeleteFunc(unresolvedRemovedTables, func(table *schema.Table) bool {
+				return table.Node.TableIdentifier.Eq(typ.From)
 			})
 
 			// add the rename op to the output
 			ops = append(ops, typ)
 		case *NewTableOp:
 			// add the new table to final added
-			finalAdded = append(finalAdded, typ.CreateTable)
+			finalAdded = append(finalAdded, newTable)
 		}
 	}
 
@@ -159,136 +226,152 @@ func resolveMissingTables(
 
 func (diff *Diff) DiffSchema(src, tgt []ast.Statement) ([]Op, error) {
 	ops := []Op{}
+	diff.Warnings = nil
 
-	// Compare all create table statements
-	{
-		src := slices.Collect(filterThenMap(slices.Values(src), filterForCreateTable))
-		tgt := slices.Collect(filterThenMap(slices.Values(tgt), filterForCreateTable))
+	if !diff.IncludeTemporary {
+		src, tgt = WithoutTemporary(src), WithoutTemporary(tgt)
+	}
 
-		maybeRemovedTables, maybeAddedTables := symmetricDifference(src, tgt, isSameCreateTable)
-		maybeModifiedTables := intersection(src, tgt, isSameCreateTable)
+	srcSchema := schema.FromStatements(src)
+	tgtSchema := schema.FromStatements(tgt)
 
-		removedTables, addedTables, renamedTableOps := resolveMissingTables(maybeRemovedTables, maybeAddedTables)
+	// Compare all tables
+	{
+		maybeRemovedTables, maybeAddedTables := symmetricDifference(srcSchema.Tables, tgtSchema.Tables, isSameTable)
+		maybeModifiedTables := intersection(srcSchema.Tables, tgtSchema.Tables, isSameTable)
+
+		removedTables, addedTables, renamedTableOps := diff.resolveMissingTables(maybeRemovedTables, maybeAddedTables)
 
 		for _, removedTable := range removedTables {
-			ops = append(ops, &DelTableOp{removedTable.TableIdentifier})
+			ops = append(ops, &DelTableOp{removedTable.Node.TableIdentifier})
 		}
 
 		for _, addedTable := range addedTables {
-			ops = append(ops, &NewTableOp{addedTable})
+			ops = append(ops, &NewTableOp{addedTable.Node})
 		}
 
 		ops = append(ops, renamedTableOps...)
 
 		for _, pair := range maybeModifiedTables {
-			tableOps := diff.DiffCreateTable(pair.A, pair.B)
+			tableOps := diff.DiffTable(pair.A, pair.B)
 			if tableOps != nil {
 				ops = append(ops, tableOps...)
 			}
 		}
 	}
 
-	return ops, nil
-}
+	// Indexes, views and triggers cannot be altered, so a modified object is
+	// dropped and created again
+	{
+		removedIndexes, addedIndexes := symmetricDifference(srcSchema.Indexes, tgtSchema.Indexes, isSameIndex)
 
-func isSameColumnDefinition(a, b ast.ColumnDefinition) bool {
-	return a.ColumnName.Eq(&b.ColumnName)
-}
+		for _, index := range removedIndexes {
+			ops = append(ops, &DelIndexOp{&index.Node.IndexIdentifier})
+		}
 
-func isSameTableConstraint(a, b ast.TableConstraint) bool {
-	switch a := a.(type) {
-	case *ast.TableConstraint_PrimaryKey:
-		_, ok := b.(*ast.TableConstraint_PrimaryKey)
-		if !ok {
-			return false
+		for _, pair := range intersection(srcSchema.Indexes, tgtSchema.Indexes, isSameIndex) {
+			if !pair.A.Eq(pair.B) {
+				ops = append(ops, &DelIndexOp{&pair.A.Node.IndexIdentifier}, &NewIndexOp{pair.B.Node})
+			}
 		}
-		return true
-	case *ast.TableConstraint_ForeignKey:
-		b, ok := b.(*ast.TableConstraint_ForeignKey)
-		if !ok {
-			return false
+
+		for _, index := range addedIndexes {
+			ops = append(ops, &NewIndexOp{index.Node})
 		}
-		return a.Eq(b)
-	default:
-		return false
 	}
+
+	// A module is only given its arguments when the virtual table is created,
+	// so a virtual table with changed arguments is created again
+	{
+		removed, added := symmetricDifference(srcSchema.VirtualTables, tgtSchema.VirtualTables, isSameVirtualTable)
+
+		for _, vt := range removed {
+			ops = append(ops, &DelTableOp{&vt.Node.TableIdentifier})
+		}
+
+		for _, pair := range intersection(srcSchema.VirtualTables, tgtSchema.VirtualTables, isSameVirtualTable) {
+			if !pair.A.Eq(pair.B) {
+				ops = append(ops, &ChangeVirtualTableOp{From: pair.A.Node, To: pair.B.Node})
+			}
+		}
+
+		for _, vt := range added {
+			ops = append(ops, &NewVirtualTableOp{vt.Node})
+		}
+	}
+
+	ops = append(ops, diffObjects(src, tgt, isSameCreateView,
+		func(view *ast.CreateView) Op { return &DelViewOp{&view.ViewIdentifier} },
+		func(view *ast.CreateView) Op { return &NewViewOp{view} },
+	)...)
+
+	ops = append(ops, diffObjects(src, tgt, isSameCreateTrigger,
+		func(trigger *ast.CreateTrigger) Op { return &DelTriggerOp{&trigger.TriggerIdentifier} },
+		func(trigger *ast.CreateTrigger) Op { return &NewTriggerOp{trigger} },
+	)...)
+
+	return ops, nil
+}
+
+func isSameColumn(a, b *schema.Column) bool {
+	return a.Name == b.Name
 }
 
-func (diff *Diff) DiffCreateTable(src, tgt *ast.CreateTable) []Op {
+func (diff *Diff) DiffTable(src, tgt *schema.Table) []Op {
 	ops := []Op{}
+	table := src.Node.TableIdentifier
 
-	// Compare column definitions
+	// Compare columns
 	{
-		a := src.TableDefinition.ColumnDefinitions
-		b := tgt.TableDefinition.ColumnDefinitions
+		maybeRemovedColumns, maybeAddedColumns := symmetricDifference(src.Columns, tgt.Columns, isSameColumn)
+		maybeModifiedColumns := intersection(src.Columns, tgt.Columns, isSameColumn)
 
-		maybeRemovedColumns, maybeAddedColumns := symmetricDifference(a, b, isSameColumnDefinition)
-		maybeModifiedColumns := intersection(a, b, isSameColumnDefinition)
-
-		removedColumns, addedColumns, renamedColumnsOps := resolveMissingColumns(src.TableIdentifier, maybeRemovedColumns, maybeAddedColumns)
+		removedColumns, addedColumns, renamedColumnsOps := diff.resolveMissingColumns(table, maybeRemovedColumns, maybeAddedColumns)
 
 		for _, removedColumn := range removedColumns {
-			ops = append(ops, &DelColOp{Table: src.TableIdentifier, Col: &removedColumn.ColumnName})
+			ops = append(ops, &DelColOp{Table: table, Col: &removedColumn.ColumnDefinition.ColumnName})
 		}
 
 		for _, addedColumn := range addedColumns {
-			ops = append(ops, &NewColOp{Table: src.TableIdentifier, Col: &addedColumn})
+			ops = append(ops, &NewColOp{Table: table, Col: addedColumn.ColumnDefinition})
 		}
 
 		ops = append(ops, renamedColumnsOps...)
 
 		for _, pair := range maybeModifiedColumns {
-			columnOps := diff.DiffColumnDefinition(src.TableIdentifier, pair.A, pair.B)
+			columnOps := diff.DiffColumn(src, tgt, pair.A, pair.B)
 			if columnOps != nil {
 				ops = append(ops, columnOps...)
 			}
 		}
 	}
 
-	/*
-			We need to extract out the table constraints by type.
+	// Keys declared on a column have been lifted to the table, so moving a
+	// key between the column and the table is not a change
+	if !src.ConstraintsEq(tgt) {
+		ops = append(ops, &ChangeTableConstraintsOp{Table: table, CreateTable: tgt.Node})
+	}
 
-			1. We know that there is only ever one PK constraint per table
-			2. We can collect and compare all FK constraints
-		       we might be able to skip FK constraints as they would likely be handled in the planner
-			   where we explicity check all FK constraints for referentaial integrety.
-			3. We can collect and compare all UNIQUE constraints
-			4. We can collect and compare all CHECK constraints
-	*/
+	if src.Strict != tgt.Strict || src.WithoutRowId != tgt.WithoutRowId {
+		ops = append(ops, &ChangeTableOptionsOp{Table: table, CreateTable: tgt.Node})
+	}
 
 	return ops
 }
 
-func (diff *Diff) DiffColumnDefinition(table *ast.CatalogObjectIdentifier, src, tgt ast.ColumnDefinition) []Op {
+// DiffColumn compares a column of srcTable with the column of the same name
+// in tgtTable.
+func (diff *Diff) DiffColumn(srcTable, tgtTable *schema.Table, src, tgt *schema.Column) []Op {
 	ops := []Op{}
+	table := srcTable.Node.TableIdentifier
 
-	if !src.TypeName.Eq(tgt.TypeName) {
-		ops = append(ops, &ChangeColTypeOp{Table: table, Col: &src.ColumnName, TypeName: tgt.TypeName})
+	if diff.typeChanged(srcTable, tgtTable, src, tgt) {
+		ops = append(ops, &ChangeColTypeOp{Table: table, Col: &src.ColumnDefinition.ColumnName, TypeName: tgt.ColumnDefinition.TypeName})
 	}
 
-	/*
-		We need to pick out column constraints that are 1 per col
-		seperate them from CHECK constraints as 1 column can have many check constraints.
-
-		should we lift column constraints that can be defined as table constraints up to the table?
-		if we did we would be closer to our goal of semantic analysis, moving away from syntax more.
-
-		1. Primary Key constraint
-		2. Foreign Key (REFERENCES) constraint
-		3. Default constraint
-		4. Not Null constraint
-		5. Unique constraint
-		6. Collate constraint
-		7. As constraint
-	*/
-
-	return ops
-}
-
-func (diff *Diff) DiffTableConstraint(table *ast.CatalogObjectIdentifier, a, b ast.TableConstraint) []Op {
-	ops := []Op{}
-
-	// do something here
+	if !src.ColumnConstraints.Eq(&tgt.ColumnConstraints) {
+		ops = append(ops, &ChangeColConstraintsOp{Table: table, Col: tgt.ColumnDefinition})
+	}
 
 	return ops
 }
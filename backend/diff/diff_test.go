@@ -0,0 +1,191 @@
+package diff_test
+
+import (
+	"testing"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func parse(t *testing.T, source string) []ast.Statement {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(source),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statements
+}
+
+func TestDiffTypeComparison(t *testing.T) {
+	tests := []struct {
+		name       string
+		src, tgt   string
+		comparison diff.TypeComparison
+		ops        int
+		warnings   int
+	}{
+		{
+			name:       "strict varchar to text",
+			src:        "CREATE TABLE t (a varchar(4));",
+			tgt:        "CREATE TABLE t (a text);",
+			comparison: diff.TypeComparisonStrict,
+			ops:        1,
+		},
+		{
+			name:       "affinity varchar to text",
+			src:        "CREATE TABLE t (a varchar(4));",
+			tgt:        "CREATE TABLE t (a text);",
+			comparison: diff.TypeComparisonAffinity,
+		},
+		{
+			name:       "warn INT to integer",
+			src:        "CREATE TABLE t (a INT);",
+			tgt:        "CREATE TABLE t (a integer);",
+			comparison: diff.TypeComparisonWarn,
+			warnings:   1,
+		},
+		{
+			name:       "warn case only",
+			src:        "CREATE TABLE t (a INTEGER);",
+			tgt:        "CREATE TABLE t (a integer);",
+			comparison: diff.TypeComparisonWarn,
+		},
+		{
+			name:       "affinity text to integer",
+			src:        "CREATE TABLE t (a text);",
+			tgt:        "CREATE TABLE t (a integer);",
+			comparison: diff.TypeComparisonAffinity,
+			ops:        1,
+		},
+		{
+			name:       "warn INT to INTEGER primary key",
+			src:        "CREATE TABLE t (id INT PRIMARY KEY);",
+			tgt:        "CREATE TABLE t (id INTEGER PRIMARY KEY);",
+			comparison: diff.TypeComparisonWarn,
+			ops:        1,
+		},
+		{
+			name:       "affinity INTEGER to INT table primary key",
+			src:        "CREATE TABLE t (id INTEGER, PRIMARY KEY (id));",
+			tgt:        "CREATE TABLE t (id INT, PRIMARY KEY (id));",
+			comparison: diff.TypeComparisonAffinity,
+			ops:        1,
+		},
+		{
+			name:       "warn INT to INTEGER composite primary key",
+			src:        "CREATE TABLE t (a INT, b INT, PRIMARY KEY (a, b));",
+			tgt:        "CREATE TABLE t (a INTEGER, b INT, PRIMARY KEY (a, b));",
+			comparison: diff.TypeComparisonWarn,
+			warnings:   1,
+		},
+		{
+			name:       "warn INT to INTEGER primary key without rowid",
+			src:        "CREATE TABLE t (id INT PRIMARY KEY) WITHOUT ROWID;",
+			tgt:        "CREATE TABLE t (id INTEGER PRIMARY KEY) WITHOUT ROWID;",
+			comparison: diff.TypeComparisonWarn,
+			warnings:   1,
+		},
+		{
+			name:       "warn no type to blob",
+			src:        "CREATE TABLE t (a);",
+			tgt:        "CREATE TABLE t (a blob);",
+			comparison: diff.TypeComparisonWarn,
+			warnings:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			differ := diff.Diff{Unattended: true, TypeComparison: test.comparison}
+			ops, err := differ.DiffSchema(parse(t, test.src), parse(t, test.tgt))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ops) != test.ops {
+				t.Errorf("expected %d operations, got %d", test.ops, len(ops))
+			}
+			if len(differ.Warnings) != test.warnings {
+				t.Errorf("expected %d warnings, got %d", test.warnings, len(differ.Warnings))
+			}
+		})
+	}
+}
+
+func TestDiffCanonicalIdentifiers(t *testing.T) {
+	src := parse(t, `
+		CREATE TABLE users (id integer PRIMARY KEY, Email text UNIQUE);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users(id));
+		CREATE INDEX users_email ON users (email);
+		CREATE VIEW emails AS SELECT email FROM users;`)
+	tgt := parse(t, "CREATE TABLE \"Users\" ([id] integer PRIMARY KEY, `email` text UNIQUE);\n"+`
+		CREATE TABLE [posts] ("ID" integer PRIMARY KEY, user_id integer REFERENCES USERS("Id"));
+		CREATE INDEX [USERS_EMAIL] ON "users" ("EMAIL");
+		CREATE VIEW Emails AS SELECT email FROM users;`)
+
+	differ := diff.Diff{Unattended: true}
+	ops, err := differ.DiffSchema(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range ops {
+		t.Errorf("unexpected operation %T %+v", op, op)
+	}
+}
+
+func TestDiffUniqueConstraintForms(t *testing.T) {
+	src := parse(t, `CREATE TABLE users (id integer PRIMARY KEY, email text UNIQUE, name text);`)
+	tgt := parse(t, `CREATE TABLE users (id integer PRIMARY KEY, email text, name text, UNIQUE (email));`)
+
+	differ := diff.Diff{Unattended: true}
+	ops, err := differ.DiffSchema(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range ops {
+		t.Errorf("unexpected operation %T %+v", op, op)
+	}
+
+	// a unique key over both columns is not the same as a key on each
+	tgt = parse(t, `CREATE TABLE users (id integer PRIMARY KEY, email text, name text, UNIQUE (email, name));`)
+	ops, err = differ.DiffSchema(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) == 0 {
+		t.Error("expected the changed unique key to be migrated")
+	}
+}
+
+func TestDiffTemporaryObjects(t *testing.T) {
+	src := parse(t, `CREATE TABLE t (a text);`)
+	tgt := parse(t, `
+		CREATE TABLE t (a text);
+		CREATE TEMP TABLE scratch (a text);
+		CREATE TEMPORARY VIEW v AS SELECT a FROM t;
+		CREATE INDEX temp.scratch_a ON scratch (a);`)
+
+	differ := diff.Diff{Unattended: true}
+	ops, err := differ.DiffSchema(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range ops {
+		t.Errorf("unexpected operation %T %+v", op, op)
+	}
+
+	differ.IncludeTemporary = true
+	ops, err = differ.DiffSchema(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 3 {
+		t.Errorf("expected the three temporary objects to be created, got %+v", ops)
+	}
+}
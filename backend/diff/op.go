@@ -6,17 +6,52 @@ type Op interface {
 	op()
 }
 
-func (*TransactionOp) op()   {}
-func (*NewTableOp) op()      {}
-func (*DelTableOp) op()      {}
-func (*RenameTableOp) op()   {}
-func (*NewColOp) op()        {}
-func (*DelColOp) op()        {}
-func (*RenameColOp) op()     {}
-func (*ChangeColTypeOp) op() {}
+func (*TransactionOp) op()            {}
+func (*NewTableOp) op()               {}
+func (*DelTableOp) op()               {}
+func (*RenameTableOp) op()            {}
+func (*NewColOp) op()                 {}
+func (*DelColOp) op()                 {}
+func (*RenameColOp) op()              {}
+func (*ChangeColTypeOp) op()          {}
+func (*ChangeColConstraintsOp) op()   {}
+func (*ChangeTableConstraintsOp) op() {}
+func (*ChangeTableOptionsOp) op()     {}
+func (*NewIndexOp) op()               {}
+func (*DelIndexOp) op()               {}
+func (*NewViewOp) op()                {}
+func (*DelViewOp) op()                {}
+func (*NewTriggerOp) op()             {}
+func (*DelTriggerOp) op()             {}
+func (*RecreateTableOp) op()          {}
+func (*NewVirtualTableOp) op()        {}
+func (*ChangeVirtualTableOp) op()     {}
+func (*RecreateVirtualTableOp) op()   {}
+func (*PragmaOp) op()                 {}
+func (*CommentOp) op()                {}
 
+// TransactionOp runs its operations in a single transaction.
 type TransactionOp struct {
-	ops []Op
+	Ops []Op
+}
+
+// Flatten returns the operations of the plan with the operations of each
+// transaction in place of the transaction.
+func Flatten(ops []Op) []Op {
+	flat := []Op{}
+	for _, op := range ops {
+		if transaction, ok := op.(*TransactionOp); ok {
+			flat = append(flat, Flatten(transaction.Ops)...)
+			continue
+		}
+		flat = append(flat, op)
+	}
+	return flat
+}
+
+// CommentOp explains the operations that follow it, it has no effect.
+type CommentOp struct {
+	Text string
 }
 
 type NewTableOp struct {
@@ -53,3 +88,86 @@ type ChangeColTypeOp struct {
 	Col      *ast.Identifier
 	TypeName *ast.TypeName
 }
+
+// ChangeColConstraintsOp changes the NOT NULL, DEFAULT, COLLATE, CHECK or
+// generated expression of a column to those of Col.
+type ChangeColConstraintsOp struct {
+	Table *ast.CatalogObjectIdentifier
+	Col   *ast.ColumnDefinition
+}
+
+// ChangeTableConstraintsOp changes the primary key, unique, foreign key or
+// check constraints of a table to those of CreateTable.
+type ChangeTableConstraintsOp struct {
+	Table       *ast.CatalogObjectIdentifier
+	CreateTable *ast.CreateTable
+}
+
+// ChangeTableOptionsOp changes whether a table is STRICT or WITHOUT ROWID to
+// the options of CreateTable.
+type ChangeTableOptionsOp struct {
+	Table       *ast.CatalogObjectIdentifier
+	CreateTable *ast.CreateTable
+}
+
+type NewIndexOp struct {
+	*ast.CreateIndex
+}
+
+type DelIndexOp struct {
+	*ast.CatalogObjectIdentifier
+}
+
+type NewViewOp struct {
+	*ast.CreateView
+}
+
+type DelViewOp struct {
+	*ast.CatalogObjectIdentifier
+}
+
+type NewTriggerOp struct {
+	*ast.CreateTrigger
+}
+
+type DelTriggerOp struct {
+	*ast.CatalogObjectIdentifier
+}
+
+// RecreateTableOp replaces a table with a new definition by copying its rows
+// into a freshly created table. Columns pairs each source column (A) with the
+// target column (B) it is copied into, columns without a pair are not copied.
+type RecreateTableOp struct {
+	Table       *ast.CatalogObjectIdentifier
+	CreateTable *ast.CreateTable
+	Columns     []ast.IdentifierPair
+}
+
+// NewVirtualTableOp creates a virtual table, a virtual table is dropped by a
+// DelTableOp.
+type NewVirtualTableOp struct {
+	*ast.CreateVirtualTable
+}
+
+// ChangeVirtualTableOp changes the module or the module arguments of a
+// virtual table from those of From to those of To.
+type ChangeVirtualTableOp struct {
+	From *ast.CreateVirtualTable
+	To   *ast.CreateVirtualTable
+}
+
+// RecreateVirtualTableOp drops a virtual table and creates it again from
+// CreateVirtualTable. The rows of the Columns are copied into the new table,
+// or when Rebuild is set the module rebuilds the table from its external
+// content instead.
+type RecreateVirtualTableOp struct {
+	Table              *ast.CatalogObjectIdentifier
+	CreateVirtualTable *ast.CreateVirtualTable
+	Columns            []string
+	Rebuild            bool
+}
+
+type PragmaOp struct {
+	Key   string
+	Value string
+}
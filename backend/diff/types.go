@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"fmt"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+// TypeComparison decides when the declared type of a column has changed.
+//
+// Sqlite only enforces the affinity of a column, so changing `varchar(4)` to
+// `text` changes nothing about the stored values but still requires the
+// table to be rebuilt.
+//
+// TypeComparison is a flag.Value, the flag takes strict, affinity or warn.
+type TypeComparison int
+
+const (
+	// TypeComparisonStrict compares the declared types textually, ignoring case.
+	TypeComparisonStrict TypeComparison = iota
+	// TypeComparisonAffinity only changes a column when its affinity changes.
+	TypeComparisonAffinity
+	// TypeComparisonWarn is TypeComparisonAffinity, but reports a warning
+	// for every declared type that changed without changing its affinity.
+	TypeComparisonWarn
+)
+
+var typeComparisonNames = map[TypeComparison]string{
+	TypeComparisonStrict:   "strict",
+	TypeComparisonAffinity: "affinity",
+	TypeComparisonWarn:     "warn",
+}
+
+func (c TypeComparison) String() string {
+	return typeComparisonNames[c]
+}
+
+func (c *TypeComparison) Set(value string) error {
+	for comparison, name := range typeComparisonNames {
+		if name == value {
+			*c = comparison
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown type comparison %q, expected one of strict, affinity or warn", value)
+}
+
+// typeChanged compares the declared types of a column, a change that keeps the
+// affinity is reported as a warning when diff.TypeComparison is TypeComparisonWarn.
+// A change that makes the column an alias of the rowid, or stops it being one,
+// always changes the column, as it changes how the rows are stored.
+func (diff *Diff) typeChanged(srcTable, tgtTable *schema.Table, src, tgt *schema.Column) bool {
+	if src.Type.Eq(tgt.Type) {
+		return false
+	}
+
+	if diff.TypeComparison == TypeComparisonStrict || src.Affinity() != tgt.Affinity() {
+		return true
+	}
+
+	if srcTable.IsRowIdAlias(src) != tgtTable.IsRowIdAlias(tgt) {
+		return true
+	}
+
+	table := tgtTable.Node.TableIdentifier.ObjectName.Text
+
+	if diff.TypeComparison == TypeComparisonWarn {
+		label := report.LabelFromIdentifier(tgt.ColumnDefinition.ColumnName, "declared without a type")
+		if tgt.Type != nil {
+			label = report.LabelFromIdentifier(tgt.Type.TypeName.Name, fmt.Sprintf("was %s", typeOrNone(src.Type)))
+		}
+
+		diff.Warnings = append(diff.Warnings, *report.
+			NewReport("warning").
+			WithLocation(tgt.ColumnDefinition.ColumnName.FileLoc).
+			WithMessage(fmt.Sprintf("type of column \"%s.%s\" changed from %s to %s, this is not migrated as the %s affinity is unchanged", table, tgt.ColumnDefinition.ColumnName.Text, typeOrNone(src.Type), typeOrNone(tgt.Type), tgt.Affinity())).
+			WithLabels(label).
+			WithNotes("compare types strictly to rebuild the table with the new declared type"),
+		)
+	}
+
+	return false
+}
+
+func typeOrNone(typ *schema.Type) string {
+	if typ == nil {
+		return "no type"
+	}
+	return typ.String()
+}
@@ -89,7 +89,12 @@ func (f *CoreFormatter) writeIndent() {
 	}
 }
 
+// Identifier writes s between the escape characters, an escape character in
+// s is doubled.
 func (f *CoreFormatter) Identifier(s string) {
+	if f.escapeIdentifierEnd != "" {
+		s = strings.ReplaceAll(s, f.escapeIdentifierEnd, f.escapeIdentifierEnd+f.escapeIdentifierEnd)
+	}
 	f.Text(f.escapeIdentifierStart)
 	f.Text(s)
 	f.Text(f.escapeIdentifierEnd)
@@ -0,0 +1,172 @@
+package lint
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+type Severity int
+
+const (
+	SeverityOff Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityOff:     "off",
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+var severityLevels = map[Severity]report.Level{
+	SeverityInfo:    report.LevelNote,
+	SeverityWarning: report.LevelWarning,
+	SeverityError:   report.LevelError,
+}
+
+// Level is the level the reports of a rule with the severity are shown at.
+func (s Severity) Level() report.Level {
+	return severityLevels[s]
+}
+
+func ParseSeverity(name string) (Severity, error) {
+	for severity, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return severity, nil
+		}
+	}
+	return SeverityOff, fmt.Errorf("unknown severity %q, expected one of off, info, warning or error", name)
+}
+
+// Rule is a check of the schema that is not an error to sqlite, but is likely
+// to be a mistake or to be regretted later.
+type Rule struct {
+	Name        string
+	Description string
+	// Severity is used when the rule is not configured.
+	Severity Severity
+	Check    func(schema *Schema) []*report.Report
+}
+
+// Config overrides the severity of rules by their name, a rule configured as
+// SeverityOff is disabled.
+//
+// Config is a flag.Value, the flag takes comma separated name=severity pairs
+// and may be given more than once.
+type Config map[string]Severity
+
+func (c Config) String() string {
+	pairs := []string{}
+	for _, name := range slices.Sorted(maps.Keys(c)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, c[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (c Config) Set(value string) error {
+	for pair := range strings.SplitSeq(value, ",") {
+		name, severityName, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected rule=severity, got %q", pair)
+		}
+
+		if _, known := RuleByName(name); !known {
+			return fmt.Errorf("unknown lint rule %q", name)
+		}
+
+		severity, err := ParseSeverity(severityName)
+		if err != nil {
+			return err
+		}
+		c[name] = severity
+	}
+	return nil
+}
+
+func (c Config) Severity(rule Rule) Severity {
+	if severity, ok := c[rule.Name]; ok {
+		return severity
+	}
+	return rule.Severity
+}
+
+// Schema is what the linter collected from the statements of the schema, the
+// tables and indexes are normalized so that rules read keys and types the
+// same way wherever they were declared.
+type Schema struct {
+	*schema.Schema
+	Views    []*ast.CreateView
+	Triggers []*ast.CreateTrigger
+}
+
+type Linter struct {
+	ast.BaseVisitor
+	Config Config
+
+	schema Schema
+}
+
+func NewLinter(config Config) *Linter {
+	return &Linter{
+		Config: config,
+	}
+}
+
+func (l *Linter) VisitCreateView(node *ast.CreateView) {
+	l.schema.Views = append(l.schema.Views, node)
+}
+
+func (l *Linter) VisitCreateTrigger(node *ast.CreateTrigger) {
+	l.schema.Triggers = append(l.schema.Triggers, node)
+}
+
+// Lint runs every enabled rule over the statements, the kind of each report
+// is the name of the rule and its level the severity of the rule.
+func (l *Linter) Lint(statements []ast.Statement) []report.Report {
+	// the tables are linted as later statements, such as ALTER TABLE, left
+	// them, statements that sqlite would reject are linted as they are
+	definitions := statements
+	if interpreter, err := schema.Replay(statements); err == nil {
+		definitions = interpreter.Statements()
+	}
+
+	l.schema = Schema{Schema: schema.FromStatements(definitions)}
+	for _, statement := range definitions {
+		statement.Accept(l)
+	}
+
+	reports := []report.Report{}
+	for _, rule := range Rules {
+		severity := l.Config.Severity(rule)
+		if severity == SeverityOff {
+			continue
+		}
+
+		for _, rep := range rule.Check(&l.schema) {
+			rep.Kind = rule.Name
+			rep.Level = severity.Level()
+			reports = append(reports, *rep)
+		}
+	}
+
+	return reports
+}
+
+// HasErrors reports whether any of the reports came from a rule configured as an error.
+func HasErrors(reports []report.Report) bool {
+	return slices.ContainsFunc(reports, func(rep report.Report) bool {
+		return rep.Level == report.LevelError
+	})
+}
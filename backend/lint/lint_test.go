@@ -0,0 +1,185 @@
+package lint_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"woodybriggs/justmigrate/backend/lint"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+func lintKinds(t *testing.T, config lint.Config, schema string) []string {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(schema),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []string{}
+	for _, rep := range lint.NewLinter(config).Lint(statements) {
+		kinds = append(kinds, fmt.Sprintf("%s[%s]", rep.Level, rep.Kind))
+	}
+	return kinds
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		kinds  []string
+	}{
+		{
+			name: "clean",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text COLLATE NOCASE UNIQUE, active boolean NOT NULL);
+				CREATE TABLE login (id integer PRIMARY KEY, account_id integer REFERENCES account(id));
+				CREATE INDEX login_account_id ON login (account_id);`,
+			kinds: []string{},
+		},
+		{
+			name: "fk without index",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY);
+				CREATE TABLE login (id integer PRIMARY KEY, account_id integer REFERENCES account(id));`,
+			kinds: []string{"warning[fk-without-index]"},
+		},
+		{
+			name: "fk index in another schema",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY);
+				CREATE TABLE login (id integer PRIMARY KEY, account_id integer REFERENCES account(id));
+				CREATE INDEX aux.login_account_id ON login (account_id);`,
+			kinds: []string{"warning[fk-without-index]"},
+		},
+		{
+			name: "fk index in the main schema",
+			schema: `
+				CREATE TABLE main.account (id integer PRIMARY KEY);
+				CREATE TABLE main.login (id integer PRIMARY KEY, account_id integer REFERENCES account(id));
+				CREATE INDEX login_account_id ON LOGIN (account_id);`,
+			kinds: []string{},
+		},
+		{
+			name: "no primary key",
+			schema: `
+				CREATE TABLE account (email text);`,
+			kinds: []string{"warning[no-primary-key]"},
+		},
+		{
+			name: "unique text without collate",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email varchar(255) UNIQUE);`,
+			kinds: []string{"note[text-without-collate]"},
+		},
+		{
+			name: "nullable boolean",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, active boolean);`,
+			kinds: []string{"warning[nullable-boolean]"},
+		},
+		{
+			name: "money as real",
+			schema: `
+				CREATE TABLE exchange_rates (id integer PRIMARY KEY, rate real NOT NULL, generated_at real);`,
+			kinds: []string{"warning[money-as-real]"},
+		},
+		{
+			name: "naming convention",
+			schema: `
+				CREATE TABLE Account (id integer PRIMARY KEY, "emailAddress" text);`,
+			kinds: []string{"warning[naming-convention]", "warning[naming-convention]"},
+		},
+		{
+			name: "column added by alter table",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY);
+				CREATE TABLE login (id integer PRIMARY KEY);
+				ALTER TABLE login ADD COLUMN account_id integer REFERENCES account(id);`,
+			kinds: []string{"warning[fk-without-index]"},
+		},
+		{
+			name: "column renamed by alter table",
+			schema: `
+				CREATE TABLE invoice (id integer PRIMARY KEY, amt real);
+				ALTER TABLE invoice RENAME COLUMN amt TO amount;`,
+			kinds: []string{"warning[money-as-real]"},
+		},
+		{
+			name: "boolean in a table primary key",
+			schema: `
+				CREATE TABLE flag (name text COLLATE NOCASE, active boolean, PRIMARY KEY (name, active));`,
+			kinds: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kinds := lintKinds(t, lint.Config{}, test.schema)
+			if !slices.Equal(kinds, test.kinds) {
+				t.Errorf("expected %v, got %v", test.kinds, kinds)
+			}
+		})
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	schema := `CREATE TABLE account (email text);`
+
+	config := lint.Config{}
+	if err := config.Set("no-primary-key=error"); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := lintKinds(t, config, schema); !slices.Equal(kinds, []string{"error[no-primary-key]"}) {
+		t.Errorf("expected the rule to be an error, got %v", kinds)
+	}
+
+	if err := config.Set("no-primary-key=off"); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := lintKinds(t, config, schema); len(kinds) != 0 {
+		t.Errorf("expected the rule to be disabled, got %v", kinds)
+	}
+
+	if err := config.Set("no-such-rule=warning"); err == nil {
+		t.Error("expected unknown rules to be rejected")
+	}
+	if err := config.Set("no-primary-key=loud"); err == nil {
+		t.Error("expected unknown severities to be rejected")
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	schema := `CREATE TABLE account (email text);`
+
+	config := lint.Config{}
+	statements, err := parser.Parse(lexer.SourceCode{FileName: t.Name(), Raw: []rune(schema)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports := lint.NewLinter(config).Lint(statements); lint.HasErrors(reports) {
+		t.Error("expected a warning not to be an error")
+	}
+
+	if err := config.Set("no-primary-key=error"); err != nil {
+		t.Fatal(err)
+	}
+	reports := lint.NewLinter(config).Lint(statements)
+	if !lint.HasErrors(reports) {
+		t.Error("expected a rule configured as an error to be an error")
+	}
+	if kind := reports[0].Kind; kind != "no-primary-key" {
+		t.Errorf("expected the kind to be the name of the rule, got %q", kind)
+	}
+
+	// the kind is not where the level is kept
+	named := []report.Report{{Kind: "error[no-primary-key]", Level: report.LevelWarning}}
+	if lint.HasErrors(named) {
+		t.Error("expected the level of the report to decide, not its kind")
+	}
+}
@@ -0,0 +1,276 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+var Rules = []Rule{
+	{
+		Name:        "fk-without-index",
+		Description: "foreign key columns should be indexed, otherwise every delete from the referenced table scans this table",
+		Severity:    SeverityWarning,
+		Check:       checkForeignKeyIndexes,
+	},
+	{
+		Name:        "no-primary-key",
+		Description: "tables should have a primary key",
+		Severity:    SeverityWarning,
+		Check:       checkPrimaryKeys,
+	},
+	{
+		Name:        "text-without-collate",
+		Description: "unique or indexed text columns should declare a collation, text is compared case sensitively by default",
+		Severity:    SeverityInfo,
+		Check:       checkTextCollations,
+	},
+	{
+		Name:        "nullable-boolean",
+		Description: "boolean columns should be NOT NULL, otherwise they have three states",
+		Severity:    SeverityWarning,
+		Check:       checkNullableBooleans,
+	},
+	{
+		Name:        "money-as-real",
+		Description: "amounts of money should not be stored as floating point numbers",
+		Severity:    SeverityWarning,
+		Check:       checkMoneyAsReal,
+	},
+	{
+		Name:        "naming-convention",
+		Description: "tables, columns, indexes, views and triggers should be named in snake_case",
+		Severity:    SeverityWarning,
+		Check:       checkNamingConvention,
+	},
+}
+
+func RuleByName(name string) (Rule, bool) {
+	index := slices.IndexFunc(Rules, func(rule Rule) bool { return rule.Name == name })
+	if index < 0 {
+		return Rule{}, false
+	}
+	return Rules[index], true
+}
+
+func checkForeignKeyIndexes(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	for _, table := range s.Tables {
+		// every key or index whose leading columns are the foreign key columns
+		// can be used
+		prefixes := [][]string{}
+		if table.PrimaryKey != nil {
+			prefixes = append(prefixes, columnNames(table.PrimaryKey.Columns))
+		}
+		for _, unique := range table.Uniques {
+			prefixes = append(prefixes, columnNames(unique.Columns))
+		}
+		for _, index := range s.IndexesOn(table) {
+			prefixes = append(prefixes, columnNames(index.Columns))
+		}
+
+		for _, fk := range table.ForeignKeys {
+			if len(fk.Columns) == 0 || slices.ContainsFunc(prefixes, func(prefix []string) bool { return isPrefix(fk.Columns, prefix) }) {
+				continue
+			}
+
+			columns := foreignKeyColumns(table, fk)
+			names := []string{}
+			for _, column := range columns {
+				names = append(names, column.Text)
+			}
+
+			tableName := table.Node.TableIdentifier.ObjectName.Text
+			reports = append(reports, report.
+				NewReport("").
+				WithLocation(columns[0].FileLoc).
+				WithMessage(fmt.Sprintf("foreign key (%s) of table \"%s\" is not indexed", strings.Join(names, ", "), tableName)).
+				WithLabels(report.LabelFromIdentifier(columns[0], "foreign key column")).
+				WithNotes(fmt.Sprintf("CREATE INDEX %s_%s ON %s (%s)", tableName, strings.Join(names, "_"), tableName, strings.Join(names, ", "))),
+			)
+		}
+	}
+
+	return reports
+}
+
+func checkPrimaryKeys(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	for _, table := range s.Tables {
+		if table.PrimaryKey != nil {
+			continue
+		}
+
+		name := table.Node.TableIdentifier.ObjectName
+		reports = append(reports, report.
+			NewReport("").
+			WithLocation(name.FileLoc).
+			WithMessage(fmt.Sprintf("table \"%s\" has no primary key", name.Text)).
+			WithLabels(report.LabelFromIdentifier(name, "this table has no primary key")),
+		)
+	}
+
+	return reports
+}
+
+func checkTextCollations(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	for _, table := range s.Tables {
+		lookedUp := []string{}
+		if table.PrimaryKey != nil {
+			lookedUp = append(lookedUp, columnNames(table.PrimaryKey.Columns)...)
+		}
+		for _, unique := range table.Uniques {
+			lookedUp = append(lookedUp, columnNames(unique.Columns)...)
+		}
+		for _, index := range s.IndexesOn(table) {
+			lookedUp = append(lookedUp, columnNames(index.Columns)...)
+		}
+
+		for _, column := range table.Columns {
+			if column.Affinity() != schema.AffinityText || column.Collate != "" || !slices.Contains(lookedUp, column.Name) {
+				continue
+			}
+
+			name := column.ColumnDefinition.ColumnName
+			reports = append(reports, report.
+				NewReport("").
+				WithLocation(name.FileLoc).
+				WithMessage(fmt.Sprintf("text column \"%s\" is looked up without a collation", name.Text)).
+				WithLabels(report.LabelFromIdentifier(column.Type.TypeName.Name, "declared without COLLATE")).
+				WithNotes("add COLLATE NOCASE if lookups should ignore case, or COLLATE BINARY to make case sensitivity explicit"),
+			)
+		}
+	}
+
+	return reports
+}
+
+func checkNullableBooleans(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			if column.Type == nil || !slices.Contains([]string{"bool", "boolean"}, strings.ToLower(column.Type.Name)) {
+				continue
+			}
+			if column.NotNull || table.PrimaryKey != nil && slices.Contains(columnNames(table.PrimaryKey.Columns), column.Name) {
+				continue
+			}
+
+			name := column.ColumnDefinition.ColumnName
+			reports = append(reports, report.
+				NewReport("").
+				WithLocation(name.FileLoc).
+				WithMessage(fmt.Sprintf("boolean column \"%s\" is nullable", name.Text)).
+				WithLabels(report.LabelFromIdentifier(column.Type.TypeName.Name, "missing NOT NULL")),
+			)
+		}
+	}
+
+	return reports
+}
+
+// moneyWords are the words of a column name that suggest it holds an amount of money.
+var moneyWords = []string{"amount", "balance", "cost", "fee", "money", "price", "rate", "salary", "total"}
+
+func checkMoneyAsReal(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	for _, table := range s.Tables {
+		for _, column := range table.Columns {
+			if column.Affinity() != schema.AffinityReal {
+				continue
+			}
+
+			words := strings.Split(column.Name, "_")
+			if !slices.ContainsFunc(words, func(word string) bool { return slices.Contains(moneyWords, word) }) {
+				continue
+			}
+
+			name := column.ColumnDefinition.ColumnName
+			reports = append(reports, report.
+				NewReport("").
+				WithLocation(name.FileLoc).
+				WithMessage(fmt.Sprintf("column \"%s\" looks like an amount of money but is stored as %s", name.Text, column.Type.Name)).
+				WithLabels(report.LabelFromIdentifier(column.Type.TypeName.Name, "floating point numbers can not represent most decimal fractions")).
+				WithNotes("store the amount as an integer of the smallest unit, or as text"),
+			)
+		}
+	}
+
+	return reports
+}
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func checkNamingConvention(s *Schema) []*report.Report {
+	reports := []*report.Report{}
+
+	check := func(kind string, name ast.Identifier) {
+		if snakeCase.MatchString(name.Text) {
+			return
+		}
+		reports = append(reports, report.
+			NewReport("").
+			WithLocation(name.FileLoc).
+			WithMessage(fmt.Sprintf("%s \"%s\" is not snake_case", kind, name.Text)).
+			WithLabels(report.LabelFromIdentifier(name, "rename to lower case words separated by underscores")),
+		)
+	}
+
+	for _, table := range s.Tables {
+		check("table", table.Node.TableIdentifier.ObjectName)
+		for _, column := range table.Columns {
+			check("column", column.ColumnDefinition.ColumnName)
+		}
+	}
+	for _, index := range s.Indexes {
+		check("index", index.Node.IndexIdentifier.ObjectName)
+	}
+	for _, view := range s.Views {
+		check("view", view.ViewIdentifier.ObjectName)
+	}
+	for _, trigger := range s.Triggers {
+		check("trigger", trigger.TriggerIdentifier.ObjectName)
+	}
+
+	return reports
+}
+
+// foreignKeyColumns returns the columns of the foreign key as they were
+// written, a key declared on a column is on that column.
+func foreignKeyColumns(table *schema.Table, fk *schema.ForeignKey) []ast.Identifier {
+	if constraint, ok := fk.Node.(*ast.TableConstraint_ForeignKey); ok {
+		return constraint.Columns
+	}
+	column, _ := table.Column(fk.Columns[0])
+	return []ast.Identifier{column.ColumnDefinition.ColumnName}
+}
+
+// columnNames returns the names of the leading columns of a key or an index,
+// up to the first indexed expression.
+func columnNames(indexed []schema.IndexedColumn) []string {
+	names := []string{}
+	for _, column := range indexed {
+		if column.Expr != nil {
+			break
+		}
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func isPrefix(names, of []string) bool {
+	if len(names) > len(of) {
+		return false
+	}
+	return slices.Equal(names, of[:len(names)])
+}
@@ -1,105 +1,123 @@
 package schema
 
 import (
-	"errors"
-	"woodybriggs/justmigrate/core/ast"
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
 )
 
 type Type struct {
 	TypeName *ast.TypeName
 
-	Name string
-	Args []ast.NumericLiteral
+	Name     string
+	Args     []ast.NumericLiteral
+	Affinity Affinity
 }
 
 func TypeFromAst(typeName *ast.TypeName) *Type {
 	if typeName == nil {
 		return nil
 	}
+
+	args := []ast.NumericLiteral{}
+	for _, arg := range []ast.NumericLiteral{typeName.Arg0, typeName.Arg1} {
+		if arg != nil {
+			args = append(args, arg)
+		}
+	}
+
 	return &Type{
 		TypeName: typeName,
 		Name:     typeName.Name.Text,
-		Args:     []ast.NumericLiteral{typeName.Arg0, typeName.Arg1},
+		Args:     args,
+		Affinity: AffinityOf(typeName.Name.Text),
+	}
+}
+
+// String is the declared type, as it was written.
+func (t *Type) String() string {
+	if t == nil {
+		return ""
 	}
+
+	args := []string{}
+	for _, arg := range t.Args {
+		switch literal := arg.(type) {
+		case *ast.LiteralUnsignedInteger:
+			args = append(args, literal.Token.Text)
+		case *ast.LiteralSignedInteger:
+			args = append(args, literal.Token.Text)
+		case *ast.LiteralFloat:
+			args = append(args, literal.Token.Text)
+		}
+	}
+
+	if len(args) == 0 {
+		return t.Name
+	}
+	return fmt.Sprintf("%s(%s)", t.Name, strings.Join(args, ", "))
 }
 
-func PrimaryKeyFromColumnConstraint(constraint *ast.ColumnConstraint_PrimaryKey) *PrimaryKey {
-	panic(errors.New("not implemented"))
+// Eq compares the declared types, type names are case insensitive.
+func (t *Type) Eq(other *Type) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+
+	return strings.EqualFold(t.Name, other.Name) &&
+		slices.EqualFunc(t.Args, other.Args, func(a, b ast.NumericLiteral) bool { return a.Eq(b) })
 }
 
-func ForeignKeyFromColumnConstraint(constraint *ast.ColumnConstraint_ForeignKey) *ForeignKey {
-	panic(errors.New("not implemented"))
+type Generated struct {
+	AsExpr  ast.Expr
+	Storage Storage
 }
 
+func (g *Generated) Eq(other *Generated) bool {
+	if g == nil || other == nil {
+		return g == other
+	}
+	return g.Storage == other.Storage && ast.CheckPtr(g.AsExpr, other.AsExpr)
+}
+
+// ColumnConstraints are the constraints that only ever apply to a single
+// column, PRIMARY KEY, UNIQUE and REFERENCES are lifted to the table.
 type ColumnConstraints struct {
-	Collate     string
-	NotNull     bool
-	Unique      bool
-	Checks      []ast.Expr
-	FK          any
-	PK          any
-	Default     ast.Expr
-	GeneratedAs ast.Expr
+	Collate   string
+	NotNull   bool
+	Checks    []*Check
+	Default   ast.Expr
+	Generated *Generated
 }
 
 func ColumnConstraintsFromAst(constraints []ast.ColumnConstraint) *ColumnConstraints {
-
-	var notNull bool
-	var unique bool
-	var collate string
-	var checks []ast.Expr
-	var defaultVal ast.Expr
-	var generatedAsExpr ast.Expr
-	var fk any
-	var pk *PrimaryKey
+	result := &ColumnConstraints{}
 
 	for _, constraint := range constraints {
 		switch typ := constraint.(type) {
 		case *ast.ColumnConstraint_Check:
-			checks = append(checks, typ.CheckExpr)
+			result.Checks = append(result.Checks, &Check{Node: typ, Name: constraintName(typ.Name), Expr: typ.CheckExpr})
 		case *ast.ColumnConstraint_Collate:
-			collate = typ.CollationName.Text
+			result.Collate = strings.ToUpper(typ.CollationName.Text)
 		case *ast.ColumnConstraint_Generated:
-			generatedAsExpr = typ.AsExpr
+			result.Generated = &Generated{AsExpr: typ.AsExpr, Storage: storageFromAst(typ.Storage)}
 		case *ast.ColumnConstraint_NotNull:
-			notNull = true
+			result.NotNull = true
 		case *ast.ColumnConstraint_Default:
-			defaultVal = typ.Default
-		case *ast.ColumnConstraint_Unique:
-			unique = true
-		case *ast.ColumnConstraint_PrimaryKey:
-			pk = PrimaryKeyFromColumnConstraint(typ)
-		case *ast.ColumnConstraint_ForeignKey:
-			fk = ForeignKeyFromColumnConstraint(typ)
+			result.Default = typ.Default
 		}
 	}
 
-	return &ColumnConstraints{
-		Collate:     collate,
-		NotNull:     notNull,
-		Unique:      unique,
-		Checks:      checks,
-		PK:          pk,
-		FK:          fk,
-		GeneratedAs: generatedAsExpr,
-		Default:     defaultVal,
-	}
+	return result
 }
 
-type Columnish interface {
-	column()
-}
-
-func (genCol *GeneratedColumn) column() {}
-func (col *Column) column()             {}
-
-type GeneratedColumn struct {
-	ColumnDefinition *ast.ColumnDefinition
-
-	Name    string
-	Type    *Type
-	Storage Storage
-	ColumnConstraints
+func (c *ColumnConstraints) Eq(other *ColumnConstraints) bool {
+	return c.Collate == other.Collate &&
+		c.NotNull == other.NotNull &&
+		slices.EqualFunc(c.Checks, other.Checks, (*Check).Eq) &&
+		ast.CheckPtr(c.Default, other.Default) &&
+		c.Generated.Eq(other.Generated)
 }
 
 type Column struct {
@@ -107,15 +125,22 @@ type Column struct {
 
 	Name string
 	Type *Type
-	PK   any
 	ColumnConstraints
 }
 
-func ColumnFromAst(colDef *ast.ColumnDefinition) Columnish {
+func ColumnFromAst(colDef *ast.ColumnDefinition) *Column {
 	return &Column{
 		ColumnDefinition:  colDef,
-		Name:              colDef.ColumnName.Text,
+		Name:              colDef.ColumnName.Canonical(),
 		Type:              TypeFromAst(colDef.TypeName),
 		ColumnConstraints: *ColumnConstraintsFromAst(colDef.ColumnConstraints),
 	}
 }
+
+// Affinity of the column, a column without a declared type has BLOB affinity.
+func (col *Column) Affinity() Affinity {
+	if col.Type == nil {
+		return AffinityBlob
+	}
+	return col.Type.Affinity
+}
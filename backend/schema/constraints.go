@@ -1,17 +1,209 @@
 package schema
 
-import "woodybriggs/justmigrate/core/ast"
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+)
 
-type PrimaryKeyCommon struct {
+type Node interface {
+	Accept(ast.Visitor)
 }
 
-type PrimaryKey struct {
-	Node interface {
-		Accept(ast.Visitor)
+func constraintName(name *ast.ConstraintName) string {
+	if name == nil {
+		return ""
+	}
+	return name.Name.Canonical()
+}
+
+// IndexedColumn is a column, or an expression, that a key or an index is on.
+type IndexedColumn struct {
+	Name    string
+	Expr    ast.Expr
+	Collate string
+	Order   Order
+}
+
+func IndexedColumnFromAst(indexed ast.IndexedColumn) IndexedColumn {
+	result := IndexedColumn{Order: orderFromAst(indexed.Order)}
+
+	if ident, ok := indexed.Subject.(*ast.Identifier); ok {
+		result.Name = ident.Canonical()
+	} else {
+		result.Expr = indexed.Subject
+	}
+
+	if indexed.Collation != nil {
+		result.Collate = strings.ToUpper(indexed.Collation.Name.Text)
 	}
-	Order          Order
+
+	return result
+}
+
+func (c IndexedColumn) Eq(other IndexedColumn) bool {
+	return c.Name == other.Name &&
+		c.Collate == other.Collate &&
+		c.Order == other.Order &&
+		ast.CheckPtr(c.Expr, other.Expr)
+}
+
+func indexedColumnsFromAst(indexed []ast.IndexedColumn) []IndexedColumn {
+	result := make([]IndexedColumn, 0, len(indexed))
+	for _, column := range indexed {
+		result = append(result, IndexedColumnFromAst(column))
+	}
+	return result
+}
+
+type PrimaryKey struct {
+	Node Node
+
+	Name           string
+	Columns        []IndexedColumn
 	ConflictClause ConflictAction
+	AutoIncrement  bool
+}
+
+func PrimaryKeyFromColumnConstraint(column *ast.ColumnDefinition, constraint *ast.ColumnConstraint_PrimaryKey) *PrimaryKey {
+	return &PrimaryKey{
+		Node: constraint,
+		Name: constraintName(constraint.Name),
+		Columns: []IndexedColumn{
+			{Name: column.ColumnName.Canonical(), Order: orderFromAst(constraint.Order)},
+		},
+		ConflictClause: conflictActionFromAst(constraint.ConflictClause),
+		AutoIncrement:  constraint.AutoIncrement != nil,
+	}
+}
+
+func PrimaryKeyFromTableConstraint(constraint *ast.TableConstraint_PrimaryKey) *PrimaryKey {
+	return &PrimaryKey{
+		Node:           constraint,
+		Name:           constraintName(constraint.Name),
+		Columns:        indexedColumnsFromAst(constraint.IndexedColumns),
+		ConflictClause: conflictActionFromAst(constraint.ConflictClause),
+		AutoIncrement:  constraint.AutoIncrement != nil,
+	}
+}
+
+func (pk *PrimaryKey) Eq(other *PrimaryKey) bool {
+	if pk == nil || other == nil {
+		return pk == other
+	}
+
+	return pk.Name == other.Name &&
+		slices.EqualFunc(pk.Columns, other.Columns, IndexedColumn.Eq) &&
+		pk.ConflictClause == other.ConflictClause &&
+		pk.AutoIncrement == other.AutoIncrement
+}
+
+type Unique struct {
+	Node Node
+
+	Name    string
+	Columns []IndexedColumn
+}
+
+func UniqueFromColumnConstraint(column *ast.ColumnDefinition, constraint *ast.ColumnConstraint_Unique) *Unique {
+	return &Unique{
+		Node:    constraint,
+		Name:    constraintName(constraint.Name),
+		Columns: []IndexedColumn{{Name: column.ColumnName.Canonical(), Order: OrderAscending}},
+	}
+}
+
+func UniqueFromTableConstraint(constraint *ast.TableConstraint_Unique) *Unique {
+	return &Unique{
+		Node:    constraint,
+		Name:    constraintName(constraint.Name),
+		Columns: indexedColumnsFromAst(constraint.IndexedColumns),
+	}
+}
+
+func (u *Unique) Eq(other *Unique) bool {
+	return u.Name == other.Name && slices.EqualFunc(u.Columns, other.Columns, IndexedColumn.Eq)
 }
 
 type ForeignKey struct {
+	Node Node
+
+	Name           string
+	Columns        []string
+	ForeignTable   string
+	ForeignColumns []string
+	OnDelete       ForeignKeyAction
+	OnUpdate       ForeignKeyAction
+	// Deferred is set when the constraint is DEFERRABLE INITIALLY DEFERRED,
+	// every other form is checked immediately.
+	Deferred bool
+}
+
+func foreignKeyFromClause(node Node, name *ast.ConstraintName, columns []string, clause *ast.ForeignKeyClause) *ForeignKey {
+	fk := &ForeignKey{
+		Node:         node,
+		Name:         constraintName(name),
+		Columns:      columns,
+		ForeignTable: clause.ForeignTable.ObjectName.Canonical(),
+	}
+
+	for _, column := range clause.ForeignColumns {
+		fk.ForeignColumns = append(fk.ForeignColumns, column.Canonical())
+	}
+
+	for _, action := range clause.Actions {
+		switch a := action.(type) {
+		case *ast.ForeignKeyDeleteAction:
+			fk.OnDelete = foreignKeyActionFromAst(a.Action)
+		case *ast.ForeignKeyUpdateAction:
+			fk.OnUpdate = foreignKeyActionFromAst(a.Action)
+		}
+	}
+
+	if d := clause.Deferrable; d != nil && d.NotKeyword == nil && d.Deferrable != nil {
+		fk.Deferred = strings.EqualFold(d.Deferrable.Text, "DEFERRED")
+	}
+
+	return fk
+}
+
+func ForeignKeyFromColumnConstraint(column *ast.ColumnDefinition, constraint *ast.ColumnConstraint_ForeignKey) *ForeignKey {
+	return foreignKeyFromClause(constraint, constraint.Name, []string{column.ColumnName.Canonical()}, &constraint.FkClause)
+}
+
+func ForeignKeyFromTableConstraint(constraint *ast.TableConstraint_ForeignKey) *ForeignKey {
+	columns := []string{}
+	for _, column := range constraint.Columns {
+		columns = append(columns, column.Canonical())
+	}
+	return foreignKeyFromClause(constraint, constraint.Name, columns, &constraint.FkClause)
+}
+
+func (fk *ForeignKey) Eq(other *ForeignKey) bool {
+	return fk.Name == other.Name &&
+		slices.Equal(fk.Columns, other.Columns) &&
+		fk.ForeignTable == other.ForeignTable &&
+		slices.Equal(fk.ForeignColumns, other.ForeignColumns) &&
+		fk.OnDelete == other.OnDelete &&
+		fk.OnUpdate == other.OnUpdate &&
+		fk.Deferred == other.Deferred
+}
+
+type Check struct {
+	Node Node
+
+	Name string
+	Expr ast.Expr
+}
+
+func CheckFromTableConstraint(constraint *ast.TableConstraint_Check) *Check {
+	return &Check{
+		Node: constraint,
+		Name: constraintName(constraint.Name),
+		Expr: constraint.Expr,
+	}
+}
+
+func (c *Check) Eq(other *Check) bool {
+	return c.Name == other.Name && ast.CheckPtr(c.Expr, other.Expr)
 }
@@ -0,0 +1,413 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+var (
+	ErrNoSuchObject    = errors.New("no such object")
+	ErrObjectExists    = errors.New("object already exists")
+	ErrNoSuchColumn    = errors.New("no such column")
+	ErrDuplicateColumn = errors.New("duplicate column name")
+)
+
+// Interpreter applies statements to the data definitions of a database the
+// way sqlite would, without a database. Replaying the migrations of a project
+// through it gives the definitions that the migrations produce.
+//
+// The definitions are kept as the CREATE statements of the objects, in the
+// order they were created, statements that don't change the definitions,
+// such as PRAGMA, INSERT or SELECT, are ignored and so are temporary objects.
+// Renaming a table or a column renames it wherever it is referenced by name,
+// by keys, foreign keys, indexes and triggers, but not within expressions,
+// views or the body of a trigger, which are kept as they were written.
+type Interpreter struct {
+	statements []ast.Statement
+}
+
+// Replay applies every statement to empty data definitions, see Interpreter.
+func Replay(statements []ast.Statement) (*Interpreter, error) {
+	interpreter := &Interpreter{}
+	for _, statement := range statements {
+		if err := interpreter.Apply(statement); err != nil {
+			return nil, err
+		}
+	}
+	return interpreter, nil
+}
+
+// Statements are the CREATE statements of every object, in the order they
+// were created.
+func (in *Interpreter) Statements() []ast.Statement {
+	return slices.Clone(in.statements)
+}
+
+func (in *Interpreter) Schema() *Schema {
+	return FromStatements(in.statements)
+}
+
+// Apply applies the statement, a statement that sqlite would reject, such as
+// creating a table that already exists, returns an error and is not applied.
+func (in *Interpreter) Apply(statement ast.Statement) error {
+	switch stmt := statement.(type) {
+	case *ast.CreateTable, *ast.CreateVirtualTable, *ast.CreateView, *ast.CreateIndex, *ast.CreateTrigger:
+		return in.create(stmt)
+	case *ast.DropTable:
+		return in.drop(objectTable, stmt.IfExists != nil, &stmt.TableIdentifier)
+	case *ast.DropIndex:
+		return in.drop(objectIndex, stmt.IfExists != nil, &stmt.IndexIdentifier)
+	case *ast.DropView:
+		return in.drop(objectView, stmt.IfExists != nil, &stmt.ViewIdentifier)
+	case *ast.DropTrigger:
+		return in.drop(objectTrigger, stmt.IfExists != nil, &stmt.TriggerIdentifier)
+	case *ast.AlterTable:
+		return in.alterTable(stmt)
+	}
+	return nil
+}
+
+type objectKind string
+
+const (
+	objectTable   objectKind = "table"
+	objectIndex   objectKind = "index"
+	objectView    objectKind = "view"
+	objectTrigger objectKind = "trigger"
+)
+
+// object describes the object a CREATE statement creates.
+type object struct {
+	Kind objectKind
+	Name string
+	// Schema is the canonical name of the schema of the object
+	Schema string
+	// Table is the canonical, qualified, name of the table an index or
+	// trigger is on
+	Table string
+}
+
+func objectOf(statement ast.Statement) object {
+	switch stmt := statement.(type) {
+	case *ast.CreateTable:
+		return newObject(objectTable, stmt.TableIdentifier, "")
+	case *ast.CreateVirtualTable:
+		return newObject(objectTable, &stmt.TableIdentifier, "")
+	case *ast.CreateView:
+		return newObject(objectView, &stmt.ViewIdentifier, "")
+	case *ast.CreateIndex:
+		return newObject(objectIndex, &stmt.IndexIdentifier, stmt.OnTable.Text)
+	case *ast.CreateTrigger:
+		return newObject(objectTrigger, &stmt.TriggerIdentifier, stmt.OnTable.ObjectName.Text)
+	}
+	return object{}
+}
+
+func newObject(kind objectKind, ident *ast.CatalogObjectIdentifier, table string) object {
+	obj := object{Kind: kind, Name: ident.Canonical(), Schema: schemaName(ident.SchemaName)}
+	if table != "" {
+		obj.Table = ast.QualifiedName(ident.SchemaName, table)
+	}
+	return obj
+}
+
+// conflicts reports whether the objects can't both exist, tables, indexes and
+// views share their names, triggers are named apart from them.
+func (obj object) conflicts(other object) bool {
+	return obj.Name == other.Name && (obj.Kind == objectTrigger) == (other.Kind == objectTrigger)
+}
+
+func (in *Interpreter) find(match func(object) bool) int {
+	return slices.IndexFunc(in.statements, func(statement ast.Statement) bool {
+		return match(objectOf(statement))
+	})
+}
+
+func (in *Interpreter) create(statement ast.Statement) error {
+	if ast.IsTemporary(statement) {
+		return nil
+	}
+
+	created := objectOf(statement)
+	if in.find(created.conflicts) >= 0 {
+		if hasIfNotExists(statement) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s %s", ErrObjectExists, created.Kind, created.Name)
+	}
+
+	if created.Table != "" {
+		onTable := func(obj object) bool {
+			return obj.Name == created.Table && (obj.Kind == objectTable || (created.Kind == objectTrigger && obj.Kind == objectView))
+		}
+		if in.find(onTable) < 0 {
+			return fmt.Errorf("%w: %s %s is on %s which does not exist", ErrNoSuchObject, created.Kind, created.Name, created.Table)
+		}
+	}
+
+	in.statements = append(in.statements, statement)
+	return nil
+}
+
+func hasIfNotExists(statement ast.Statement) bool {
+	switch stmt := statement.(type) {
+	case *ast.CreateTable:
+		return stmt.IfNotExist != nil
+	case *ast.CreateVirtualTable:
+		return stmt.IfNotExist != nil
+	case *ast.CreateView:
+		return stmt.IfNotExists != nil
+	case *ast.CreateIndex:
+		return stmt.IfNotExists != nil
+	case *ast.CreateTrigger:
+		return stmt.IfNotExists != nil
+	}
+	return false
+}
+
+// drop drops the object, dropping a table drops its indexes and triggers.
+func (in *Interpreter) drop(kind objectKind, ifExists bool, ident *ast.CatalogObjectIdentifier) error {
+	name := ident.Canonical()
+	i := in.find(func(obj object) bool { return obj.Kind == kind && obj.Name == name })
+	if i < 0 {
+		if ifExists || ident.InTempSchema() {
+			return nil
+		}
+		return fmt.Errorf("%w: %s %s", ErrNoSuchObject, kind, name)
+	}
+
+	in.statements = slices.Delete(in.statements, i, i+1)
+	if kind == objectTable {
+		in.statements = slices.DeleteFunc(in.statements, func(statement ast.Statement) bool {
+			obj := objectOf(statement)
+			return (obj.Kind == objectIndex || obj.Kind == objectTrigger) && obj.Table == name
+		})
+	}
+	return nil
+}
+
+func (in *Interpreter) alterTable(alter *ast.AlterTable) error {
+	name := alter.TableIdentifier.Canonical()
+	i := in.find(func(obj object) bool { return obj.Kind == objectTable && obj.Name == name })
+	if i < 0 {
+		if alter.TableIdentifier.InTempSchema() {
+			return nil
+		}
+		return fmt.Errorf("%w: table %s", ErrNoSuchObject, name)
+	}
+
+	if rename, ok := alter.Alteration.(*ast.RenameTable); ok {
+		return in.renameTable(i, rename.NewTableName)
+	}
+
+	table, ok := in.statements[i].(*ast.CreateTable)
+	if !ok {
+		return fmt.Errorf("virtual table %s may only be renamed", name)
+	}
+
+	switch alteration := alter.Alteration.(type) {
+	case *ast.AddColumn:
+		if findColumn(table, alteration.ColumnDefinition.ColumnName) >= 0 {
+			return fmt.Errorf("%w: %s.%s", ErrDuplicateColumn, name, alteration.ColumnDefinition.ColumnName.Canonical())
+		}
+		table = cloneTable(table)
+		table.TableDefinition.ColumnDefinitions = append(table.TableDefinition.ColumnDefinitions, alteration.ColumnDefinition)
+		in.statements[i] = table
+	case *ast.DropColumn:
+		column := findColumn(table, alteration.ColumnName)
+		if column < 0 {
+			return fmt.Errorf("%w: %s.%s", ErrNoSuchColumn, name, alteration.ColumnName.Canonical())
+		}
+		table = cloneTable(table)
+		table.TableDefinition.ColumnDefinitions = slices.Delete(table.TableDefinition.ColumnDefinitions, column, column+1)
+		in.statements[i] = table
+	case *ast.RenameColumn:
+		return in.renameColumn(i, alteration.ColumnName, alteration.NewColumnName)
+	}
+	return nil
+}
+
+func findColumn(table *ast.CreateTable, name ast.Identifier) int {
+	return slices.IndexFunc(table.TableDefinition.ColumnDefinitions, func(column ast.ColumnDefinition) bool {
+		return column.ColumnName.Canonical() == name.Canonical()
+	})
+}
+
+// cloneTable copies the table down to its column definitions and constraints,
+// so that altering it leaves the statement it was created by untouched.
+func cloneTable(table *ast.CreateTable) *ast.CreateTable {
+	clone := *table
+	definition := *table.TableDefinition
+	definition.ColumnDefinitions = slices.Clone(definition.ColumnDefinitions)
+	for i := range definition.ColumnDefinitions {
+		definition.ColumnDefinitions[i].ColumnConstraints = slices.Clone(definition.ColumnDefinitions[i].ColumnConstraints)
+	}
+	definition.TableConstraints = slices.Clone(definition.TableConstraints)
+	clone.TableDefinition = &definition
+	return &clone
+}
+
+func (in *Interpreter) renameTable(i int, newName ast.Identifier) error {
+	renamed := objectOf(in.statements[i])
+	newIdent := ast.MakeCatalogObjectIdentifier(schemaIdentifier(in.statements[i]), newName)
+	if in.find(object{Kind: objectTable, Name: newIdent.Canonical()}.conflicts) >= 0 {
+		return fmt.Errorf("%w: %s", ErrObjectExists, newIdent.Canonical())
+	}
+
+	switch stmt := in.statements[i].(type) {
+	case *ast.CreateTable:
+		table := *stmt
+		table.TableIdentifier = newIdent
+		in.statements[i] = &table
+	case *ast.CreateVirtualTable:
+		table := *stmt
+		table.TableIdentifier = *newIdent
+		in.statements[i] = &table
+	}
+
+	for j, statement := range in.statements {
+		obj := objectOf(statement)
+		switch stmt := statement.(type) {
+		case *ast.CreateIndex:
+			if obj.Table == renamed.Name {
+				index := *stmt
+				index.OnTable = newName
+				in.statements[j] = &index
+			}
+		case *ast.CreateTrigger:
+			if obj.Table == renamed.Name {
+				trigger := *stmt
+				trigger.OnTable = *ast.MakeCatalogObjectIdentifier(trigger.OnTable.SchemaName, newName)
+				in.statements[j] = &trigger
+			}
+		case *ast.CreateTable:
+			if obj.Schema == renamed.Schema {
+				in.statements[j] = renameReferences(stmt, renamed.Name, func(fk *ast.ForeignKeyClause) {
+					fk.ForeignTable = *ast.MakeCatalogObjectIdentifier(fk.ForeignTable.SchemaName, newName)
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (in *Interpreter) renameColumn(i int, from, to ast.Identifier) error {
+	table := in.statements[i].(*ast.CreateTable)
+	column := findColumn(table, from)
+	if column < 0 {
+		return fmt.Errorf("%w: %s.%s", ErrNoSuchColumn, table.TableIdentifier.Canonical(), from.Canonical())
+	}
+	if findColumn(table, to) >= 0 {
+		return fmt.Errorf("%w: %s.%s", ErrDuplicateColumn, table.TableIdentifier.Canonical(), to.Canonical())
+	}
+
+	renamed := objectOf(table)
+	rename := func(ident *ast.Identifier) {
+		if ident.Canonical() == from.Canonical() {
+			*ident = to
+		}
+	}
+
+	table = cloneTable(table)
+	table.TableDefinition.ColumnDefinitions[column].ColumnName = to
+	for j, constraint := range table.TableDefinition.TableConstraints {
+		switch c := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			key := *c
+			key.IndexedColumns = renameIndexedColumns(key.IndexedColumns, rename)
+			table.TableDefinition.TableConstraints[j] = &key
+		case *ast.TableConstraint_Unique:
+			unique := *c
+			unique.IndexedColumns = renameIndexedColumns(unique.IndexedColumns, rename)
+			table.TableDefinition.TableConstraints[j] = &unique
+		case *ast.TableConstraint_ForeignKey:
+			fk := *c
+			fk.Columns = slices.Clone(fk.Columns)
+			for k := range fk.Columns {
+				rename(&fk.Columns[k])
+			}
+			table.TableDefinition.TableConstraints[j] = &fk
+		}
+	}
+	in.statements[i] = table
+
+	for j, statement := range in.statements {
+		obj := objectOf(statement)
+		switch stmt := statement.(type) {
+		case *ast.CreateIndex:
+			if obj.Table == renamed.Name {
+				index := *stmt
+				index.IndexedColumns = renameIndexedColumns(index.IndexedColumns, rename)
+				in.statements[j] = &index
+			}
+		case *ast.CreateTable:
+			if obj.Schema == renamed.Schema {
+				in.statements[j] = renameReferences(stmt, renamed.Name, func(fk *ast.ForeignKeyClause) {
+					fk.ForeignColumns = slices.Clone(fk.ForeignColumns)
+					for k := range fk.ForeignColumns {
+						rename(&fk.ForeignColumns[k])
+					}
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// renameIndexedColumns renames the indexed columns that are a plain column name.
+func renameIndexedColumns(columns []ast.IndexedColumn, rename func(*ast.Identifier)) []ast.IndexedColumn {
+	columns = slices.Clone(columns)
+	for i, column := range columns {
+		switch subject := column.Subject.(type) {
+		case *ast.Identifier:
+			ident := *subject
+			rename(&ident)
+			columns[i].Subject = &ident
+		case *ast.ColumnName:
+			name := *subject
+			rename(&name.Column)
+			columns[i].Subject = &name
+		}
+	}
+	return columns
+}
+
+// renameReferences applies rename to a copy of every foreign key of the table
+// that references the table named referenced.
+func renameReferences(table *ast.CreateTable, referenced string, rename func(*ast.ForeignKeyClause)) *ast.CreateTable {
+	references := func(fk *ast.ForeignKeyClause) bool {
+		return ast.QualifiedName(table.TableIdentifier.SchemaName, fk.ForeignTable.ObjectName.Text) == referenced
+	}
+
+	table = cloneTable(table)
+	for i := range table.TableDefinition.ColumnDefinitions {
+		constraints := table.TableDefinition.ColumnDefinitions[i].ColumnConstraints
+		for j, constraint := range constraints {
+			if c, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok && references(&c.FkClause) {
+				fk := *c
+				rename(&fk.FkClause)
+				constraints[j] = &fk
+			}
+		}
+	}
+	for i, constraint := range table.TableDefinition.TableConstraints {
+		if c, ok := constraint.(*ast.TableConstraint_ForeignKey); ok && references(&c.FkClause) {
+			fk := *c
+			rename(&fk.FkClause)
+			table.TableDefinition.TableConstraints[i] = &fk
+		}
+	}
+	return table
+}
+
+func schemaIdentifier(statement ast.Statement) *ast.Identifier {
+	switch stmt := statement.(type) {
+	case *ast.CreateTable:
+		return stmt.TableIdentifier.SchemaName
+	case *ast.CreateVirtualTable:
+		return stmt.TableIdentifier.SchemaName
+	}
+	return nil
+}
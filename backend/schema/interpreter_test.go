@@ -0,0 +1,111 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func parse(t *testing.T, source string) []ast.Statement {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(source),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statements
+}
+
+func TestReplayAltersTables(t *testing.T) {
+	interpreter, err := schema.Replay(parse(t, `
+		PRAGMA foreign_keys = ON;
+		BEGIN;
+		CREATE TABLE users (id integer PRIMARY KEY, name text, age int);
+		CREATE TABLE posts (id integer PRIMARY KEY, author_id integer REFERENCES users(id));
+		CREATE INDEX users_name ON users (name);
+		CREATE TEMP TABLE scratch (a text);
+		INSERT INTO users (name) VALUES ('a');
+		ALTER TABLE users ADD COLUMN email text;
+		ALTER TABLE users DROP COLUMN age;
+		ALTER TABLE users RENAME COLUMN name TO full_name;
+		ALTER TABLE users RENAME COLUMN id TO user_id;
+		ALTER TABLE users RENAME TO people;
+		CREATE TABLE IF NOT EXISTS people (id integer);
+		COMMIT;`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := interpreter.Schema()
+	if len(s.Tables) != 2 {
+		t.Fatalf("expected the tables people and posts, got %+v", s.Tables)
+	}
+
+	people, ok := s.Table("people")
+	if !ok {
+		t.Fatal("expected the table people")
+	}
+	columns := []string{}
+	for _, column := range people.Columns {
+		columns = append(columns, column.Name)
+	}
+	if len(columns) != 3 || columns[0] != "user_id" || columns[1] != "full_name" || columns[2] != "email" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+
+	if len(s.Indexes) != 1 || s.Indexes[0].Table != "people" || s.Indexes[0].Columns[0].Name != "full_name" {
+		t.Errorf("expected the index to follow the renames, got %+v", s.Indexes)
+	}
+
+	posts, _ := s.Table("posts")
+	if fk := posts.ForeignKeys[0]; fk.ForeignTable != "people" || fk.ForeignColumns[0] != "user_id" {
+		t.Errorf("expected the foreign key to follow the renames, got %+v", fk)
+	}
+}
+
+func TestReplayDropsObjects(t *testing.T) {
+	interpreter, err := schema.Replay(parse(t, `
+		CREATE TABLE t (a text);
+		CREATE INDEX t_a ON t (a);
+		CREATE VIEW v AS SELECT a FROM t;
+		CREATE TRIGGER t_log AFTER INSERT ON t BEGIN SELECT 1; END;
+		DROP TABLE t;
+		DROP VIEW IF EXISTS v;
+		DROP INDEX IF EXISTS t_a;`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statements := interpreter.Statements(); len(statements) != 0 {
+		t.Errorf("expected every object to be dropped, got %d statements", len(statements))
+	}
+}
+
+func TestReplayRejectsInvalidStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    error
+	}{
+		{name: "table exists", source: "CREATE TABLE t (a text); CREATE INDEX t ON t (a);", err: schema.ErrObjectExists},
+		{name: "no such table", source: "DROP TABLE t;", err: schema.ErrNoSuchObject},
+		{name: "index on missing table", source: "CREATE INDEX i ON t (a);", err: schema.ErrNoSuchObject},
+		{name: "no such column", source: "CREATE TABLE t (a text); ALTER TABLE t DROP COLUMN b;", err: schema.ErrNoSuchColumn},
+		{name: "duplicate column", source: "CREATE TABLE t (a text); ALTER TABLE t ADD COLUMN A int;", err: schema.ErrDuplicateColumn},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := schema.Replay(parse(t, test.source))
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -1,12 +1,24 @@
 package schema
 
+import (
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
 type Storage int
 
 const (
-	StorageStored Storage = iota
-	StorageVirtual
+	StorageVirtual Storage = iota
+	StorageStored
 )
 
+func storageFromAst(storage any) Storage {
+	if keyword, ok := storage.(*ast.Keyword); ok && keyword != nil && strings.EqualFold(keyword.Text, "STORED") {
+		return StorageStored
+	}
+	return StorageVirtual
+}
+
 type Order int
 
 const (
@@ -15,6 +27,14 @@ const (
 	OrderAscending  Order = 1
 )
 
+// orderFromAst treats a missing order as ascending, which is the default.
+func orderFromAst(order *ast.Keyword) Order {
+	if order != nil && strings.EqualFold(order.Text, "DESC") {
+		return OrderDescending
+	}
+	return OrderAscending
+}
+
 type ConflictAction int
 
 const (
@@ -25,3 +45,97 @@ const (
 	ConflictActionIgnore
 	ConflictActionReplace
 )
+
+var conflictActions = map[string]ConflictAction{
+	"ROLLBACK": ConflictActionRollback,
+	"ABORT":    ConflictActionAbort,
+	"FAIL":     ConflictActionFail,
+	"IGNORE":   ConflictActionIgnore,
+	"REPLACE":  ConflictActionReplace,
+}
+
+func conflictActionFromAst(clause *ast.ConflictClause) ConflictAction {
+	if clause == nil {
+		return ConflictActionNone
+	}
+	return conflictActions[strings.ToUpper(clause.Action.Text)]
+}
+
+type ForeignKeyAction int
+
+const (
+	ForeignKeyActionNoAction ForeignKeyAction = iota
+	ForeignKeyActionRestrict
+	ForeignKeyActionSetNull
+	ForeignKeyActionSetDefault
+	ForeignKeyActionCascade
+)
+
+var foreignKeyActionNames = map[ForeignKeyAction]string{
+	ForeignKeyActionNoAction:   "NO ACTION",
+	ForeignKeyActionRestrict:   "RESTRICT",
+	ForeignKeyActionSetNull:    "SET NULL",
+	ForeignKeyActionSetDefault: "SET DEFAULT",
+	ForeignKeyActionCascade:    "CASCADE",
+}
+
+func (a ForeignKeyAction) String() string {
+	return foreignKeyActionNames[a]
+}
+
+func foreignKeyActionFromAst(do ast.ForeignKeyActionDo) ForeignKeyAction {
+	switch do.(type) {
+	case *ast.Restrict:
+		return ForeignKeyActionRestrict
+	case *ast.SetNull:
+		return ForeignKeyActionSetNull
+	case *ast.SetDefault:
+		return ForeignKeyActionSetDefault
+	case *ast.Cascade:
+		return ForeignKeyActionCascade
+	default:
+		return ForeignKeyActionNoAction
+	}
+}
+
+// Affinity is the type preference of a column, values stored in a column are
+// converted to its affinity where possible.
+type Affinity int
+
+const (
+	AffinityBlob Affinity = iota
+	AffinityText
+	AffinityNumeric
+	AffinityInteger
+	AffinityReal
+)
+
+var affinityNames = map[Affinity]string{
+	AffinityBlob:    "BLOB",
+	AffinityText:    "TEXT",
+	AffinityNumeric: "NUMERIC",
+	AffinityInteger: "INTEGER",
+	AffinityReal:    "REAL",
+}
+
+func (a Affinity) String() string {
+	return affinityNames[a]
+}
+
+// AffinityOf determines the affinity of a declared type name following the
+// rules in order, see https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func AffinityOf(typeName string) Affinity {
+	name := strings.ToUpper(typeName)
+	switch {
+	case strings.Contains(name, "INT"):
+		return AffinityInteger
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "CLOB"), strings.Contains(name, "TEXT"):
+		return AffinityText
+	case name == "", strings.Contains(name, "BLOB"):
+		return AffinityBlob
+	case strings.Contains(name, "REAL"), strings.Contains(name, "FLOA"), strings.Contains(name, "DOUB"):
+		return AffinityReal
+	default:
+		return AffinityNumeric
+	}
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"slices"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+type Index struct {
+	Node *ast.CreateIndex
+
+	Schema  string
+	Name    string
+	Table   string
+	Unique  bool
+	Columns []IndexedColumn
+	Where   ast.Expr
+}
+
+func IndexFromAst(createIndex *ast.CreateIndex) *Index {
+	return &Index{
+		Node:    createIndex,
+		Schema:  schemaName(createIndex.IndexIdentifier.SchemaName),
+		Name:    createIndex.IndexIdentifier.ObjectName.Canonical(),
+		Table:   createIndex.OnTable.Canonical(),
+		Unique:  createIndex.UniqueKeyword != nil,
+		Columns: indexedColumnsFromAst(createIndex.IndexedColumns),
+		Where:   createIndex.WhereExpr,
+	}
+}
+
+func (index *Index) Eq(other *Index) bool {
+	return index.Schema == other.Schema &&
+		index.Name == other.Name &&
+		index.Table == other.Table &&
+		index.Unique == other.Unique &&
+		slices.EqualFunc(index.Columns, other.Columns, IndexedColumn.Eq) &&
+		ast.CheckPtr(index.Where, other.Where)
+}
+
+// Schema is the normalized model of the tables, indexes and virtual tables
+// declared by a sequence of statements, other statements are ignored.
+//
+// Every name in the model is canonical, see ast.Identifier.Canonical, the
+// name as it was written is kept by the node it came from. Objects are in the
+// schema they were qualified with, or in main, and tables are referenced by
+// name from objects in the same schema.
+type Schema struct {
+	Tables        []*Table
+	Indexes       []*Index
+	VirtualTables []*VirtualTable
+}
+
+func schemaName(schema *ast.Identifier) string {
+	if schema == nil {
+		return "main"
+	}
+	return schema.Canonical()
+}
+
+func FromStatements(statements []ast.Statement) *Schema {
+	schema := &Schema{}
+
+	for _, statement := range statements {
+		switch stmt := statement.(type) {
+		case *ast.CreateTable:
+			schema.Tables = append(schema.Tables, TableFromAst(stmt))
+		case *ast.CreateIndex:
+			schema.Indexes = append(schema.Indexes, IndexFromAst(stmt))
+		case *ast.CreateVirtualTable:
+			schema.VirtualTables = append(schema.VirtualTables, VirtualTableFromAst(stmt))
+		}
+	}
+
+	// the tables a module stores a virtual table in are created by the module
+	schema.Tables = slices.DeleteFunc(schema.Tables, func(table *Table) bool {
+		return slices.ContainsFunc(schema.VirtualTables, func(vt *VirtualTable) bool { return vt.IsShadowTable(table) })
+	})
+
+	return schema
+}
+
+// Table finds the table by its name as it would be written in a statement,
+// optionally qualified by its schema.
+func (schema *Schema) Table(name string) (*Table, bool) {
+	name = ast.CanonicalName(name)
+	index := slices.IndexFunc(schema.Tables, func(table *Table) bool {
+		return (table.Schema == "main" && table.Name == name) || table.Schema+"."+table.Name == name
+	})
+	if index < 0 {
+		return nil, false
+	}
+	return schema.Tables[index], true
+}
+
+// IndexesOn returns the indexes created on the table.
+func (schema *Schema) IndexesOn(table *Table) []*Index {
+	result := []*Index{}
+	for _, index := range schema.Indexes {
+		if index.Schema == table.Schema && index.Table == table.Name {
+			result = append(result, index)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package schema_test
+
+import (
+	"testing"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func schemaFromString(t *testing.T, source string) *schema.Schema {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(source),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema.FromStatements(statements)
+}
+
+func TestTableLiftsColumnConstraints(t *testing.T) {
+	s := schemaFromString(t, `
+		CREATE TABLE posts (
+			id integer PRIMARY KEY AUTOINCREMENT,
+			slug varchar(64) NOT NULL UNIQUE,
+			user_id integer REFERENCES users(id) ON DELETE CASCADE
+		);`)
+
+	posts, ok := s.Table("posts")
+	if !ok {
+		t.Fatal("expected table posts")
+	}
+
+	if posts.PrimaryKey == nil || len(posts.PrimaryKey.Columns) != 1 || posts.PrimaryKey.Columns[0].Name != "id" || !posts.PrimaryKey.AutoIncrement {
+		t.Fatalf("expected an autoincrement primary key on id, got %+v", posts.PrimaryKey)
+	}
+
+	if len(posts.Uniques) != 1 || posts.Uniques[0].Columns[0].Name != "slug" {
+		t.Fatalf("expected a unique key on slug, got %+v", posts.Uniques)
+	}
+
+	if len(posts.ForeignKeys) != 1 || posts.ForeignKeys[0].ForeignTable != "users" || posts.ForeignKeys[0].OnDelete != schema.ForeignKeyActionCascade {
+		t.Fatalf("expected a cascading foreign key to users, got %+v", posts.ForeignKeys)
+	}
+
+	slug, ok := posts.Column("slug")
+	if !ok {
+		t.Fatal("expected column slug")
+	}
+	if !slug.NotNull || slug.Affinity() != schema.AffinityText {
+		t.Fatalf("expected slug to be NOT NULL with TEXT affinity, got %+v", slug)
+	}
+}
+
+func TestTableConstraintsEq(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		eq   bool
+	}{
+		{
+			name: "column and table primary key",
+			a:    "CREATE TABLE t (id integer PRIMARY KEY, b text);",
+			b:    "CREATE TABLE t (id integer, b text, PRIMARY KEY (id));",
+			eq:   true,
+		},
+		{
+			name: "column and table foreign key",
+			a:    "CREATE TABLE t (id integer, p integer REFERENCES t(id));",
+			b:    "CREATE TABLE t (id integer, p integer, FOREIGN KEY (p) REFERENCES t(id));",
+			eq:   true,
+		},
+		{
+			name: "foreign key order",
+			a:    "CREATE TABLE t (a integer REFERENCES x(id), b integer REFERENCES y(id));",
+			b:    "CREATE TABLE t (a integer, b integer, FOREIGN KEY (b) REFERENCES y(id), FOREIGN KEY (a) REFERENCES x(id));",
+			eq:   true,
+		},
+		{
+			name: "descending primary key",
+			a:    "CREATE TABLE t (id integer PRIMARY KEY);",
+			b:    "CREATE TABLE t (id integer PRIMARY KEY DESC);",
+			eq:   false,
+		},
+		{
+			name: "column and table unique",
+			a:    "CREATE TABLE t (id integer, email text UNIQUE);",
+			b:    "CREATE TABLE t (id integer, email text, UNIQUE (email));",
+			eq:   true,
+		},
+		{
+			name: "unique over two columns",
+			a:    "CREATE TABLE t (a text UNIQUE, b text UNIQUE);",
+			b:    "CREATE TABLE t (a text, b text, UNIQUE (a, b));",
+			eq:   false,
+		},
+		{
+			name: "added unique",
+			a:    "CREATE TABLE t (a text);",
+			b:    "CREATE TABLE t (a text UNIQUE);",
+			eq:   false,
+		},
+		{
+			name: "changed delete action",
+			a:    "CREATE TABLE t (p integer REFERENCES t(id) ON DELETE CASCADE);",
+			b:    "CREATE TABLE t (p integer REFERENCES t(id) ON DELETE SET NULL);",
+			eq:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := schemaFromString(t, test.a).Tables[0]
+			b := schemaFromString(t, test.b).Tables[0]
+
+			if a.ConstraintsEq(b) != test.eq {
+				t.Fatalf("expected ConstraintsEq to be %v", test.eq)
+			}
+		})
+	}
+}
+
+func TestAffinityOf(t *testing.T) {
+	tests := map[string]schema.Affinity{
+		"INT":              schema.AffinityInteger,
+		"tinyint":          schema.AffinityInteger,
+		"varchar":          schema.AffinityText,
+		"CLOB":             schema.AffinityText,
+		"blob":             schema.AffinityBlob,
+		"":                 schema.AffinityBlob,
+		"double":           schema.AffinityReal,
+		"FLOAT":            schema.AffinityReal,
+		"decimal":          schema.AffinityNumeric,
+		"boolean":          schema.AffinityNumeric,
+		"CHARINT":          schema.AffinityInteger,
+		"floating point":   schema.AffinityInteger,
+		"datetime":         schema.AffinityNumeric,
+		"nvarchar":         schema.AffinityText,
+		"double precision": schema.AffinityReal,
+	}
+
+	for name, want := range tests {
+		if got := schema.AffinityOf(name); got != want {
+			t.Errorf("AffinityOf(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -1,18 +1,128 @@
 package schema
 
-import "woodybriggs/justmigrate/core/ast"
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+)
 
 type Table struct {
 	Node *ast.CreateTable
 
-	Name    string
-	Columns map[string]*Column
+	Schema       string
+	Name         string
+	Columns      []*Column
+	PrimaryKey   *PrimaryKey
+	Uniques      []*Unique
+	ForeignKeys  []*ForeignKey
+	Checks       []*Check
+	Strict       bool
+	WithoutRowId bool
 }
 
+// TableFromAst normalizes the table definition, the PRIMARY KEY, UNIQUE and
+// REFERENCES column constraints are lifted to the table so that a key reads
+// the same whether it was declared on the column or on the table.
 func TableFromAst(createTable *ast.CreateTable) *Table {
+	table := &Table{
+		Node:   createTable,
+		Schema: schemaName(createTable.TableIdentifier.SchemaName),
+		Name:   createTable.TableIdentifier.ObjectName.Canonical(),
+	}
+
+	if options := createTable.TableOptions; options != nil {
+		table.Strict = options.Strict != nil
+		table.WithoutRowId = options.WithoutRowId != nil
+	}
+
+	if createTable.TableDefinition == nil {
+		return table
+	}
+
+	columns := createTable.TableDefinition.ColumnDefinitions
+	for i := range columns {
+		colDef := &columns[i]
+		table.Columns = append(table.Columns, ColumnFromAst(colDef))
+
+		for _, constraint := range colDef.ColumnConstraints {
+			switch typ := constraint.(type) {
+			case *ast.ColumnConstraint_PrimaryKey:
+				table.PrimaryKey = PrimaryKeyFromColumnConstraint(colDef, typ)
+			case *ast.ColumnConstraint_Unique:
+				table.Uniques = append(table.Uniques, UniqueFromColumnConstraint(colDef, typ))
+			case *ast.ColumnConstraint_ForeignKey:
+				table.ForeignKeys = append(table.ForeignKeys, ForeignKeyFromColumnConstraint(colDef, typ))
+			}
+		}
+	}
+
+	for _, constraint := range createTable.TableDefinition.TableConstraints {
+		switch typ := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			table.PrimaryKey = PrimaryKeyFromTableConstraint(typ)
+		case *ast.TableConstraint_Unique:
+			table.Uniques = append(table.Uniques, UniqueFromTableConstraint(typ))
+		case *ast.TableConstraint_ForeignKey:
+			table.ForeignKeys = append(table.ForeignKeys, ForeignKeyFromTableConstraint(typ))
+		case *ast.TableConstraint_Check:
+			table.Checks = append(table.Checks, CheckFromTableConstraint(typ))
+		}
+	}
+
+	return table
+}
+
+func (table *Table) Column(name string) (*Column, bool) {
+	name = ast.CanonicalName(name)
+	index := slices.IndexFunc(table.Columns, func(col *Column) bool { return col.Name == name })
+	if index < 0 {
+		return nil, false
+	}
+	return table.Columns[index], true
+}
+
+// IsRowIdAlias reports whether the column is an alias of the rowid, that is
+// the single column primary key of a rowid table declared exactly as INTEGER.
+// A column level PRIMARY KEY DESC is not an alias, see
+// https://www.sqlite.org/lang_createtable.html#rowid
+func (table *Table) IsRowIdAlias(col *Column) bool {
+	pk := table.PrimaryKey
+	if table.WithoutRowId || pk == nil || len(pk.Columns) != 1 || pk.Columns[0].Name != col.Name {
+		return false
+	}
+	if _, ok := pk.Node.(*ast.ColumnConstraint_PrimaryKey); ok && pk.Columns[0].Order == OrderDescending {
+		return false
+	}
+	return col.Type != nil && len(col.Type.Args) == 0 && strings.EqualFold(col.Type.Name, "INTEGER")
+}
+
+// ConstraintsEq compares the table level constraints, the order
+// of UNIQUE, FOREIGN KEY and CHECK constraints is not significant.
+func (table *Table) ConstraintsEq(other *Table) bool {
+	return table.PrimaryKey.Eq(other.PrimaryKey) &&
+		unorderedEq(table.Uniques, other.Uniques, (*Unique).Eq) &&
+		unorderedEq(table.ForeignKeys, other.ForeignKeys, (*ForeignKey).Eq) &&
+		unorderedEq(table.Checks, other.Checks, (*Check).Eq)
+}
+
+func unorderedEq[T any](a, b []T, eq func(x, y T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
 
-	return &Table{
-		Node: createTable,
-		Name: createTable.TableIdentifier.String(),
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for j, y := range b {
+			if !matched[j] && eq(x, y) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
+	return true
 }
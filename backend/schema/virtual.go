@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+// shadowTables are the suffixes of the tables that the builtin modules store a
+// virtual table in, a virtual table "x" using fts5 is stored in "x_data",
+// "x_idx" and so on.
+var shadowTables = map[string][]string{
+	"fts3":      {"content", "segments", "segdir", "docsize", "stat"},
+	"fts4":      {"content", "segments", "segdir", "docsize", "stat"},
+	"fts5":      {"data", "idx", "content", "docsize", "config"},
+	"rtree":     {"node", "parent", "rowid"},
+	"rtree_i32": {"node", "parent", "rowid"},
+}
+
+// VirtualTable is a table implemented by a module, beyond its name sqlite only
+// knows the arguments the module was created with.
+type VirtualTable struct {
+	Node *ast.CreateVirtualTable
+
+	Schema string
+	Name   string
+	Module string
+	Args   []string
+}
+
+func VirtualTableFromAst(createVirtualTable *ast.CreateVirtualTable) *VirtualTable {
+	args := []string{}
+	for _, arg := range createVirtualTable.ModuleArgs {
+		args = append(args, strings.TrimSpace(arg))
+	}
+
+	return &VirtualTable{
+		Node:   createVirtualTable,
+		Schema: schemaName(createVirtualTable.TableIdentifier.SchemaName),
+		Name:   createVirtualTable.TableIdentifier.ObjectName.Canonical(),
+		Module: createVirtualTable.ModuleName.Canonical(),
+		Args:   args,
+	}
+}
+
+// Eq compares the module and its arguments verbatim.
+func (vt *VirtualTable) Eq(other *VirtualTable) bool {
+	return vt.Schema == other.Schema &&
+		vt.Name == other.Name &&
+		vt.Module == other.Module &&
+		slices.Equal(vt.Args, other.Args)
+}
+
+// Columns are the columns declared by the module arguments, an argument that
+// sets an option, such as content=..., does not declare a column.
+func (vt *VirtualTable) Columns() []string {
+	columns := []string{}
+	for _, arg := range vt.Args {
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		if name := leadingName(strings.TrimPrefix(arg, "+")); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// Option is the value of the option argument name=value, unquoted.
+func (vt *VirtualTable) Option(name string) (string, bool) {
+	for _, arg := range vt.Args {
+		key, value, ok := strings.Cut(arg, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return unquote(strings.TrimSpace(value)), true
+		}
+	}
+	return "", false
+}
+
+// IsShadowTable reports whether the table is one that the module stores the
+// virtual table in.
+func (vt *VirtualTable) IsShadowTable(table *Table) bool {
+	if table.Schema != vt.Schema {
+		return false
+	}
+	suffix, ok := strings.CutPrefix(table.Name, vt.Name+"_")
+	return ok && slices.Contains(shadowTables[vt.Module], suffix)
+}
+
+// leadingName is the canonical name an argument starts with, which may be quoted.
+func leadingName(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return ""
+	}
+
+	closing := map[byte]byte{'"': '"', '\'': '\'', '`': '`', '[': ']'}
+	if end, quoted := closing[arg[0]]; quoted {
+		if i := strings.IndexByte(arg[1:], end); i >= 0 {
+			return ast.CanonicalName(arg[1 : i+1])
+		}
+	}
+
+	return ast.CanonicalName(strings.Fields(arg)[0])
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && strings.ContainsRune(`"'`+"`", rune(value[0])) && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
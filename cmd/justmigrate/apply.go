@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+)
+
+func runApply(args []string) error {
+	var migrationFlags MigrationFlags
+	var dryRun bool
+
+	flags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	migrationFlags.Register(flags)
+	flags.BoolVar(&dryRun, "dry-run", false, "apply the migration to an in-memory copy of the database and check that it reaches the schema")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	db, err := migrationFlags.OpenDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migration, err := PlanMigration(db, migrationFlags.SchemaFile, migrationFlags.TypeComparison, migrationFlags.Temporary)
+	if err != nil {
+		return err
+	}
+	ShowWarnings(migration.Warnings)
+
+	script, err := generator.Generate(migration.Plan)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		if err := DryRun(ctx, db, migration, script); err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stdout, script)
+		fmt.Fprintf(os.Stderr, "dry run: %d operations converge to %s\n", len(diff.Flatten(migration.Plan)), migrationFlags.SchemaFile)
+		return nil
+	}
+
+	if err := db.Apply(ctx, script); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "applied %d operations to %s\n", len(diff.Flatten(migration.Plan)), db.Url())
+	return nil
+}
+
+// DryRun applies the migration script to an in-memory copy of the database,
+// then re-introspects the copy and diffs it against the target schema to
+// prove that the migration converges with no remaining operations.
+func DryRun(ctx context.Context, db *sqlite.Sqlite, migration *Migration, script string) error {
+	scratch, err := db.CloneInMemory(ctx)
+	if err != nil {
+		return err
+	}
+	defer scratch.Close()
+
+	return verify.Converge(ctx, scratch, migration.Target, script, migration.Types)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+)
+
+// writeDatabase creates a database file from the statements, next to a schema
+// file with the contents of schema, and returns both paths.
+func writeDatabase(t *testing.T, statements string, schema string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "database.db")
+	conn, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(statements); err != nil {
+		t.Fatal(err)
+	}
+
+	schemaFile := filepath.Join(dir, "schema.sql")
+	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dbFile, schemaFile
+}
+
+func TestApplyDryRunLeavesDatabaseUntouched(t *testing.T) {
+	dbFile, schemaFile := writeDatabase(t,
+		"CREATE TABLE users (id integer PRIMARY KEY, name text); INSERT INTO users VALUES (1, 'a'), (2, 'b');",
+		"CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL, email text);\n",
+	)
+
+	before, err := os.ReadFile(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runApply([]string{"-db", dbFile, "-schema", schemaFile, "-dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("expected a dry run to leave the database file byte for byte identical")
+	}
+}
+
+func TestApplyDryRunReportsFailure(t *testing.T) {
+	// the rows already in the table break the new unique constraint
+	dbFile, schemaFile := writeDatabase(t,
+		"CREATE TABLE users (id integer PRIMARY KEY, email text); INSERT INTO users VALUES (1, 'a'), (2, 'a');",
+		"CREATE TABLE users (id integer PRIMARY KEY, email text UNIQUE);\n",
+	)
+
+	err := runApply([]string{"-db", dbFile, "-schema", schemaFile, "-dry-run"})
+	if !errors.Is(err, verify.ErrApplyFailed) {
+		t.Errorf("expected %v, got %v", verify.ErrApplyFailed, err)
+	}
+}
+
+func TestApplyDryRunReportsForeignKeyViolations(t *testing.T) {
+	// foreign keys are off while the rows are inserted, the recreation of
+	// posts checks them
+	dbFile, schemaFile := writeDatabase(t, `
+		CREATE TABLE users (id integer PRIMARY KEY);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users (id));
+		INSERT INTO posts VALUES (1, 7);`, `
+CREATE TABLE users (id integer PRIMARY KEY);
+CREATE TABLE posts (id integer PRIMARY KEY, user_id integer NOT NULL REFERENCES users (id));
+`)
+
+	err := runApply([]string{"-db", dbFile, "-schema", schemaFile, "-dry-run"})
+	if !errors.Is(err, sqlite.ErrForeignKeyViolation) {
+		t.Errorf("expected %v, got %v", sqlite.ErrForeignKeyViolation, err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+)
+
+var (
+	ErrCheckWithoutMigrations = errors.New("check requires -migrations")
+)
+
+func runCheck(args []string) error {
+	var migrationsDir string
+	var schemaFile string
+
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.StringVar(&migrationsDir, "migrations", "", "directory of the migrations to replay")
+	flags.StringVar(&schemaFile, "schema", defaultSchemaFile, "schema file the migrations should produce")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if migrationsDir == "" {
+		return ErrCheckWithoutMigrations
+	}
+
+	file, err := os.Open(schemaFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, target, err := AstFromFile(file)
+	if err != nil {
+		return err
+	}
+
+	result, err := verify.Drift(migrationsDir, target)
+	if err != nil {
+		if result != nil {
+			fmt.Fprint(os.Stdout, result.Script)
+		}
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%s produces %s\n", migrationsDir, schemaFile)
+	return nil
+}
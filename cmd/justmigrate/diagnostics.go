@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSarif = "sarif"
+)
+
+// diagnostics is where every command writes its errors and warnings, in the
+// format chosen with -format.
+var diagnostics = &Diagnostics{Format: formatText, Output: os.Stderr}
+
+// Diagnostics writes reports as text or as JSON lines as they are shown, a
+// SARIF log holds every report so it is written by Flush once the command
+// is done.
+//
+// Diagnostics are written to stderr, or the file given with -o, in every
+// format. Stdout belongs to what the commands produce, such as a migration
+// script or the protocol of the language server.
+type Diagnostics struct {
+	Format string
+	Output io.Writer
+	sarif  report.SarifRenderer
+}
+
+func (d *Diagnostics) Validate() error {
+	switch d.Format {
+	case formatText, formatJSON, formatSarif:
+		return nil
+	default:
+		return fmt.Errorf("unknown diagnostics format %q, expected text, json or sarif", d.Format)
+	}
+}
+
+// Open chooses where the diagnostics are written, fileName is the -o flag.
+// The returned file, if any, must be closed once the diagnostics are flushed.
+func (d *Diagnostics) Open(fileName string) (*os.File, error) {
+	if fileName == "" {
+		d.Output = os.Stderr
+		return nil, nil
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	d.Output = file
+	return file, nil
+}
+
+// Show shows the report at its own level, or at level if it has none.
+func (d *Diagnostics) Show(level report.Level, rep report.Report) {
+	level = rep.LevelOr(level)
+	switch d.Format {
+	case formatJSON:
+		renderer := report.JSONRenderer{Level: level}
+		io.WriteString(d.Output, renderer.Render(rep))
+	case formatSarif:
+		d.sarif.Add(level, rep)
+	default:
+		renderer := report.Renderer{}
+		io.WriteString(d.Output, renderer.Render(rep))
+	}
+}
+
+// ShowError shows the reports wrapped by err, an error that has none is shown
+// as a report of its own.
+func (d *Diagnostics) ShowError(err error) {
+	reports := report.Collect(err)
+	if len(reports) == 0 {
+		reports = append(reports, report.NewReport("error").WithMessage(err.Error()))
+	}
+	for _, rep := range reports {
+		d.Show(report.LevelError, *rep)
+	}
+}
+
+func (d *Diagnostics) Flush() {
+	if d.Format != formatSarif {
+		return
+	}
+	d.sarif.ToolName = "justmigrate"
+	io.WriteString(d.Output, d.sarif.Render())
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+func TestDiagnosticsNeverGoToStdout(t *testing.T) {
+	saved := *diagnostics
+	defer func() { *diagnostics = saved }()
+
+	// stdout carries migration scripts and the protocol of the language
+	// server, so no format may write diagnostics there
+	for _, format := range []string{formatText, formatJSON, formatSarif} {
+		*diagnostics = Diagnostics{Format: format, Output: os.Stdout}
+		output, err := diagnostics.Open("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != nil {
+			t.Errorf("%s: expected no file to be opened without -o", format)
+		}
+		if diagnostics.Output != os.Stderr {
+			t.Errorf("%s: expected the diagnostics to be written to stderr", format)
+		}
+	}
+}
+
+func TestDiagnosticsAreWrittenToOutputFile(t *testing.T) {
+	saved := *diagnostics
+	defer func() { *diagnostics = saved }()
+
+	fileName := filepath.Join(t.TempDir(), "diagnostics.sarif")
+	*diagnostics = Diagnostics{Format: formatSarif, Output: os.Stderr}
+	output, err := diagnostics.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ReportError(errors.New("something went wrong"))
+	diagnostics.Flush()
+	if err := output.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]any
+	if err := json.Unmarshal(contents, &log); err != nil {
+		t.Fatalf("expected the file to hold only the SARIF log, got %v:\n%s", err, contents)
+	}
+}
+
+func TestReportsAreShownAtTheirOwnLevel(t *testing.T) {
+	saved := *diagnostics
+	defer func() { *diagnostics = saved }()
+
+	out := &strings.Builder{}
+	*diagnostics = Diagnostics{Format: formatJSON, Output: out}
+	ShowWarnings([]report.Report{
+		*report.NewReport("no-primary-key").WithLevel(report.LevelError),
+		*report.NewReport("text-without-collate").WithLevel(report.LevelNote),
+		*report.NewReport("unused"),
+	})
+
+	levels := []report.Level{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var rep struct {
+			Level report.Level `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(line), &rep); err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, rep.Level)
+	}
+
+	expected := []report.Level{report.LevelError, report.LevelNote, report.LevelWarning}
+	if !slices.Equal(levels, expected) {
+		t.Errorf("expected %v, got %v", expected, levels)
+	}
+}
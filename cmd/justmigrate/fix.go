@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func runFix(args []string) error {
+	var schemaFile string
+	var dryRun bool
+
+	flags := flag.NewFlagSet("fix", flag.ContinueOnError)
+	flags.StringVar(&schemaFile, "schema", defaultSchemaFile, "schema file to fix")
+	flags.BoolVar(&dryRun, "dry-run", false, "print the fixed schema instead of writing it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return err
+	}
+
+	fixed, applied, remaining := sqlite.Fix(lexer.SourceCode{FileName: schemaFile, Raw: []rune(string(raw))})
+
+	if dryRun {
+		fmt.Fprint(os.Stdout, string(fixed.Raw))
+	} else if applied > 0 {
+		info, err := os.Stat(schemaFile)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(schemaFile, []byte(string(fixed.Raw)), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "applied %d fixes to %s\n", applied, schemaFile)
+	return remaining
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+var (
+	ErrNotFormatted    = errors.New("schema files are not formatted")
+	ErrNoFilesToFormat = errors.New("no schema files to format, name the files to format")
+)
+
+var keywordCases = map[string]generator.KeywordCase{
+	"upper": generator.KeywordCaseUpper,
+	"lower": generator.KeywordCaseLower,
+}
+
+var identifierQuotings = map[string]generator.IdentifierQuoting{
+	"always":   generator.QuoteAlways,
+	"needed":   generator.QuoteNeeded,
+	"preserve": generator.QuotePreserve,
+}
+
+func runFmt(args []string) error {
+	var width int
+	var keywordCase, quoting string
+	var check bool
+	options := generator.FormatOptions{}
+
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.IntVar(&width, "width", 80, "width to fit statements into")
+	flags.StringVar(&keywordCase, "keyword-case", "upper", "case of keywords, upper or lower")
+	flags.StringVar(&quoting, "quote", "always", "quoting of identifiers, always, needed or preserve")
+	flags.BoolVar(&options.AlignColumns, "align", false, "align the types and constraints of the columns of a table")
+	flags.BoolVar(&check, "check", false, "list the files that are not formatted instead of writing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var ok bool
+	if options.KeywordCase, ok = keywordCases[keywordCase]; !ok {
+		return fmt.Errorf("unknown keyword case %q, expected upper or lower", keywordCase)
+	}
+	if options.Quoting, ok = identifierQuotings[quoting]; !ok {
+		return fmt.Errorf("unknown identifier quoting %q, expected always, needed or preserve", quoting)
+	}
+
+	// files are rewritten in place, so they are never chosen by default
+	files := flags.Args()
+	if len(files) == 0 {
+		return ErrNoFilesToFormat
+	}
+
+	unformatted := 0
+	for _, file := range files {
+		formatted, err := formatFile(file, width, options, check)
+		if err != nil {
+			return err
+		}
+		if !formatted {
+			unformatted++
+		}
+	}
+
+	if check && unformatted > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrNotFormatted, unformatted, len(files))
+	}
+	return nil
+}
+
+// formatFile formats the schema file in place, formatted reports whether it
+// already was. When checking the file is listed instead of written. A file
+// whose formatting would change its tokens is never written, the error says
+// where.
+func formatFile(file string, width int, options generator.FormatOptions, check bool) (formatted bool, err error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := sqlite.Format(lexer.SourceCode{FileName: file, Raw: []rune(string(raw))}, width, options)
+	if err != nil {
+		return false, err
+	}
+	if result == string(raw) {
+		return true, nil
+	}
+
+	if check {
+		fmt.Fprintln(os.Stdout, file)
+		return false, nil
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(file, []byte(result), info.Mode().Perm())
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFmtRequiresFiles(t *testing.T) {
+	if err := runFmt([]string{}); !errors.Is(err, ErrNoFilesToFormat) {
+		t.Errorf("expected fmt without files to be refused, got %v", err)
+	}
+}
+
+func TestFmtWritesNamedFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(file, []byte("create table t (id integer primary key);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runFmt([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "CREATE TABLE \"t\" (\n    \"id\" integer PRIMARY KEY\n);\n"
+	if string(formatted) != expected {
+		t.Errorf("expected %q, got %q", expected, string(formatted))
+	}
+}
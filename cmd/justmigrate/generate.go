@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+)
+
+var (
+	ErrNameWithoutMigrations = errors.New("-name requires -migrations")
+)
+
+func runGenerate(args []string) error {
+	var migrationFlags MigrationFlags
+	var migrationsDir string
+	var name string
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	migrationFlags.Register(flags)
+	flags.StringVar(&migrationsDir, "migrations", "", "replay the migrations in this directory into a shadow database and use it instead of -db")
+	flags.StringVar(&name, "name", "", "write the migration into the -migrations directory with this name, instead of printing it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if name != "" && migrationsDir == "" {
+		return ErrNameWithoutMigrations
+	}
+
+	ctx := context.Background()
+
+	var db *sqlite.Sqlite
+	var err error
+	if migrationsDir != "" {
+		db, err = sqlite.NewShadowDatabase(ctx, migrationsDir, migrationFlags.Attach.Schemas()...)
+	} else {
+		db, err = migrationFlags.OpenDatabase(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migration, err := PlanMigration(db, migrationFlags.SchemaFile, migrationFlags.TypeComparison, migrationFlags.Temporary)
+	if err != nil {
+		return err
+	}
+	ShowWarnings(migration.Warnings)
+
+	script, err := generator.Generate(migration.Plan)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		fmt.Fprint(os.Stdout, script)
+		return nil
+	}
+
+	if len(migration.Plan) == 0 {
+		fmt.Fprintf(os.Stderr, "%s is up to date with %s\n", migrationsDir, migrationFlags.SchemaFile)
+		return nil
+	}
+
+	fileName, err := sqlite.NextMigrationFileName(migrationsDir, name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(fileName, []byte(script), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", fileName)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateWithoutMigrations(t *testing.T) {
+	if err := runGenerate([]string{"-name", "add_posts"}); !errors.Is(err, ErrNameWithoutMigrations) {
+		t.Errorf("expected %v, got %v", ErrNameWithoutMigrations, err)
+	}
+}
+
+func TestGenerateIntoMigrations(t *testing.T) {
+	dir := t.TempDir()
+	migrations := filepath.Join(dir, "migrations")
+	if err := os.Mkdir(migrations, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"0001_users.sql": "CREATE TABLE users (id integer PRIMARY KEY);",
+		"0003_names.sql": "ALTER TABLE users ADD COLUMN name text;",
+	} {
+		if err := os.WriteFile(filepath.Join(migrations, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schemaFile := filepath.Join(dir, "schema.sql")
+	schema := "CREATE TABLE users (id integer PRIMARY KEY, name text);\nCREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users (id));\n"
+	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-schema", schemaFile, "-migrations", migrations, "-name", "posts"}
+	if err := runGenerate(args); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(migrations, "0004_posts.sql"))
+	if err != nil {
+		t.Fatalf("expected the migration to be numbered after the highest migration: %v", err)
+	}
+	if !strings.Contains(string(written), "CREATE TABLE") || !strings.Contains(string(written), "posts") {
+		t.Errorf("expected the migration to create posts, got\n%s", written)
+	}
+
+	// the migrations now produce the schema, so there is nothing to write
+	if err := runGenerate(args); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected no migration to be written for an up to date schema, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/backend/lint"
+)
+
+var (
+	ErrLintErrors = errors.New("lint rules configured as errors failed")
+)
+
+func runLint(args []string) error {
+	var schemaFile string
+	var listRules bool
+	config := lint.Config{}
+
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.StringVar(&schemaFile, "schema", defaultSchemaFile, "schema file to lint")
+	flags.Var(config, "rule", "set the severity of rules as name=off|info|warning|error, comma separated")
+	flags.BoolVar(&listRules, "list", false, "list the lint rules and their default severity")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if listRules {
+		for _, rule := range lint.Rules {
+			fmt.Fprintf(os.Stdout, "%-22s %-8s %s\n", rule.Name, config.Severity(rule), rule.Description)
+		}
+		return nil
+	}
+
+	file, err := os.Open(schemaFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, statements, err := AstFromFile(file)
+	if err != nil {
+		return err
+	}
+
+	reports := lint.NewLinter(config).Lint(statements)
+	ShowWarnings(reports)
+
+	if lint.HasErrors(reports) {
+		return ErrLintErrors
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite/lsp"
+)
+
+func runLsp(args []string) error {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	// editors pass --stdio to servers that also speak over sockets, stdio
+	// is the only transport so it is accepted and ignored
+	flags.Bool("stdio", true, "speak the protocol over stdin and stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// stdout carries the protocol, diagnostics never go there, see Diagnostics
+	return lsp.NewServer(os.Stdin, os.Stdout).Serve()
+}
@@ -1,16 +1,12 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
 
-	"woodybriggs/justmigrate/backend/diff"
-	"woodybriggs/justmigrate/dialects/sqlite"
-	"woodybriggs/justmigrate/dialects/sqlite/generator"
 	"woodybriggs/justmigrate/dialects/sqlite/parser"
 	"woodybriggs/justmigrate/frontend/ast"
 	"woodybriggs/justmigrate/frontend/lexer"
@@ -23,18 +19,6 @@ var (
 	ErrInvalidNode = errors.New("invalid ast node")
 )
 
-type ParserErrors struct {
-	Errs []error
-}
-
-func (e *ParserErrors) Error() string {
-	return fmt.Sprintf("parser has %d errors", len(e.Errs))
-}
-
-func (e *ParserErrors) Unwrap() []error {
-	return e.Errs
-}
-
 func assert(cond bool, err error) {
 	if !cond {
 		panic(err)
@@ -46,17 +30,15 @@ type Database interface {
 	ExportDataDefinitions() (string, error)
 }
 
-func ShowErrors(errors []report.Report, w io.Writer) {
-	errorRenderer := report.Renderer{}
-	for _, report := range errors {
-		w.Write([]byte(errorRenderer.Render(report)))
+func ShowErrors(errors []report.Report) {
+	for _, rep := range errors {
+		diagnostics.Show(report.LevelError, rep)
 	}
 }
 
-func ShowWarnings(warnings []report.Report, w io.Writer) {
-	renderer := report.Renderer{}
-	for _, report := range warnings {
-		w.Write([]byte(renderer.Render(report)))
+func ShowWarnings(warnings []report.Report) {
+	for _, rep := range warnings {
+		diagnostics.Show(report.LevelWarning, rep)
 	}
 }
 
@@ -66,27 +48,13 @@ func AstFromDatabase(database Database) (lexer.SourceCode, []ast.Statement, erro
 		return lexer.SourceCode{}, nil, err
 	}
 
-	parser := parser.NewSqliteParser(lexer.NewLexer(
-		lexer.SourceCode{
-			FileName: database.Url(),
-			Raw:      []rune(source),
-		},
-	))
-
-	nodes := parser.Statements()
-	errors := parser.ErrorsAsErrorSlice()
-	if len(errors) > 0 {
-		return parser.Current().SourceCode, nil, &ParserErrors{
-			Errs: errors,
-		}
+	sourceCode := lexer.SourceCode{
+		FileName: database.Url(),
+		Raw:      []rune(source),
 	}
 
-	// warnings := slices.Collect(maps.Values(parser.Warnings))
-	// if len(warnings) > 0 {
-	// 	ShowWarnings(warnings, os.Stderr)
-	// }
-
-	return parser.Parser.Current().SourceCode, nodes, nil
+	nodes, err := parser.Parse(sourceCode)
+	return sourceCode, nodes, err
 }
 
 func AstFromFile(file *os.File) (lexer.SourceCode, []ast.Statement, error) {
@@ -95,81 +63,105 @@ func AstFromFile(file *os.File) (lexer.SourceCode, []ast.Statement, error) {
 		return lexer.SourceCode, nil, err
 	}
 
-	parser := parser.NewSqliteParser(lexer)
-
-	nodes := parser.Statements()
-	errors := parser.ErrorsAsErrorSlice()
-	if len(errors) > 0 {
-		return lexer.SourceCode, nil, &ParserErrors{
-			Errs: parser.ErrorsAsErrorSlice(),
-		}
-	}
-
-	// warnings := slices.Collect(maps.Values(parser.Warnings))
-	// if len(warnings) > 0 {
-	// 	ShowWarnings(warnings, os.Stderr)
-	// }
-
-	return lexer.SourceCode, nodes, nil
+	nodes, err := parser.Parse(lexer.SourceCode)
+	return lexer.SourceCode, nodes, err
 }
 
-func main() {
+type Command struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+}
 
-	var err error
+var commands = []Command{
+	{Name: "generate", Description: "print the migration from the database to the schema", Run: runGenerate},
+	{Name: "apply", Description: "apply the migration from the database to the schema", Run: runApply},
+	{Name: "verify", Description: "check that the migration between two schema files reaches the target", Run: runVerify},
+	{Name: "validate", Description: "check the schema file for mistakes sqlite would reject or fail on", Run: runValidate},
+	{Name: "lint", Description: "check the schema file against configurable style and design rules", Run: runLint},
+	{Name: "check", Description: "check that replaying the migrations directory produces the schema file", Run: runCheck},
+	{Name: "fix", Description: "apply the suggested fixes for the mistakes in the schema file", Run: runFix},
+	{Name: "fmt", Description: "format schema files in place, or list the unformatted ones with -check", Run: runFmt},
+	{Name: "lsp", Description: "run the language server for schema files over stdio", Run: runLsp},
+}
 
-	databaseURL := "resources/database.db"
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: justmigrate [-format text|json|sarif] [-o file] <command> [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Description)
+	}
+}
 
-	conn, err := sql.Open("sqlite3", databaseURL)
-	if err != nil {
-		log.Panicln(err)
+// ReportError writes the reports wrapped by err, or err itself if it isn't wrapping any.
+func ReportError(err error) {
+	if diagnostics.Format != formatText {
+		diagnostics.ShowError(err)
+		return
 	}
 
-	db := &sqlite.Sqlite{DB: conn, FileName: databaseURL}
+	w := diagnostics.Output
 
-	fileName := "resources/schema.sql"
-	file, err := os.Open(fileName)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "open file failed with err %v", err)
-		os.Exit(1)
+	type multiError interface {
+		Error() string
+		Unwrap() []error
 	}
-
-	_, tgtAst, err := AstFromFile(file)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ast from file failed with err %v", err)
-		os.Exit(1)
+	if errs, ok := errors.AsType[multiError](err); ok {
+		for _, report := range errs.Unwrap() {
+			fmt.Fprintln(w, report)
+		}
+		return
 	}
+	fmt.Fprintln(w, err)
+}
 
-	_, srcAst, err := AstFromDatabase(db)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ast from database failed with err %v", err)
-		os.Exit(1)
+func main() {
+	flags := flag.NewFlagSet("justmigrate", flag.ExitOnError)
+	flags.Usage = func() { usage(os.Stderr) }
+	var outputFile string
+	flags.StringVar(&diagnostics.Format, "format", formatText, "format of the diagnostics: text, json or sarif")
+	flags.StringVar(&outputFile, "o", "", "write the diagnostics to this file instead of stderr")
+	flags.Parse(os.Args[1:])
+
+	if err := diagnostics.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	differ := diff.Diff{}
-
-	ops, err := differ.DiffSchema(srcAst, tgtAst)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "diff schema failed with err %v", err)
-		os.Exit(1)
+	args := flags.Args()
+	if len(args) < 1 {
+		usage(os.Stderr)
+		os.Exit(2)
 	}
 
-	gen := generator.SqliteFormatter{}
+	for _, cmd := range commands {
+		if cmd.Name != args[0] {
+			continue
+		}
 
-	ops, err = gen.Plan(srcAst, tgtAst, ops)
+		output, err := diagnostics.Open(outputFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
 
-	type multiError interface {
-		Error() string
-		Unwrap() []error
-	}
-	if err != nil {
-		if errs, ok := errors.AsType[multiError](err); ok {
-			for _, report := range errs.Unwrap() {
-				fmt.Println(report)
+		err = cmd.Run(args[1:])
+		if err != nil {
+			ReportError(err)
+		}
+		diagnostics.Flush()
+		if output != nil {
+			if closeErr := output.Close(); closeErr != nil {
+				fmt.Fprintln(os.Stderr, closeErr)
+				os.Exit(1)
 			}
 		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
 	}
 
-	for _, op := range ops {
-		fmt.Printf("%T, %+v\n", op, op)
-	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage(os.Stderr)
+	os.Exit(2)
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+const (
+	defaultDatabaseURL = "resources/database.db"
+	defaultSchemaFile  = "resources/schema.sql"
+)
+
+// MigrationFlags are the flags shared by every command that migrates a database to a schema.
+type MigrationFlags struct {
+	DatabaseURL    string
+	SchemaFile     string
+	TypeComparison diff.TypeComparison
+	Attach         Attachments
+	Temporary      bool
+}
+
+// Attachments are the databases attached to the migrated database by their
+// schema name, Attachments is a flag.Value that takes name=path and may be
+// given more than once.
+type Attachments []Attachment
+
+type Attachment struct {
+	Schema   string
+	FileName string
+}
+
+func (a *Attachments) String() string {
+	pairs := []string{}
+	for _, attachment := range *a {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", attachment.Schema, attachment.FileName))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a *Attachments) Set(value string) error {
+	schema, fileName, ok := strings.Cut(value, "=")
+	if !ok || schema == "" || fileName == "" {
+		return fmt.Errorf("expected schema=path, got %q", value)
+	}
+	*a = append(*a, Attachment{Schema: schema, FileName: fileName})
+	return nil
+}
+
+// Schemas are the schema names of the attachments.
+func (a Attachments) Schemas() []string {
+	schemas := []string{}
+	for _, attachment := range a {
+		schemas = append(schemas, attachment.Schema)
+	}
+	return schemas
+}
+
+func (mf *MigrationFlags) Register(flags *flag.FlagSet) {
+	flags.StringVar(&mf.DatabaseURL, "db", defaultDatabaseURL, "sqlite database to migrate")
+	flags.StringVar(&mf.SchemaFile, "schema", defaultSchemaFile, "target schema file")
+	flags.Var(&mf.Attach, "attach", "attach a database to the migrated database as schema=path, objects of the schema are qualified with its name in the schema file")
+	flags.BoolVar(&mf.Temporary, "temp", false, "include temporary tables, views, triggers and indexes of the schema file in the migration")
+	flags.Var(&mf.TypeComparison, "types", "compare column types as strict, affinity (only changes of affinity are migrated) or warn (affinity, and warn about the rest)")
+}
+
+func (mf *MigrationFlags) OpenDatabase(ctx context.Context) (*sqlite.Sqlite, error) {
+	conn, err := sql.Open("sqlite3", mf.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &sqlite.Sqlite{DB: conn, FileName: mf.DatabaseURL}
+	for _, attachment := range mf.Attach {
+		if err := db.Attach(ctx, attachment.Schema, attachment.FileName); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+type Migration struct {
+	Source   []ast.Statement
+	Target   []ast.Statement
+	Plan     []diff.Op
+	Types    diff.TypeComparison
+	Warnings []report.Report
+}
+
+// PlanMigration diffs the database against the schema file and plans the
+// operations that take the database to the schema.
+//
+// Temporary objects are left out of the plan, unless includeTemporary is set,
+// as the database never keeps them.
+func PlanMigration(db Database, schemaFileName string, types diff.TypeComparison, includeTemporary bool) (*Migration, error) {
+	file, err := os.Open(schemaFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	_, tgtAst, err := AstFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	_, srcAst, err := AstFromDatabase(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeTemporary {
+		srcAst, tgtAst = diff.WithoutTemporary(srcAst), diff.WithoutTemporary(tgtAst)
+	}
+
+	differ := diff.Diff{TypeComparison: types, IncludeTemporary: includeTemporary}
+	ops, err := differ.DiffSchema(srcAst, tgtAst)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.SqliteFormatter{}
+	plan, err := gen.Plan(srcAst, tgtAst, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		Source:   srcAst,
+		Target:   tgtAst,
+		Plan:     plan,
+		Types:    types,
+		Warnings: differ.Warnings,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+)
+
+func runValidate(args []string) error {
+	var schemaFile string
+
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.StringVar(&schemaFile, "schema", defaultSchemaFile, "schema file to validate")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	file, err := os.Open(schemaFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, statements, err := AstFromFile(file)
+	if err != nil {
+		return err
+	}
+
+	if err := generator.Validate(statements); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%s is valid\n", schemaFile)
+	return nil
+}
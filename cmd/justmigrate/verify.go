@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+var (
+	ErrVerifyWithoutSource = errors.New("verify requires -source, the schema file the migration starts from")
+)
+
+func runVerify(args []string) error {
+	var sourceFile string
+	var schemaFile string
+
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.StringVar(&sourceFile, "source", "", "schema file that the migration starts from")
+	flags.StringVar(&schemaFile, "schema", defaultSchemaFile, "target schema file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if sourceFile == "" {
+		return ErrVerifyWithoutSource
+	}
+
+	source, err := sourceCodeFromFile(sourceFile)
+	if err != nil {
+		return err
+	}
+
+	target, err := sourceCodeFromFile(schemaFile)
+	if err != nil {
+		return err
+	}
+
+	result, err := verify.RoundTrip(context.Background(), source, target)
+	if err != nil {
+		if result != nil {
+			fmt.Fprint(os.Stdout, result.Script)
+		}
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, result.Script)
+	fmt.Fprintf(os.Stderr, "verified: %d operations take %s to %s\n", len(diff.Flatten(result.Plan)), sourceFile, schemaFile)
+	return nil
+}
+
+func sourceCodeFromFile(fileName string) (lexer.SourceCode, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return lexer.SourceCode{}, err
+	}
+	defer file.Close()
+
+	lex, err := lexer.NewLexerFromFile(file)
+	if err != nil {
+		return lexer.SourceCode{}, err
+	}
+
+	return lex.SourceCode, nil
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"slices"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+// maxFixPasses bounds how often a fixed source is checked again, fixing one
+// problem can uncover another that it was hiding.
+const maxFixPasses = 8
+
+// Diagnose parses the source and validates the schema it defines, the errors
+// are returned as reports wrapped by the error. A source that does not parse
+// is not validated.
+func Diagnose(source lexer.SourceCode) error {
+	statements, err := parser.Parse(source)
+	if err != nil {
+		return err
+	}
+	return generator.Validate(statements)
+}
+
+// Fix applies the edits suggested by the errors of the source, see Diagnose,
+// until none are left to apply. The fixed source is returned along with the
+// number of edits applied and the errors that remain.
+func Fix(source lexer.SourceCode) (lexer.SourceCode, int, error) {
+	total := 0
+
+	for range maxFixPasses {
+		err := Diagnose(source)
+		if err == nil {
+			return source, total, nil
+		}
+
+		// a report whose edits touch the text edited for an earlier report is
+		// left to the next pass, the earlier edits may already fix it
+		edits := []report.Edit{}
+		for _, rep := range report.Collect(err) {
+			reportEdits := rep.Edits(source.FileName)
+			if slices.ContainsFunc(reportEdits, func(edit report.Edit) bool { return touchesAny(edits, edit) }) {
+				continue
+			}
+			edits = append(edits, reportEdits...)
+		}
+		if len(edits) == 0 {
+			return source, total, err
+		}
+
+		raw, applied := report.ApplyEdits(slices.Clone(source.Raw), edits)
+		if applied == 0 {
+			return source, total, err
+		}
+
+		source = lexer.SourceCode{FileName: source.FileName, Raw: raw}
+		total += applied
+	}
+
+	return source, total, Diagnose(source)
+}
+
+// touchesAny reports whether the edit overlaps or is next to one of the edits.
+func touchesAny(edits []report.Edit, edit report.Edit) bool {
+	return slices.ContainsFunc(edits, func(other report.Edit) bool {
+		return edit.Range.Start <= other.Range.End && other.Range.Start <= edit.Range.End
+	})
+}
@@ -0,0 +1,75 @@
+package sqlite_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+func TestFixResourceSchema(t *testing.T) {
+	raw, err := os.ReadFile("../../resources/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := lexer.SourceCode{FileName: "schema.sql", Raw: []rune(string(raw))}
+	before := report.Collect(sqlite.Diagnose(source))
+
+	fixed, applied, err := sqlite.Fix(source)
+
+	// "currencies"."code" is referenced as varchar and as text, no single
+	// column satisfies both so adding it is left to a note
+	if applied != 0 {
+		t.Errorf("expected no edits to be applied, got %d:\n%s", applied, string(fixed.Raw))
+	}
+	if string(fixed.Raw) != string(source.Raw) {
+		t.Error("expected the source to be left as it is")
+	}
+
+	after := report.Collect(err)
+	if len(after) != len(before) {
+		t.Errorf("expected the %d errors of the source, got %d", len(before), len(after))
+	}
+	for _, rep := range after {
+		if len(rep.Edits(source.FileName)) != 0 {
+			t.Errorf("expected %q to suggest no edits", rep.Description())
+		}
+		if len(rep.Notes) == 0 {
+			t.Errorf("expected %q to note how to fix it", rep.Description())
+		}
+	}
+}
+
+func TestFixAddsReferencedColumnOnce(t *testing.T) {
+	schema := strings.Join([]string{
+		`CREATE TABLE "currencies" (`,
+		`    "name" text NOT NULL`,
+		`);`,
+		`CREATE TABLE "prices" (`,
+		`    "currency_code" text NOT NULL,`,
+		`    FOREIGN KEY ("currency_code") REFERENCES "currencies" ("code")`,
+		`);`,
+		`CREATE TABLE "rates" (`,
+		`    "base" text NOT NULL,`,
+		`    "quote" text NOT NULL,`,
+		`    FOREIGN KEY ("base") REFERENCES "currencies" ("code"),`,
+		`    FOREIGN KEY ("quote") REFERENCES "currencies" ("code")`,
+		`);`,
+		``,
+	}, "\n")
+
+	fixed, applied, err := sqlite.Fix(lexer.SourceCode{FileName: t.Name(), Raw: []rune(schema)})
+	if err != nil {
+		t.Fatalf("expected the fixed schema to validate, got %v:\n%s", err, string(fixed.Raw))
+	}
+	if applied != 1 {
+		t.Errorf("expected one edit to be applied, got %d", applied)
+	}
+
+	expected := strings.Replace(schema, `CREATE TABLE "currencies" (`, "CREATE TABLE \"currencies\" (\n    \"code\" text UNIQUE,", 1)
+	if string(fixed.Raw) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(fixed.Raw))
+	}
+}
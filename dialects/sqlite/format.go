@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"woodybriggs/justmigrate/backend/formatter"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+var (
+	ErrFormatChangesSource = errors.New("formatting would change the meaning of the source")
+)
+
+// Format rewrites the statements of the source in the style of the options,
+// fitting them into width columns where the formatter can break them. The
+// comments of the source are kept: those between statements and on the line
+// of a semicolon where they were, those of a table definition with its
+// column or constraint. A statement with comments anywhere else is kept as it
+// was written. A source that does not parse is not formatted, its errors are
+// returned. The formatted source is parsed again and must have the tokens of
+// the source, kinds, literal values and identifier names, else
+// ErrFormatChangesSource is returned instead of a source that means something
+// else.
+func Format(source lexer.SourceCode, width int, options generator.FormatOptions) (string, error) {
+	p := parser.NewSqliteParser(lexer.NewLexer(source))
+	statements := p.Statements()
+	if errors := p.ErrorsAsErrorSlice(); len(errors) > 0 {
+		return "", &parser.ParserErrors{Errs: errors}
+	}
+	ranges := p.StatementRanges()
+
+	tokens := []token.Token{}
+	lex := lexer.NewLexer(source)
+	for {
+		tok := lex.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Kind == token.TokenKind_EOF {
+			break
+		}
+	}
+	comments := generator.NewComments(tokens)
+	options.Comments = comments
+
+	sb := &strings.Builder{}
+	for i, statement := range statements {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		leading, blankLineAfter := comments.Leading(ranges[i])
+		for j, comment := range leading {
+			if comment.BlankLineBefore && j > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(comment.Text)
+			sb.WriteString("\n")
+		}
+		if blankLineAfter {
+			sb.WriteString("\n")
+		}
+
+		if comments.Placed(ranges[i], statement) {
+			f := generator.NewSqliteFormatter(false, formatter.NewCoreFormatter(sb, width, "\"\""))
+			f.Options = options
+			statement.Accept(f)
+			sb.WriteString(";")
+		} else {
+			sb.WriteString(string(source.Raw[ranges[i].Start:ranges[i].End]))
+		}
+
+		for _, comment := range comments.Trailing(ranges[i]) {
+			sb.WriteString(" ")
+			sb.WriteString(comment.Text)
+		}
+		sb.WriteString("\n")
+	}
+
+	if remaining := comments.Remaining(); len(remaining) > 0 {
+		if len(statements) > 0 {
+			sb.WriteString("\n")
+		}
+		for j, comment := range remaining {
+			if comment.BlankLineBefore && j > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(comment.Text)
+			sb.WriteString("\n")
+		}
+	}
+
+	formatted := sb.String()
+	if err := sameTokens(tokens, lexer.SourceCode{FileName: source.FileName, Raw: []rune(formatted)}); err != nil {
+		return "", err
+	}
+	return formatted, nil
+}
+
+// sameTokens parses the formatted source and compares its tokens with the
+// tokens of the source. Quotes, case and trivia may change, kinds, literal
+// values and the names identifiers refer to may not.
+func sameTokens(tokens []token.Token, formatted lexer.SourceCode) error {
+	p := parser.NewSqliteParser(lexer.NewLexer(formatted))
+	p.Statements()
+	if errs := p.ErrorsAsErrorSlice(); len(errs) > 0 {
+		return fmt.Errorf("%w: the formatted source does not parse: %w", ErrFormatChangesSource, &parser.ParserErrors{Errs: errs})
+	}
+
+	lex := lexer.NewLexer(formatted)
+	for _, expected := range tokens {
+		got := lex.NextToken()
+		changed := got.Kind != expected.Kind ||
+			(expected.Kind.IsLiteral() && got.Text != expected.Text) ||
+			(expected.Kind == token.TokenKind_Identifier && ast.CanonicalName(got.Text) != ast.CanonicalName(expected.Text))
+		if changed {
+			return fmt.Errorf(
+				"%w: %s:%d:%d %s %q is written as %s %q",
+				ErrFormatChangesSource,
+				expected.FileLoc.FileName, expected.FileLoc.Line, expected.FileLoc.Col,
+				expected.Kind.DebugString(), expected.Text, got.Kind.DebugString(), got.Text,
+			)
+		}
+	}
+	return nil
+}
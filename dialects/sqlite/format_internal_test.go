@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+func TestSameTokens(t *testing.T) {
+	source := "create table t (c text default current_timestamp, d text default 'a');"
+
+	cases := []struct {
+		name      string
+		formatted string
+		changed   bool
+	}{
+		{name: "quotes and case", formatted: `CREATE TABLE "t" ("c" text DEFAULT CURRENT_TIMESTAMP, "d" text DEFAULT 'a');`},
+		{name: "keyword quoted as a string", formatted: `CREATE TABLE t (c text DEFAULT 'CURRENT_TIMESTAMP', d text DEFAULT 'a');`, changed: true},
+		{name: "changed literal", formatted: `CREATE TABLE t (c text DEFAULT CURRENT_TIMESTAMP, d text DEFAULT 'b');`, changed: true},
+		{name: "requoted identifiers", formatted: "CREATE TABLE [T] (`C` text DEFAULT CURRENT_TIMESTAMP, \"d\" text DEFAULT 'a');"},
+		{name: "renamed identifier", formatted: `CREATE TABLE t (c text DEFAULT CURRENT_TIMESTAMP, e text DEFAULT 'a');`, changed: true},
+		{name: "identifier quoted into another name", formatted: `CREATE TABLE "t " (c text DEFAULT CURRENT_TIMESTAMP, d text DEFAULT 'a');`, changed: true},
+		{name: "missing token", formatted: `CREATE TABLE t (c text DEFAULT CURRENT_TIMESTAMP, d text);`, changed: true},
+	}
+
+	tokens := []token.Token{}
+	lex := lexer.NewLexer(lexer.SourceCode{FileName: t.Name(), Raw: []rune(source)})
+	for {
+		tok := lex.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Kind == token.TokenKind_EOF {
+			break
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := sameTokens(tokens, lexer.SourceCode{FileName: t.Name(), Raw: []rune(tc.formatted)})
+			if changed := errors.Is(err, ErrFormatChangesSource); changed != tc.changed {
+				t.Errorf("expected changed to be %v, got %v", tc.changed, err)
+			}
+		})
+	}
+}
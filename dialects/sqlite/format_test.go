@@ -0,0 +1,127 @@
+package sqlite_test
+
+import (
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		options  generator.FormatOptions
+		source   string
+		expected string
+	}{
+		{
+			name:    "constraints",
+			options: generator.FormatOptions{Quoting: generator.QuoteAlways},
+			source: `create table account (id integer primary key asc on conflict abort autoincrement, team_id int not null on conflict fail references team(id) on delete set null on update cascade match simple deferrable initially deferred, name text constraint name_nocase collate nocase, primary key (id, team_id) on conflict replace) strict, without rowid;
+create unique index if not exists account_name on account (name collate nocase desc) where name <> '';`,
+			expected: `CREATE TABLE "account" (
+    "id" integer PRIMARY KEY ASC ON CONFLICT ABORT AUTOINCREMENT,
+    "team_id" int NOT NULL ON CONFLICT FAIL REFERENCES "team" ("id") ON DELETE SET NULL ON UPDATE CASCADE MATCH "simple" DEFERRABLE INITIALLY DEFERRED,
+    "name" text CONSTRAINT "name_nocase" COLLATE "nocase",
+    PRIMARY KEY ("id", "team_id") ON CONFLICT REPLACE
+) STRICT, WITHOUT ROWID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "account_name" ON "account" ("name" COLLATE "nocase" DESC)
+WHERE "name" <> '';
+`,
+		},
+		{
+			name:    "lower keywords and needed quotes",
+			options: generator.FormatOptions{KeywordCase: generator.KeywordCaseLower, Quoting: generator.QuoteNeeded},
+			source:  `CREATE TABLE "Order" ("id" INTEGER PRIMARY KEY, [group] TEXT, "first name" TEXT DEFAULT 'x' CHECK ("first name" <> '' OR "group" IS NULL));`,
+			expected: `create table "Order" (
+    id INTEGER primary key,
+    "group" TEXT,
+    "first name" TEXT default 'x' check ("first name" <> '' or "group" is null)
+);
+`,
+		},
+		{
+			name:     "preserved quotes",
+			options:  generator.FormatOptions{Quoting: generator.QuotePreserve},
+			source:   "create table `users` ([id] integer, \"name\" text, email text);",
+			expected: "CREATE TABLE `users` (\n    [id] integer,\n    \"name\" text,\n    email text\n);\n",
+		},
+		{
+			name:    "aligned columns",
+			options: generator.FormatOptions{Quoting: generator.QuoteNeeded, AlignColumns: true},
+			source:  `create table users (id integer primary key, email_address text not null unique, age int, nickname);`,
+			expected: `CREATE TABLE users (
+    id            integer PRIMARY KEY,
+    email_address text    NOT NULL UNIQUE,
+    age           int,
+    nickname
+);
+`,
+		},
+		{
+			name:    "comments",
+			options: generator.FormatOptions{Quoting: generator.QuoteNeeded},
+			source: `-- the schema
+
+-- people
+create table users ( -- one row per person
+  id integer primary key, -- rowid alias
+
+  /* contact */
+  email text
+  -- more to come
+); -- users
+
+create view adults as select *
+  -- grown ups only
+  from users where age >= 18;
+-- the end
+`,
+			expected: `-- the schema
+
+-- people
+CREATE TABLE users ( -- one row per person
+    id integer PRIMARY KEY, -- rowid alias
+
+    /* contact */
+    email text
+    -- more to come
+); -- users
+
+create view adults as select *
+  -- grown ups only
+  from users where age >= 18;
+
+-- the end
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, err := sqlite.Format(lexer.SourceCode{FileName: t.Name(), Raw: []rune(tc.source)}, 80, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatted != tc.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tc.expected, formatted)
+			}
+
+			again, err := sqlite.Format(lexer.SourceCode{FileName: t.Name(), Raw: []rune(formatted)}, 80, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != formatted {
+				t.Errorf("formatting again changed\n%s\nto\n%s", formatted, again)
+			}
+		})
+	}
+}
+
+func TestFormatSyntaxErrors(t *testing.T) {
+	_, err := sqlite.Format(lexer.SourceCode{FileName: t.Name(), Raw: []rune("create table users (id integer primry key);")}, 80, generator.FormatOptions{})
+	if err == nil {
+		t.Error("expected the parse error")
+	}
+}
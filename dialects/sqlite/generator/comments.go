@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// Comment is a comment of the source as it was written, a -- comment runs to
+// the end of its line.
+type Comment struct {
+	Text string
+	// BlankLineBefore is set when an empty line separates the comment from
+	// what was written before it
+	BlankLineBefore bool
+}
+
+func (c Comment) isLineComment() bool {
+	return strings.HasPrefix(c.Text, "--")
+}
+
+// triviaComments splits trivia into its comments, blankLineAfter is set when
+// an empty line follows the last of them. afterNewline is set when the line
+// before the trivia has already ended.
+func triviaComments(trivia string, afterNewline bool) (comments []Comment, blankLineAfter bool) {
+	newlines := 0
+	if afterNewline {
+		newlines = 1
+	}
+	for len(trivia) > 0 {
+		switch {
+		case strings.HasPrefix(trivia, "--"):
+			end := strings.IndexByte(trivia, '\n')
+			if end == -1 {
+				end = len(trivia)
+			}
+			comments = append(comments, Comment{Text: strings.TrimRight(trivia[:end], " \t\r"), BlankLineBefore: newlines > 1})
+			trivia, newlines = trivia[end:], 0
+		case strings.HasPrefix(trivia, "/*"):
+			end := strings.Index(trivia[2:], "*/")
+			if end == -1 {
+				end = len(trivia)
+			} else {
+				end += 4
+			}
+			comments = append(comments, Comment{Text: trivia[:end], BlankLineBefore: newlines > 1})
+			trivia, newlines = trivia[end:], 0
+		default:
+			if trivia[0] == '\n' {
+				newlines++
+			}
+			trivia = trivia[1:]
+		}
+	}
+	return comments, newlines > 1 && len(comments) > 0
+}
+
+// Comments finds the comments of a source by the tokens they are written
+// around, the tokens are those the source lexes to including its end of file.
+type Comments struct {
+	tokens []token.Token
+	eof    token.Token
+}
+
+func NewComments(tokens []token.Token) *Comments {
+	c := &Comments{tokens: tokens}
+	// the end of file token isn't in order of where it is, it is kept apart
+	if n := len(tokens); n > 0 && tokens[n-1].Kind == token.TokenKind_EOF {
+		c.tokens, c.eof = tokens[:n-1], tokens[n-1]
+	}
+	return c
+}
+
+// index is the index of the token that starts at offset.
+func (c *Comments) index(offset int) (int, bool) {
+	i := sort.Search(len(c.tokens), func(i int) bool { return c.tokens[i].SourceRange.Start >= offset })
+	return i, i < len(c.tokens) && c.tokens[i].SourceRange.Start == offset
+}
+
+func (c *Comments) leading(i int) []Comment {
+	comments, _ := c.leadingTrivia(i)
+	return comments
+}
+
+// leadingTrivia splits the leading trivia of the token at i, the token past
+// the last one is the end of file.
+func (c *Comments) leadingTrivia(i int) ([]Comment, bool) {
+	tok := c.eof
+	if i < len(c.tokens) {
+		tok = c.tokens[i]
+	}
+	afterNewline := i > 0 && strings.HasSuffix(c.tokens[i-1].TrailingTrivia, "\n")
+	return triviaComments(tok.LeadingTrivia, afterNewline)
+}
+
+func (c *Comments) trailing(i int) []Comment {
+	comments, _ := triviaComments(c.tokens[i].TrailingTrivia, false)
+	return comments
+}
+
+// between are the comments written between the tokens first and last.
+func (c *Comments) between(first, last int) []Comment {
+	comments := []Comment{}
+	for i := first; i < last; i++ {
+		comments = append(comments, c.trailing(i)...)
+		comments = append(comments, c.leading(i+1)...)
+	}
+	return comments
+}
+
+// Leading are the comments on the lines before the statement written at
+// statement, blankLineAfter is set when an empty line separates them from it.
+func (c *Comments) Leading(statement token.TextRange) (comments []Comment, blankLineAfter bool) {
+	i, ok := c.index(statement.Start)
+	if !ok {
+		return nil, false
+	}
+	return c.leadingTrivia(i)
+}
+
+// Trailing are the comments on the line of the semicolon that ends the
+// statement written at statement.
+func (c *Comments) Trailing(statement token.TextRange) []Comment {
+	last, ok := c.lastIndex(statement)
+	if !ok {
+		return nil
+	}
+	return c.trailing(last)
+}
+
+// Remaining are the comments after the last statement.
+func (c *Comments) Remaining() []Comment {
+	return c.leading(len(c.tokens))
+}
+
+func (c *Comments) lastIndex(statement token.TextRange) (int, bool) {
+	i := sort.Search(len(c.tokens), func(i int) bool { return c.tokens[i].SourceRange.End >= statement.End })
+	return i, i < len(c.tokens) && c.tokens[i].SourceRange.End == statement.End
+}
+
+// Placed reports whether the formatter writes every comment within the
+// statement written at statement, only the comments of table definitions have
+// a place in the formatted statement.
+func (c *Comments) Placed(statement token.TextRange, node ast.Statement) bool {
+	first, ok := c.index(statement.Start)
+	last, lastOk := c.lastIndex(statement)
+	if !ok || !lastOk {
+		return false
+	}
+
+	if create, ok := node.(*ast.CreateTable); ok && create.TableDefinition != nil {
+		lParen, rParen, ok := c.tableParens(create.TableDefinition)
+		if !ok || c.table(create.TableDefinition) == nil {
+			return false
+		}
+		return len(c.between(first, lParen)) == 0 && len(c.between(rParen, last)) == 0
+	}
+
+	return len(c.between(first, last)) == 0
+}
+
+func (c *Comments) tableParens(def *ast.TableDefinition) (int, int, bool) {
+	lParen, lOk := c.index(def.LParen.SourceRange.Start)
+	rParen, rOk := c.index(def.RParent.SourceRange.Start)
+	return lParen, rParen, lOk && rOk && lParen < rParen
+}
+
+// tableComments are the comments of a table definition, by the column or
+// constraint they are written around.
+type tableComments struct {
+	// open are the comments on the line of the opening parenthesis
+	open     []Comment
+	elements []elementComments
+	// close are the comments on the lines before the closing parenthesis
+	close []Comment
+}
+
+type elementComments struct {
+	// leading are the comments on the lines before the element
+	leading []Comment
+	// trailing are the comments within the element and on its last line
+	trailing []Comment
+}
+
+// table finds the comments of the table definition, nil is returned when
+// the definition isn't one of the source.
+func (c *Comments) table(def *ast.TableDefinition) *tableComments {
+	if c == nil {
+		return nil
+	}
+	lParen, rParen, ok := c.tableParens(def)
+	if !ok {
+		return nil
+	}
+
+	comments := &tableComments{open: c.trailing(lParen), close: c.leading(rParen)}
+
+	// the columns and constraints are separated by the commas that are not
+	// within parentheses of their own
+	start, depth := lParen+1, 0
+	for i := lParen + 1; i < rParen; i++ {
+		switch c.tokens[i].Kind {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if (c.tokens[i].Kind == ',' && depth == 0) || i == rParen-1 {
+			comments.elements = append(comments.elements, elementComments{
+				leading:  c.leading(start),
+				trailing: append(c.between(start, i), c.trailing(i)...),
+			})
+			start = i + 1
+		}
+	}
+
+	if len(comments.elements) != len(def.ColumnDefinitions)+len(def.TableConstraints) {
+		return nil
+	}
+	return comments
+}
+
+func (tc *tableComments) element(i int) elementComments {
+	if tc == nil || i >= len(tc.elements) {
+		return elementComments{}
+	}
+	return tc.elements[i]
+}
+
+// lineComments writes comments on lines of their own, keeping the empty
+// lines between them. atStart is set when nothing was written before them.
+func (f *SqliteFormatter) lineComments(comments []Comment, atStart bool) {
+	for i, comment := range comments {
+		if comment.BlankLineBefore && (i > 0 || !atStart) {
+			f.Break()
+		}
+		f.Text(comment.Text)
+		f.Break()
+	}
+}
+
+// trailingComments writes comments at the end of the current line, a --
+// comment ends its line so what follows it starts a new one.
+func (f *SqliteFormatter) trailingComments(comments []Comment) {
+	for i, comment := range comments {
+		if i > 0 && comments[i-1].isLineComment() {
+			f.Break()
+		} else {
+			f.Space()
+		}
+		f.Text(comment.Text)
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// KeywordCase is the case keywords are written in.
+type KeywordCase int
+
+const (
+	KeywordCaseUpper KeywordCase = iota
+	KeywordCaseLower
+)
+
+// IdentifierQuoting is how identifiers are quoted.
+type IdentifierQuoting int
+
+const (
+	// QuoteDefault leaves the quoting to the formatter being written to, as
+	// the migrations do.
+	QuoteDefault IdentifierQuoting = iota
+	// QuoteAlways double quotes every identifier.
+	QuoteAlways
+	// QuoteNeeded double quotes only the identifiers that sqlite would not
+	// read as a name without them.
+	QuoteNeeded
+	// QuotePreserve quotes identifiers the way they were written.
+	QuotePreserve
+)
+
+// FormatOptions change how the formatter writes statements, the zero value
+// writes them the way migrations are written.
+type FormatOptions struct {
+	KeywordCase KeywordCase
+	Quoting     IdentifierQuoting
+	// AlignColumns lines up the types and the constraints of the columns
+	// of a table.
+	AlignColumns bool
+	// Comments are the comments of the source the statements were parsed
+	// from, the ones within a table definition are written with it.
+	Comments *Comments
+}
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// sqliteKeywords are the words sqlite reserves, see
+// https://www.sqlite.org/lang_keywords.html
+var sqliteKeywords = map[string]struct{}{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ABORT ACTION ADD AFTER ALL ALTER ALWAYS ANALYZE AND AS ASC ATTACH
+		AUTOINCREMENT BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE
+		COLUMN COMMIT CONFLICT CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE
+		CURRENT_TIME CURRENT_TIMESTAMP DATABASE DEFAULT DEFERRABLE DEFERRED
+		DELETE DESC DETACH DISTINCT DO DROP EACH ELSE END ESCAPE EXCEPT
+		EXCLUDE EXCLUSIVE EXISTS EXPLAIN FAIL FILTER FIRST FOLLOWING FOR
+		FOREIGN FROM FULL GENERATED GLOB GROUP GROUPS HAVING IF IGNORE
+		IMMEDIATE IN INDEX INDEXED INITIALLY INNER INSERT INSTEAD INTERSECT
+		INTO IS ISNULL JOIN KEY LAST LEFT LIKE LIMIT MATCH MATERIALIZED
+		NATURAL NO NOT NOTHING NOTNULL NULL NULLS OF OFFSET ON OR ORDER
+		OTHERS OUTER OVER PARTITION PLAN PRAGMA PRECEDING PRIMARY QUERY RAISE
+		RANGE RECURSIVE REFERENCES REGEXP REINDEX RELEASE RENAME REPLACE
+		RESTRICT RETURNING RIGHT ROLLBACK ROW ROWS SAVEPOINT SELECT SET TABLE
+		TEMP TEMPORARY THEN TIES TO TRANSACTION TRIGGER UNBOUNDED UNION UNIQUE
+		UPDATE USING VACUUM VALUES VIEW VIRTUAL WHEN WHERE WINDOW WITH WITHOUT
+		STRICT ROWID TRUE FALSE`) {
+		sqliteKeywords[keyword] = struct{}{}
+	}
+}
+
+// needsQuotes reports whether the name has to be quoted to be read as a name,
+// either it isn't a plain word or it is a keyword.
+func needsQuotes(name string) bool {
+	if !plainIdentifier.MatchString(name) {
+		return true
+	}
+	_, ok := sqliteKeywords[strings.ToUpper(name)]
+	return ok
+}
+
+func doubleQuoted(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (f *SqliteFormatter) identifier(node *ast.Identifier) {
+	switch f.Options.Quoting {
+	case QuoteAlways:
+		f.Text(doubleQuoted(node.Text))
+	case QuoteNeeded:
+		if needsQuotes(node.Text) {
+			f.Text(doubleQuoted(node.Text))
+		} else {
+			f.Text(node.Text)
+		}
+	case QuotePreserve:
+		if node.OpenQuote == 0 {
+			f.Text(node.Text)
+		} else {
+			f.Text(token.Token(*node).Quoted())
+		}
+	default:
+		f.Identifier(node.Text)
+	}
+}
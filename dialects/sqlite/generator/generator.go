@@ -1,6 +1,9 @@
 package generator
 
 import (
+	"math"
+	"strings"
+	"unicode/utf8"
 	"woodybriggs/justmigrate/backend/formatter"
 	"woodybriggs/justmigrate/frontend/ast"
 )
@@ -8,6 +11,12 @@ import (
 type SqliteFormatter struct {
 	formatter.Formatter
 	ast.BaseVisitor
+
+	Options FormatOptions
+
+	// columnWidths are what the names and types of the columns of the table
+	// being written are padded to when they are aligned
+	columnWidths struct{ name, typ int }
 }
 
 func NewSqliteFormatter(debug bool, formatter formatter.Formatter) *SqliteFormatter {
@@ -22,6 +31,9 @@ func NewSqliteFormatter(debug bool, formatter formatter.Formatter) *SqliteFormat
 func (f *SqliteFormatter) VisitParseError(err *ast.ParseError) {}
 
 func (f *SqliteFormatter) Keyword(keyword string) {
+	if f.Options.KeywordCase == KeywordCaseLower {
+		keyword = strings.ToLower(keyword)
+	}
 	f.Text(keyword)
 }
 
@@ -38,6 +50,12 @@ func (f *SqliteFormatter) VisitCreateTable(node *ast.CreateTable) {
 	f.Group(func() {
 		f.Keyword("CREATE")
 		f.Space()
+
+		if node.Temporary != nil {
+			f.Keyword("TEMPORARY")
+			f.Space()
+		}
+
 		f.Keyword("TABLE")
 		f.Space()
 
@@ -53,36 +71,109 @@ func (f *SqliteFormatter) VisitCreateTable(node *ast.CreateTable) {
 		node.TableIdentifier.Accept(f)
 		f.Space()
 
+		if node.AsSelect != nil {
+			f.Keyword("AS")
+			f.Space()
+			node.AsSelect.Accept(f)
+			return
+		}
+
+		elements := []interface{ Accept(ast.Visitor) }{}
+		for i := range node.TableDefinition.ColumnDefinitions {
+			elements = append(elements, &node.TableDefinition.ColumnDefinitions[i])
+		}
+		for _, constraint := range node.TableDefinition.TableConstraints {
+			elements = append(elements, constraint)
+		}
+
+		f.alignColumns(node.TableDefinition.ColumnDefinitions)
+		defer f.alignColumns(nil)
+
+		comments := f.Options.Comments.table(node.TableDefinition)
+
 		f.Rune('(')
+		if comments != nil {
+			f.trailingComments(comments.open)
+		}
 		f.Break()
 		f.Indent(func() {
-			for i, col := range node.TableDefinition.ColumnDefinitions {
-				col.Accept(f)
-				if i != len(node.TableDefinition.ColumnDefinitions)-1 {
+			for i, element := range elements {
+				elementComments := comments.element(i)
+				f.lineComments(elementComments.leading, i == 0)
+
+				element.Accept(f)
+				if i != len(elements)-1 {
 					f.Rune(',')
+				}
+				f.trailingComments(elementComments.trailing)
+
+				if i != len(elements)-1 {
 					f.Break()
 				}
 			}
 
-			if len(node.TableDefinition.TableConstraints) > 0 {
-				f.Rune(',')
+			if comments != nil && len(comments.close) > 0 {
 				f.Break()
+				f.lineComments(comments.close, false)
+				return
 			}
+			f.Break()
+		})
 
-			for i, constraint := range node.TableDefinition.TableConstraints {
-				constraint.Accept(f)
-				if i < len(node.TableDefinition.TableConstraints)-1 {
+		f.Rune(')')
+
+		if options := node.TableOptions; options != nil {
+			if options.Strict != nil {
+				f.Space()
+				f.Keyword("STRICT")
+			}
+			if options.WithoutRowId != nil {
+				if options.Strict != nil {
 					f.Rune(',')
-					f.Break()
 				}
+				f.Space()
+				f.Keyword("WITHOUT")
+				f.Space()
+				f.Keyword("ROWID")
 			}
-		})
+		}
+	})
+}
 
-		f.Break()
-		f.Rune(')')
+func (f *SqliteFormatter) VisitCreateVirtualTable(node *ast.CreateVirtualTable) {
+	f.Keyword("CREATE")
+	f.Space()
+	f.Keyword("VIRTUAL")
+	f.Space()
+	f.Keyword("TABLE")
+	f.Space()
 
-		// visit table options
-	})
+	if node.IfNotExist != nil {
+		f.Keyword("IF")
+		f.Space()
+		f.Keyword("NOT")
+		f.Space()
+		f.Keyword("EXISTS")
+		f.Space()
+	}
+
+	node.TableIdentifier.Accept(f)
+	f.Space()
+	f.Keyword("USING")
+	f.Space()
+	f.Text(node.ModuleName.Text)
+
+	if len(node.ModuleArgs) > 0 {
+		f.Rune('(')
+		for i, arg := range node.ModuleArgs {
+			f.Text(strings.TrimSpace(arg))
+			if i != len(node.ModuleArgs)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+	}
 }
 
 func (f *SqliteFormatter) VisitAlterTable(node *ast.AlterTable) {
@@ -97,6 +188,297 @@ func (f *SqliteFormatter) VisitAlterTable(node *ast.AlterTable) {
 	})
 }
 
+func (f *SqliteFormatter) VisitDropTable(node *ast.DropTable) {
+	f.drop("TABLE", node.IfExists, &node.TableIdentifier)
+}
+
+func (f *SqliteFormatter) VisitDropIndex(node *ast.DropIndex) {
+	f.drop("INDEX", node.IfExists, &node.IndexIdentifier)
+}
+
+func (f *SqliteFormatter) VisitDropView(node *ast.DropView) {
+	f.drop("VIEW", node.IfExists, &node.ViewIdentifier)
+}
+
+func (f *SqliteFormatter) VisitDropTrigger(node *ast.DropTrigger) {
+	f.drop("TRIGGER", node.IfExists, &node.TriggerIdentifier)
+}
+
+func (f *SqliteFormatter) drop(objectKeyword string, ifExists *ast.IfExists, ident *ast.CatalogObjectIdentifier) {
+	f.Keyword("DROP")
+	f.Space()
+	f.Keyword(objectKeyword)
+	f.Space()
+
+	if ifExists != nil {
+		f.Keyword("IF")
+		f.Space()
+		f.Keyword("EXISTS")
+		f.Space()
+	}
+
+	ident.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitCreateIndex(node *ast.CreateIndex) {
+	f.Group(func() {
+		f.Keyword("CREATE")
+		f.Space()
+
+		if node.UniqueKeyword != nil {
+			f.Keyword("UNIQUE")
+			f.Space()
+		}
+
+		f.Keyword("INDEX")
+		f.Space()
+
+		if node.IfNotExists != nil {
+			f.Keyword("IF")
+			f.Space()
+			f.Keyword("NOT")
+			f.Space()
+			f.Keyword("EXISTS")
+			f.Space()
+		}
+
+		node.IndexIdentifier.Accept(f)
+		f.Space()
+		f.Keyword("ON")
+		f.Space()
+		node.OnTable.Accept(f)
+		f.Space()
+
+		f.Rune('(')
+		for i, indexedCol := range node.IndexedColumns {
+			f.VisitIndexedColumn(&indexedCol)
+			if i != len(node.IndexedColumns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+
+		if node.WhereExpr != nil {
+			f.Line()
+			f.Keyword("WHERE")
+			f.Space()
+			node.WhereExpr.Accept(f)
+		}
+	})
+}
+
+func (f *SqliteFormatter) VisitCreateView(node *ast.CreateView) {
+	f.Keyword("CREATE")
+	f.Space()
+
+	if node.Temporary != nil {
+		f.Keyword("TEMPORARY")
+		f.Space()
+	}
+
+	f.Keyword("VIEW")
+	f.Space()
+
+	if node.IfNotExists != nil {
+		f.Keyword("IF")
+		f.Space()
+		f.Keyword("NOT")
+		f.Space()
+		f.Keyword("EXISTS")
+		f.Space()
+	}
+
+	node.ViewIdentifier.Accept(f)
+	f.Space()
+
+	if len(node.Columns) > 0 {
+		f.Rune('(')
+		for i, col := range node.Columns {
+			col.Accept(f)
+			if i != len(node.Columns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+		f.Rune(')')
+		f.Space()
+	}
+
+	f.Keyword("AS")
+	f.Space()
+	node.AsSelect.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitSelect(node *ast.Select) {
+	f.Text(ast.SourceText(node.Tokens))
+}
+
+func (f *SqliteFormatter) VisitInsert(node *ast.Insert) {
+	f.Text(ast.SourceText(node.Tokens))
+}
+
+func (f *SqliteFormatter) VisitUpdate(node *ast.Update) {
+	f.Text(ast.SourceText(node.Tokens))
+}
+
+func (f *SqliteFormatter) VisitDelete(node *ast.Delete) {
+	f.Text(ast.SourceText(node.Tokens))
+}
+
+func (f *SqliteFormatter) VisitOpaqueStatement(node *ast.OpaqueStatement) {
+	f.Text(ast.SourceText(node.Tokens))
+}
+
+func (f *SqliteFormatter) VisitPragma(node *ast.Pragma) {
+	f.Keyword("PRAGMA")
+	f.Space()
+	node.Name.Accept(f)
+
+	if node.Value == nil {
+		return
+	}
+
+	f.Space()
+	f.Rune('=')
+	f.Space()
+
+	// keyword values, such as ON or WAL, are written as they were
+	if ident, ok := node.Value.(*ast.Identifier); ok && ident.OpenQuote == 0 {
+		f.Text(ident.Text)
+		return
+	}
+	node.Value.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitBeginTransaction(node *ast.BeginTransaction) {
+	f.Keyword("BEGIN")
+	if node.Mode != nil {
+		f.Space()
+		f.Keyword(strings.ToUpper(node.Mode.Text))
+	}
+	f.Space()
+	f.Keyword("TRANSACTION")
+}
+
+func (f *SqliteFormatter) VisitCommitTransaction(node *ast.CommitTransaction) {
+	f.Keyword("COMMIT")
+}
+
+func (f *SqliteFormatter) VisitRollbackTransaction(node *ast.RollbackTransaction) {
+	f.Keyword("ROLLBACK")
+	if node.Savepoint != nil {
+		f.Space()
+		f.Keyword("TO")
+		f.Space()
+		f.Keyword("SAVEPOINT")
+		f.Space()
+		node.Savepoint.Accept(f)
+	}
+}
+
+func (f *SqliteFormatter) VisitSavepoint(node *ast.Savepoint) {
+	f.Keyword("SAVEPOINT")
+	f.Space()
+	node.Name.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitReleaseSavepoint(node *ast.ReleaseSavepoint) {
+	f.Keyword("RELEASE")
+	f.Space()
+	f.Keyword("SAVEPOINT")
+	f.Space()
+	node.Name.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitCreateTrigger(node *ast.CreateTrigger) {
+	f.Keyword("CREATE")
+	f.Space()
+
+	if node.Temporary != nil {
+		f.Keyword("TEMPORARY")
+		f.Space()
+	}
+
+	f.Keyword("TRIGGER")
+	f.Space()
+
+	if node.IfNotExists != nil {
+		f.Keyword("IF")
+		f.Space()
+		f.Keyword("NOT")
+		f.Space()
+		f.Keyword("EXISTS")
+		f.Space()
+	}
+
+	node.TriggerIdentifier.Accept(f)
+	f.Space()
+
+	switch node.TriggerTime.(type) {
+	case *ast.TriggerTimeBefore:
+		f.Keyword("BEFORE")
+		f.Space()
+	case *ast.TriggerTimeAfter:
+		f.Keyword("AFTER")
+		f.Space()
+	case *ast.TriggerTimeInsteadOf:
+		f.Keyword("INSTEAD")
+		f.Space()
+		f.Keyword("OF")
+		f.Space()
+	}
+
+	switch event := node.TriggerEvent.(type) {
+	case *ast.TriggerEventDelete:
+		f.Keyword("DELETE")
+	case *ast.TriggerEventInsert:
+		f.Keyword("INSERT")
+	case *ast.TriggerEventUpdate:
+		f.Keyword("UPDATE")
+	case *ast.TriggerEventUpdateOf:
+		f.Keyword("UPDATE")
+		f.Space()
+		f.Keyword("OF")
+		f.Space()
+		for i, col := range event.Columns {
+			col.Accept(f)
+			if i != len(event.Columns)-1 {
+				f.Rune(',')
+				f.Space()
+			}
+		}
+	}
+	f.Space()
+
+	f.Keyword("ON")
+	f.Space()
+	node.OnTable.Accept(f)
+	f.Space()
+
+	f.Text(ast.SourceText(node.Body))
+}
+
+func (f *SqliteFormatter) VisitTableAlterationRenameTable(node *ast.RenameTable) {
+	f.Keyword("RENAME")
+	f.Space()
+	f.Keyword("TO")
+	f.Space()
+	node.NewTableName.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitTableAlterationRenameColumn(node *ast.RenameColumn) {
+	f.Keyword("RENAME")
+	f.Space()
+	f.Keyword("COLUMN")
+	f.Space()
+	node.ColumnName.Accept(f)
+	f.Space()
+	f.Keyword("TO")
+	f.Space()
+	node.NewColumnName.Accept(f)
+}
+
 func (f *SqliteFormatter) VisitTableAlterationAddColumn(node *ast.AddColumn) {
 	f.Keyword("ADD")
 	f.Space()
@@ -110,16 +492,56 @@ func (f *SqliteFormatter) VisitTableAlterationDropColumn(node *ast.DropColumn) {
 	f.Space()
 	f.Keyword("COLUMN")
 	f.Space()
-	f.Identifier(node.ColumnName.Text)
+	node.ColumnName.Accept(f)
+}
+
+// alignColumns sets the widths the names and types of the columns are padded
+// to, no columns stop the padding.
+func (f *SqliteFormatter) alignColumns(columns []ast.ColumnDefinition) {
+	f.columnWidths.name, f.columnWidths.typ = 0, 0
+	if !f.Options.AlignColumns {
+		return
+	}
+	for i := range columns {
+		f.columnWidths.name = max(f.columnWidths.name, f.measure(&columns[i].ColumnName))
+		if columns[i].TypeName != nil {
+			f.columnWidths.typ = max(f.columnWidths.typ, f.measure(columns[i].TypeName))
+		}
+	}
+}
+
+// measure is the width the node is written in on a line of its own.
+func (f *SqliteFormatter) measure(node interface{ Accept(ast.Visitor) }) int {
+	sb := &strings.Builder{}
+	measuring := NewSqliteFormatter(false, formatter.NewCoreFormatter(sb, math.MaxInt, "\"\""))
+	measuring.Options = FormatOptions{KeywordCase: f.Options.KeywordCase, Quoting: f.Options.Quoting}
+	node.Accept(measuring)
+	return utf8.RuneCountInString(sb.String())
+}
+
+// pad writes the spaces that widen the node to width.
+func (f *SqliteFormatter) pad(width int, node interface{ Accept(ast.Visitor) }) {
+	if width > 0 {
+		f.Text(strings.Repeat(" ", max(0, width-f.measure(node))))
+	}
 }
 
 func (f *SqliteFormatter) VisitColumnDefinition(node *ast.ColumnDefinition) {
 	node.ColumnName.Accept(f)
 	if node.TypeName != nil {
+		f.pad(f.columnWidths.name, &node.ColumnName)
 		f.Space()
 		node.TypeName.Accept(f)
 	}
 	if len(node.ColumnConstraints) > 0 {
+		if node.TypeName != nil {
+			f.pad(f.columnWidths.typ, node.TypeName)
+		} else {
+			f.pad(f.columnWidths.name, &node.ColumnName)
+			if f.columnWidths.typ > 0 {
+				f.Text(strings.Repeat(" ", f.columnWidths.typ+1))
+			}
+		}
 		f.Space()
 	}
 	for i := range len(node.ColumnConstraints) {
@@ -139,7 +561,7 @@ func (f *SqliteFormatter) VisitCatalogObjectIdentifier(node *ast.CatalogObjectId
 }
 
 func (f *SqliteFormatter) VisitIdentifier(node *ast.Identifier) {
-	f.Identifier(node.Text)
+	f.identifier(node)
 }
 
 func (f *SqliteFormatter) VisitTypeName(node *ast.TypeName) {
@@ -159,9 +581,32 @@ func (f *SqliteFormatter) VisitTypeName(node *ast.TypeName) {
 }
 
 func (f *SqliteFormatter) VisitColumnConstraintNotNull(node *ast.ColumnConstraint_NotNull) {
+	f.constraintName(node.Name)
+
 	f.Keyword("NOT")
 	f.Space()
 	f.Keyword("NULL")
+	f.conflictClause(node.ConflictClause)
+}
+
+func (f *SqliteFormatter) constraintName(name *ast.ConstraintName) {
+	if name != nil {
+		f.Keyword("CONSTRAINT")
+		f.Space()
+		name.Name.Accept(f)
+		f.Space()
+	}
+}
+
+func (f *SqliteFormatter) conflictClause(clause *ast.ConflictClause) {
+	if clause != nil {
+		f.Space()
+		f.Keyword("ON")
+		f.Space()
+		f.Keyword("CONFLICT")
+		f.Space()
+		f.Keyword(strings.ToUpper(clause.Action.Text))
+	}
 }
 
 func (f *SqliteFormatter) VisitColumnConstraintPrimaryKey(node *ast.ColumnConstraint_PrimaryKey) {
@@ -169,6 +614,7 @@ func (f *SqliteFormatter) VisitColumnConstraintPrimaryKey(node *ast.ColumnConstr
 		f.Keyword("CONSTRAINT")
 		f.Space()
 		node.Name.Name.Accept(f)
+		f.Space()
 	}
 
 	f.Keyword("PRIMARY")
@@ -177,25 +623,136 @@ func (f *SqliteFormatter) VisitColumnConstraintPrimaryKey(node *ast.ColumnConstr
 
 	if node.Order != nil {
 		f.Space()
-		f.Text(node.Order.Text)
+		f.Keyword(strings.ToUpper(node.Order.Text))
+	}
+
+	f.conflictClause(node.ConflictClause)
+
+	if node.AutoIncrement != nil {
 		f.Space()
+		f.Keyword("AUTOINCREMENT")
 	}
+}
 
-	if node.ConflictClause != nil {
+func (f *SqliteFormatter) VisitColumnConstraintUnique(node *ast.ColumnConstraint_Unique) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
 		f.Space()
-		f.Keyword("ON")
+		node.Name.Name.Accept(f)
 		f.Space()
-		f.Keyword("CONFLICT")
+	}
+
+	f.Keyword("UNIQUE")
+}
+
+func (f *SqliteFormatter) VisitColumnConstraintCollate(node *ast.ColumnConstraint_Collate) {
+	f.constraintName(node.Name)
+
+	f.Keyword("COLLATE")
+	f.Space()
+	node.CollationName.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitColumnConstraintDefault(node *ast.ColumnConstraint_Default) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
 		f.Space()
-		f.Text(node.ConflictClause.Action.Text)
+		node.Name.Name.Accept(f)
 		f.Space()
 	}
 
-	if node.AutoIncrement != nil {
+	f.Keyword("DEFAULT")
+	f.Space()
+
+	if isLiteral(node.Default) || isSignedNumber(node.Default) {
+		node.Default.Accept(f)
+	} else {
+		f.Rune('(')
+		node.Default.Accept(f)
+		f.Rune(')')
+	}
+}
+
+func isLiteral(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.LiteralString, *ast.LiteralKeyword, *ast.LiteralNull, *ast.LiteralBoolean,
+		*ast.LiteralSignedInteger, *ast.LiteralUnsignedInteger, *ast.LiteralFloat:
+		return true
+	default:
+		return false
+	}
+}
+
+// isSignedNumber reports a number with a sign, which a DEFAULT takes without
+// parentheses.
+func isSignedNumber(expr ast.Expr) bool {
+	op, ok := expr.(*ast.UnaryOp)
+	if !ok || op.Operator.Kind != '-' && op.Operator.Kind != '+' {
+		return false
+	}
+	switch op.Rhs.(type) {
+	case *ast.LiteralSignedInteger, *ast.LiteralUnsignedInteger, *ast.LiteralFloat:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *SqliteFormatter) VisitColumnConstraintGenerated(node *ast.ColumnConstraint_Generated) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
 		f.Space()
-		f.Keyword("AUTOINCREMENT")
+		node.Name.Name.Accept(f)
+		f.Space()
+	}
+
+	if node.GeneratedKeyword != nil {
+		f.Keyword("GENERATED")
+		f.Space()
+		f.Keyword("ALWAYS")
+		f.Space()
+	}
+
+	f.Keyword("AS")
+	f.Space()
+	f.Rune('(')
+	node.AsExpr.Accept(f)
+	f.Rune(')')
+
+	if storage, ok := node.Storage.(*ast.Keyword); ok && storage != nil {
+		f.Space()
+		f.Keyword(strings.ToUpper(storage.Text))
+	}
+}
+
+func (f *SqliteFormatter) VisitColumnConstraintCheck(node *ast.ColumnConstraint_Check) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
+		f.Space()
+		node.Name.Name.Accept(f)
 		f.Space()
 	}
+
+	f.Keyword("CHECK")
+	f.Space()
+	f.Rune('(')
+	node.CheckExpr.Accept(f)
+	f.Rune(')')
+}
+
+func (f *SqliteFormatter) VisitTableConstraintCheck(node *ast.TableConstraint_Check) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
+		f.Space()
+		node.Name.Name.Accept(f)
+		f.Space()
+	}
+
+	f.Keyword("CHECK")
+	f.Space()
+	f.Rune('(')
+	node.Expr.Accept(f)
+	f.Rune(')')
 }
 
 func (f *SqliteFormatter) VisitColumnConstraintForeignKey(node *ast.ColumnConstraint_ForeignKey) {
@@ -203,6 +760,7 @@ func (f *SqliteFormatter) VisitColumnConstraintForeignKey(node *ast.ColumnConstr
 		f.Keyword("CONSTRAINT")
 		f.Space()
 		node.Name.Name.Accept(f)
+		f.Space()
 	}
 
 	f.VisitForeignKeyClause(&node.FkClause)
@@ -213,6 +771,7 @@ func (f *SqliteFormatter) VisitTableConstraintPrimaryKey(node *ast.TableConstrai
 		f.Keyword("CONSTRAINT")
 		f.Space()
 		node.Name.Name.Accept(f)
+		f.Space()
 	}
 
 	f.Keyword("PRIMARY")
@@ -238,15 +797,33 @@ func (f *SqliteFormatter) VisitTableConstraintPrimaryKey(node *ast.TableConstrai
 	}
 	f.Rune(')')
 
-	if node.ConflictClause != nil {
-		f.Space()
-		f.Keyword("ON")
-		f.Space()
-		f.Keyword("CONFLICT")
+	f.conflictClause(node.ConflictClause)
+}
+
+func (f *SqliteFormatter) VisitTableConstraintUnique(node *ast.TableConstraint_Unique) {
+	if node.Name != nil {
+		f.Keyword("CONSTRAINT")
 		f.Space()
-		f.Keyword(node.ConflictClause.Action.Text)
+		node.Name.Name.Accept(f)
 		f.Space()
 	}
+
+	f.Keyword("UNIQUE")
+	f.Space()
+
+	f.Rune('(')
+	for i, indexedCol := range node.IndexedColumns {
+
+		f.VisitIndexedColumn(&indexedCol)
+
+		if i != len(node.IndexedColumns)-1 {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+	f.Rune(')')
+
+	f.conflictClause(node.ConflictClause)
 }
 
 func (f *SqliteFormatter) VisitIndexedColumn(node *ast.IndexedColumn) {
@@ -261,7 +838,7 @@ func (f *SqliteFormatter) VisitIndexedColumn(node *ast.IndexedColumn) {
 
 	if node.Order != nil {
 		f.Space()
-		f.Text(node.Order.Text)
+		f.Keyword(strings.ToUpper(node.Order.Text))
 	}
 }
 
@@ -270,6 +847,7 @@ func (f *SqliteFormatter) VisitTableConstraintForeignKey(node *ast.TableConstrai
 		f.Keyword("CONSTRAINT")
 		f.Space()
 		node.Name.Name.Accept(f)
+		f.Space()
 	}
 
 	f.Keyword("FOREIGN")
@@ -293,9 +871,9 @@ func (f *SqliteFormatter) VisitForeignKeyClause(node *ast.ForeignKeyClause) {
 	f.Keyword("REFERENCES")
 	f.Space()
 	node.ForeignTable.Accept(f)
-	f.Space()
 
 	if len(node.ForeignColumns) > 0 {
+		f.Space()
 		f.Rune('(')
 		for i, name := range node.ForeignColumns {
 			name.Accept(f)
@@ -312,6 +890,13 @@ func (f *SqliteFormatter) VisitForeignKeyClause(node *ast.ForeignKeyClause) {
 		action.Accept(f)
 	}
 
+	if node.MatchName != nil {
+		f.Space()
+		f.Keyword("MATCH")
+		f.Space()
+		node.MatchName.Accept(f)
+	}
+
 	if node.Deferrable != nil {
 		f.Space()
 		if node.Deferrable.NotKeyword != nil {
@@ -320,12 +905,12 @@ func (f *SqliteFormatter) VisitForeignKeyClause(node *ast.ForeignKeyClause) {
 		}
 
 		f.Keyword("DEFERRABLE")
-		f.Space()
 
-		if node.Deferrable.InitiallyKeyword != nil {
+		if node.Deferrable.InitiallyKeyword != nil && node.Deferrable.Deferrable != nil {
+			f.Space()
 			f.Keyword("INITIALLY")
 			f.Space()
-			f.Text(node.Deferrable.Deferrable.Text)
+			f.Keyword(strings.ToUpper(node.Deferrable.Deferrable.Text))
 		}
 	}
 }
@@ -352,6 +937,142 @@ func (f *SqliteFormatter) VisitForeignKeyActionNoAction(node *ast.NoAction) {
 	f.Keyword("ACTION")
 }
 
+func (f *SqliteFormatter) VisitForeignKeyActionCascade(node *ast.Cascade) {
+	f.Keyword("CASCADE")
+}
+
+func (f *SqliteFormatter) VisitForeignKeyActionRestrict(node *ast.Restrict) {
+	f.Keyword("RESTRICT")
+}
+
+func (f *SqliteFormatter) VisitForeignKeyActionSetNull(node *ast.SetNull) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("NULL")
+}
+
+func (f *SqliteFormatter) VisitForeignKeyActionSetDefault(node *ast.SetDefault) {
+	f.Keyword("SET")
+	f.Space()
+	f.Keyword("DEFAULT")
+}
+
 func (f *SqliteFormatter) VisitLiteralSignedInteger(node *ast.LiteralSignedInteger) {
 	f.Text(node.Token.Text)
 }
+
+func (f *SqliteFormatter) VisitLiteralUnsignedInteger(node *ast.LiteralUnsignedInteger) {
+	f.Text(node.Token.Text)
+}
+
+func (f *SqliteFormatter) VisitLiteralFloat(node *ast.LiteralFloat) {
+	f.Text(node.Token.Text)
+}
+
+func (f *SqliteFormatter) VisitLiteralBoolean(node *ast.LiteralBoolean) {
+	if node.Value {
+		f.Keyword("TRUE")
+	} else {
+		f.Keyword("FALSE")
+	}
+}
+
+func (f *SqliteFormatter) VisitLiteralNull(node *ast.LiteralNull) {
+	f.Keyword("NULL")
+}
+
+func (f *SqliteFormatter) VisitLiteralKeyword(node *ast.LiteralKeyword) {
+	// other bare words are values, their case is kept
+	if ast.IsCurrentTimeKeyword(node.Token) {
+		f.Keyword(strings.ToUpper(node.Token.Text))
+		return
+	}
+	f.Text(node.Token.Text)
+}
+
+func (f *SqliteFormatter) VisitLiteralString(node *ast.LiteralString) {
+	f.Rune('\'')
+	f.Text(strings.ReplaceAll(node.Value, "'", "''"))
+	f.Rune('\'')
+}
+
+func (f *SqliteFormatter) VisitUnaryOp(node *ast.UnaryOp) {
+	if node.Operator.Kind.IsKeyword() {
+		f.Keyword(strings.ToUpper(node.Operator.Text))
+		f.Space()
+	} else {
+		f.Text(node.Operator.Text)
+	}
+	node.Rhs.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitBinaryOp(node *ast.BinaryOp) {
+	node.Lhs.Accept(f)
+	f.Space()
+	if node.Operator.Kind.IsKeyword() {
+		f.Keyword(strings.ToUpper(node.Operator.Text))
+	} else {
+		f.Text(node.Operator.Text)
+	}
+	if node.Not != nil {
+		f.Space()
+		f.Keyword("NOT")
+	}
+	f.Space()
+	node.Rhs.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitColumnName(node *ast.ColumnName) {
+	if node.Schema != nil {
+		node.Schema.Accept(f)
+		f.Rune('.')
+	}
+	if node.Table != nil {
+		node.Table.Accept(f)
+		f.Rune('.')
+	}
+	node.Column.Accept(f)
+}
+
+func (f *SqliteFormatter) VisitCaseExpression(node *ast.CaseExpression) {
+	f.Keyword("CASE")
+	if node.Operand != nil {
+		f.Space()
+		node.Operand.Accept(f)
+	}
+	for _, when := range node.Cases {
+		f.Space()
+		f.Keyword("WHEN")
+		f.Space()
+		when.When.Accept(f)
+		f.Space()
+		f.Keyword("THEN")
+		f.Space()
+		when.Then.Accept(f)
+	}
+	if node.Else != nil {
+		f.Space()
+		f.Keyword("ELSE")
+		f.Space()
+		node.Else.Accept(f)
+	}
+	f.Space()
+	f.Keyword("END")
+}
+
+func (f *SqliteFormatter) VisitExprList(node ast.ExprList) {
+	f.Rune('(')
+	for i, expr := range node {
+		expr.Accept(f)
+		if i < len(node)-1 {
+			f.Rune(',')
+			f.Space()
+		}
+	}
+	f.Rune(')')
+}
+
+func (f *SqliteFormatter) VisitFunctionCall(node *ast.FunctionCall) {
+	f.Text(node.Name.Text)
+	node.Args.Accept(f)
+}
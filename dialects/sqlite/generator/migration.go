@@ -0,0 +1,276 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/backend/formatter"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+var (
+	ErrUnloweredOp = errors.New("operation must be lowered by the planner before it can be generated")
+)
+
+// recreatePrefix is prepended to the name of the table that rows are
+// copied into while a table is being recreated.
+const recreatePrefix = "_new_"
+
+// keepPrefix is prepended to the name of the temporary table that keeps the
+// rows of a virtual table while it is being recreated.
+const keepPrefix = "_old_"
+
+// Generate renders the plan as a sqlite migration script.
+func Generate(plan []diff.Op) (string, error) {
+	sb := &strings.Builder{}
+	gen := NewSqliteFormatter(false, formatter.NewCoreFormatter(sb, 80, "\"\""))
+	if err := gen.VisitOps(plan); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// VisitOps renders a plan produced by Plan as an executable sqlite migration script.
+func (f *SqliteFormatter) VisitOps(ops []diff.Op) error {
+	for _, op := range ops {
+		switch o := op.(type) {
+		case *diff.NewTableOp:
+			f.statement(func() { o.CreateTable.Accept(f) })
+		case *diff.DelTableOp:
+			f.statement(func() {
+				f.VisitDropTable(&ast.DropTable{TableIdentifier: *o.CatalogObjectIdentifier})
+			})
+		case *diff.RenameTableOp:
+			f.alterTable(o.From, &ast.RenameTable{NewTableName: o.To.ObjectName})
+		case *diff.NewColOp:
+			f.alterTable(o.Table, &ast.AddColumn{ColumnDefinition: *o.Col})
+		case *diff.DelColOp:
+			f.alterTable(o.Table, &ast.DropColumn{ColumnName: *o.Col})
+		case *diff.RenameColOp:
+			f.alterTable(o.Table, &ast.RenameColumn{ColumnName: *o.FromCol, NewColumnName: *o.ToCol})
+		case *diff.NewIndexOp:
+			f.statement(func() { o.CreateIndex.Accept(f) })
+		case *diff.DelIndexOp:
+			f.statement(func() {
+				f.VisitDropIndex(&ast.DropIndex{IndexIdentifier: *o.CatalogObjectIdentifier})
+			})
+		case *diff.NewViewOp:
+			f.statement(func() { o.CreateView.Accept(f) })
+		case *diff.DelViewOp:
+			f.statement(func() {
+				f.VisitDropView(&ast.DropView{ViewIdentifier: *o.CatalogObjectIdentifier})
+			})
+		case *diff.NewTriggerOp:
+			f.statement(func() { o.CreateTrigger.Accept(f) })
+		case *diff.DelTriggerOp:
+			f.statement(func() {
+				f.VisitDropTrigger(&ast.DropTrigger{TriggerIdentifier: *o.CatalogObjectIdentifier})
+			})
+		case *diff.RecreateTableOp:
+			f.recreateTable(o)
+		case *diff.NewVirtualTableOp:
+			f.statement(func() { o.CreateVirtualTable.Accept(f) })
+		case *diff.RecreateVirtualTableOp:
+			f.recreateVirtualTable(o)
+		case *diff.PragmaOp:
+			f.statement(func() { f.pragma(o) })
+		case *diff.CommentOp:
+			f.comment(o.Text)
+		case *diff.TransactionOp:
+			f.statement(func() { f.Keyword("BEGIN") })
+			if err := f.VisitOps(o.Ops); err != nil {
+				return err
+			}
+			f.statement(func() { f.Keyword("COMMIT") })
+		default:
+			return fmt.Errorf("%w: %T", ErrUnloweredOp, op)
+		}
+	}
+	return nil
+}
+
+func (f *SqliteFormatter) statement(fn func()) {
+	fn()
+	f.Rune(';')
+	f.Break()
+	f.Break()
+}
+
+func (f *SqliteFormatter) comment(text string) {
+	for line := range strings.Lines(text) {
+		f.Text("-- ")
+		f.Text(strings.TrimSuffix(line, "\n"))
+		f.Break()
+	}
+}
+
+func (f *SqliteFormatter) alterTable(table *ast.CatalogObjectIdentifier, alteration ast.TableAlteration) {
+	f.statement(func() {
+		f.VisitAlterTable(&ast.AlterTable{
+			TableIdentifier: table,
+			Alteration:      alteration,
+		})
+	})
+}
+
+func (f *SqliteFormatter) pragma(op *diff.PragmaOp) {
+	f.Keyword("PRAGMA")
+	f.Space()
+	f.Text(op.Key)
+	if op.Value != "" {
+		f.Space()
+		f.Rune('=')
+		f.Space()
+		f.Text(op.Value)
+	}
+}
+
+// recreateTable follows the table recreation procedure from
+// https://www.sqlite.org/lang_altertable.html#otheralter
+func (f *SqliteFormatter) recreateTable(op *diff.RecreateTableOp) {
+	newTableIdent := &ast.CatalogObjectIdentifier{
+		SchemaName: op.Table.SchemaName,
+		ObjectName: op.Table.ObjectName,
+	}
+	newTableIdent.ObjectName.Text = recreatePrefix + op.Table.ObjectName.Text
+
+	newTable := *op.CreateTable
+	newTable.TableIdentifier = newTableIdent
+
+	f.statement(func() { newTable.Accept(f) })
+
+	if len(op.Columns) > 0 {
+		f.statement(func() {
+			f.Keyword("INSERT")
+			f.Space()
+			f.Keyword("INTO")
+			f.Space()
+			newTableIdent.Accept(f)
+			f.Space()
+			f.Rune('(')
+			for i, pair := range op.Columns {
+				pair.B.Accept(f)
+				if i < len(op.Columns)-1 {
+					f.Rune(',')
+					f.Space()
+				}
+			}
+			f.Rune(')')
+			f.Space()
+			f.Keyword("SELECT")
+			f.Space()
+			for i, pair := range op.Columns {
+				pair.A.Accept(f)
+				if i < len(op.Columns)-1 {
+					f.Rune(',')
+					f.Space()
+				}
+			}
+			f.Space()
+			f.Keyword("FROM")
+			f.Space()
+			op.Table.Accept(f)
+		})
+	}
+
+	f.statement(func() {
+		f.VisitDropTable(&ast.DropTable{TableIdentifier: *op.Table})
+	})
+
+	f.statement(func() {
+		f.VisitAlterTable(&ast.AlterTable{
+			TableIdentifier: newTableIdent,
+			Alteration:      &ast.RenameTable{NewTableName: op.Table.ObjectName},
+		})
+	})
+}
+
+// recreateVirtualTable keeps the rows of the virtual table in a temporary
+// table while it is dropped and created again, the table is not renamed as
+// sqlite refuses to rename a table while a view or trigger refers to a table
+// that does not exist.
+func (f *SqliteFormatter) recreateVirtualTable(op *diff.RecreateVirtualTableOp) {
+	keepIdent := &ast.CatalogObjectIdentifier{
+		SchemaName: &ast.Identifier{Text: "temp"},
+		ObjectName: ast.Identifier{Text: keepPrefix + op.Table.ObjectName.Text},
+	}
+
+	columns := func() {
+		f.Text("rowid")
+		for _, column := range op.Columns {
+			f.Rune(',')
+			f.Space()
+			f.Identifier(column)
+		}
+	}
+
+	if len(op.Columns) > 0 {
+		f.statement(func() {
+			f.Keyword("CREATE")
+			f.Space()
+			f.Keyword("TABLE")
+			f.Space()
+			keepIdent.Accept(f)
+			f.Space()
+			f.Keyword("AS")
+			f.Space()
+			f.Keyword("SELECT")
+			f.Space()
+			columns()
+			f.Space()
+			f.Keyword("FROM")
+			f.Space()
+			op.Table.Accept(f)
+		})
+	}
+
+	f.statement(func() {
+		f.VisitDropTable(&ast.DropTable{TableIdentifier: *op.Table})
+	})
+
+	f.statement(func() { op.CreateVirtualTable.Accept(f) })
+
+	switch {
+	case op.Rebuild:
+		f.statement(func() {
+			f.Keyword("INSERT")
+			f.Space()
+			f.Keyword("INTO")
+			f.Space()
+			op.Table.Accept(f)
+			f.Space()
+			f.Rune('(')
+			op.Table.ObjectName.Accept(f)
+			f.Rune(')')
+			f.Space()
+			f.Keyword("VALUES")
+			f.Space()
+			f.Text("('rebuild')")
+		})
+	case len(op.Columns) > 0:
+		f.statement(func() {
+			f.Keyword("INSERT")
+			f.Space()
+			f.Keyword("INTO")
+			f.Space()
+			op.Table.Accept(f)
+			f.Space()
+			f.Rune('(')
+			columns()
+			f.Rune(')')
+			f.Space()
+			f.Keyword("SELECT")
+			f.Space()
+			columns()
+			f.Space()
+			f.Keyword("FROM")
+			f.Space()
+			keepIdent.Accept(f)
+		})
+
+		f.statement(func() {
+			f.VisitDropTable(&ast.DropTable{TableIdentifier: *keepIdent})
+		})
+	}
+}
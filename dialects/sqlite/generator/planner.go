@@ -1,12 +1,12 @@
 package generator
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
-	"os"
 	"slices"
 	"woodybriggs/justmigrate/backend/diff"
-	"woodybriggs/justmigrate/backend/formatter"
+	"woodybriggs/justmigrate/backend/schema"
 	"woodybriggs/justmigrate/frontend/ast"
 )
 
@@ -53,7 +53,10 @@ import (
 //
 //  6. Transaction Grouping: Group related sequences of operations (like the
 //     entire table recreation process) into logical units that should be
-//     executed within a single transaction to ensure atomicity.
+//     executed within a single transaction to ensure atomicity. The whole
+//     plan runs in one transaction, the foreign key pragmas have no effect
+//     within a transaction so they are set around it, while the foreign key
+//     check runs within it so that a violation rolls the migration back.
 func (gen *SqliteFormatter) Plan(src, tgt []ast.Statement, ops []diff.Op) ([]diff.Op, error) {
 	var errs []error
 	srcGraph, err := NewSchemaGraphFromStatements(src)
@@ -72,85 +75,492 @@ func (gen *SqliteFormatter) Plan(src, tgt []ast.Statement, ops []diff.Op) ([]dif
 			}
 		}
 	}
-	_ = tgtGraph
-
 	if len(errs) > 0 {
 		return nil, errors.Join(errs...)
 	}
 
+	// 1. Collect the tables that can only be migrated by a full recreation,
+	// every column level op on those tables is subsumed by the recreation.
+	recreate := map[string]bool{}
+	renamedCols := map[string][]*diff.RenameColOp{}
 	for _, op := range ops {
-		fmt.Printf("%T %+v", op, op)
+		switch o := op.(type) {
+		case *diff.DelColOp:
+			if !canDropColumn(srcGraph, o.Table, o.Col) {
+				recreate[o.Table.Canonical()] = true
+			}
+		case *diff.NewColOp:
+			if !canAddColumn(o.Col) {
+				recreate[o.Table.Canonical()] = true
+			}
+		case *diff.ChangeColTypeOp:
+			recreate[o.Table.Canonical()] = true
+		case *diff.ChangeColConstraintsOp:
+			// sqlite can not alter the constraints of an existing column
+			recreate[o.Table.Canonical()] = true
+		case *diff.ChangeTableConstraintsOp:
+			recreate[o.Table.Canonical()] = true
+		case *diff.ChangeTableOptionsOp:
+			// the options decide how the rows of the table are stored
+			recreate[o.Table.Canonical()] = true
+		case *diff.RenameColOp:
+			renamedCols[o.Table.Canonical()] = append(renamedCols[o.Table.Canonical()], o)
+		}
 	}
 
+	// 2. Lower the operations that are not natively supported.
 	var plan []diff.Op
+	notes := []diff.Op{}
+	lowered := map[string]bool{}
 	for _, op := range ops {
-		switch o := op.(type) {
-		case *diff.DelColOp:
-			// can we be certain about this lookup?, given that the ops were generated from the schemas
-
-			// if deleting the column will break a foreign key somewhere
-			// we need to drop the fk constraint in that table
-			// for now I think we push a generic "drop constraint op"
-			// and we will have to do at least 2 passes over the plan
-			// to ensure that the plan is suitable for the dialect in this case 'sqlite'
-
-			ogTable := srcGraph.Tables[o.Table.ObjectName.Text]
-			childTables := srcGraph.Columns[ogTable.Name][o.Col.Text].DependantTables
-			for _, child := range childTables {
-				// for each dependant child
-				_ = child
+		if change, ok := op.(*diff.ChangeVirtualTableOp); ok {
+			recreation, note := lowerVirtualTableRecreation(change)
+			plan = append(plan, recreation)
+			if note != "" {
+				notes = append(notes, &diff.CommentOp{Text: note})
 			}
+			continue
+		}
 
-			newTable := ast.Copy(ogTable.CreateTable).(*ast.CreateTable)
-			dropColumn(newTable, o.Col)
+		table := columnOpTable(op)
+		if table == nil || !recreate[table.Canonical()] {
+			// By default, assume the operation is natively supported (e.g., CreateTable,
+			// AddColumn, DropTable). These can be added directly to the plan.
+			plan = append(plan, op)
+			continue
+		}
 
-			fmtter := NewSqliteFormatter(false, formatter.NewCoreFormatter(os.Stdout, 80, "\"\""))
-			fmtter.VisitStatements([]ast.Statement{newTable})
-		case *diff.ChangeColTypeOp:
-			// These operations are not natively supported and require lowering to a
-			// full table recreation.
-			// The `lowerTableRecreation` helper would generate the sequence:
-			//  - CREATE new table
-			//  - INSERT INTO new_table SELECT ... FROM old_table
-			//  - DROP old_table
-			//  - RENAME new_table
-			//  - Re-create indexes and triggers
-			// recreateOps, err := gen.lowerTableRecreation(o.TableName, statements, schemaGraph)
-			// if err != nil {
-			// 	return nil, err
-			// }
-			// plan = append(plan, recreateOps...)
-			_ = o // Avoid unused variable error for this example
+		if lowered[table.Canonical()] {
+			continue
+		}
+		lowered[table.Canonical()] = true
+
+		srcTable, hasSrc := srcGraph.TableByIdent(table)
+		tgtTable, hasTgt := tgtGraph.TableByIdent(table)
+		if !hasSrc || !hasTgt {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTable, table.ObjectName.Text)
+		}
+
+		plan = append(plan, lowerTableRecreation(srcTable, tgtTable, renamedCols[table.Canonical()]))
 
+		planned := func(match func(diff.Op) bool) bool {
+			return slices.ContainsFunc(ops, match) || slices.ContainsFunc(plan, match)
+		}
+		plan = append(plan, recreateDependants(
+			srcGraph.Dependants(srcTable),
+			tgtGraph.Dependants(tgtTable),
+			planned,
+		)...)
+	}
+
+	// 3. Sort the final plan to respect dependencies.
+	plan = sortOps(plan, srcGraph, tgtGraph)
+
+	// 4. Recreating a table drops the original, which must not cascade or fail
+	// on the foreign keys that reference it, so the checks are disabled for the
+	// duration of the migration and verified once it is complete.
+	//
+	// Tables in a foreign key cycle can not be dropped in an order where no
+	// foreign key is violated in between, unless the checks are already
+	// disabled they are deferred until the migration commits.
+	droppedCycles, createdCycles := plannedCycles(plan, srcGraph, tgtGraph)
+	for _, cycle := range createdCycles {
+		notes = append(notes, &diff.CommentOp{Text: fmt.Sprintf(
+			"tables %s reference each other, sqlite does not check foreign keys when a table is created so they are created in name order",
+			cycle,
+		)})
+	}
+
+	if len(plan) == 0 {
+		return notes, nil
+	}
+
+	if len(lowered) > 0 {
+		for _, cycle := range droppedCycles {
+			notes = append(notes, &diff.CommentOp{Text: fmt.Sprintf(
+				"tables %s reference each other, they are dropped while foreign keys are disabled",
+				cycle,
+			)})
+		}
+		plan = []diff.Op{
+			&diff.PragmaOp{Key: "foreign_keys", Value: "OFF"},
+			&diff.TransactionOp{Ops: append(plan, &diff.PragmaOp{Key: "foreign_key_check"})},
+			&diff.PragmaOp{Key: "foreign_keys", Value: "ON"},
+		}
+	} else if len(droppedCycles) > 0 {
+		for _, cycle := range droppedCycles {
+			notes = append(notes, &diff.CommentOp{Text: fmt.Sprintf(
+				"tables %s reference each other, foreign key checks are deferred until the migration commits so they can be dropped in any order",
+				cycle,
+			)})
+		}
+		plan = []diff.Op{&diff.TransactionOp{
+			Ops: append([]diff.Op{&diff.PragmaOp{Key: "defer_foreign_keys", Value: "ON"}}, plan...),
+		}}
+	} else {
+		plan = []diff.Op{&diff.TransactionOp{Ops: plan}}
+	}
+
+	return append(notes, plan...), nil
+}
+
+var ErrUnknownTable = errors.New("table does not exist in schema")
+
+// sortOps orders the plan so that every operation runs after the operations
+// it depends on. Triggers, views and indexes are dropped first, then tables
+// are dropped children before parents, renamed, created parents before
+// children and altered, finally the indexes, views and triggers are created
+// once the tables they use exist.
+//
+// The tables of a foreign key cycle are kept next to each other, see
+// plannedCycles for how the plan is made to tolerate them.
+func sortOps(plan []diff.Op, src, tgt *SchemaGraph) []diff.Op {
+	dropOrder, _ := src.sort()
+	createOrder, _ := tgt.sort()
+	slices.Reverse(createOrder)
+
+	dropRank := map[string]int{}
+	for i, table := range dropOrder {
+		dropRank[table.CreateTable.TableIdentifier.Canonical()] = i
+	}
+
+	createRank := map[string]int{}
+	for i, table := range createOrder {
+		createRank[table.CreateTable.TableIdentifier.Canonical()] = i
+	}
+
+	type rank struct {
+		phase    int
+		position int
+	}
+
+	ranks := map[diff.Op]rank{}
+	for _, op := range plan {
+		switch o := op.(type) {
+		case *diff.DelTriggerOp:
+			ranks[op] = rank{0, 0}
+		case *diff.DelViewOp:
+			ranks[op] = rank{1, 0}
+		case *diff.DelIndexOp:
+			ranks[op] = rank{2, 0}
+		case *diff.DelTableOp:
+			ranks[op] = rank{3, dropRank[o.CatalogObjectIdentifier.Canonical()]}
+		case *diff.RenameTableOp:
+			ranks[op] = rank{4, 0}
+		case *diff.NewTableOp:
+			ranks[op] = rank{5, createRank[o.TableIdentifier.Canonical()]}
+		case *diff.NewVirtualTableOp:
+			ranks[op] = rank{5, len(createOrder)}
+		case *diff.RecreateTableOp:
+			ranks[op] = rank{6, createRank[o.Table.Canonical()]}
+		case *diff.RecreateVirtualTableOp:
+			ranks[op] = rank{6, len(createOrder)}
+		case *diff.NewIndexOp:
+			ranks[op] = rank{7, 0}
+		case *diff.NewViewOp:
+			ranks[op] = rank{8, 0}
+		case *diff.NewTriggerOp:
+			ranks[op] = rank{9, 0}
 		default:
-			// By default, assume the operation is natively supported (e.g., CreateTable,
-			// AddColumn, DropTable). These can be added directly to the plan.
-			// The final sorting step will handle their execution order.
-			plan = append(plan, o)
+			if table := columnOpTable(op); table != nil {
+				ranks[op] = rank{6, createRank[table.Canonical()]}
+			} else {
+				ranks[op] = rank{6, 0}
+			}
 		}
 	}
 
-	// 3. Sort the final plan to respect dependencies (e.g., using a topological sort).
-	// sortedPlan, err := sortOps(plan, schemaGraph)
+	sorted := slices.Clone(plan)
+	slices.SortStableFunc(sorted, func(a, b diff.Op) int {
+		return cmp.Or(
+			cmp.Compare(ranks[a].phase, ranks[b].phase),
+			cmp.Compare(ranks[a].position, ranks[b].position),
+		)
+	})
+
+	return sorted
+}
+
+// plannedCycles returns the foreign key cycles that the plan drops tables
+// from and the cycles that it creates tables in.
+func plannedCycles(plan []diff.Op, src, tgt *SchemaGraph) (dropped, created []Cycle) {
+	_, srcCycles := src.sort()
+	_, tgtCycles := tgt.sort()
+
+	touches := func(cycles []Cycle, sg *SchemaGraph, table *ast.CatalogObjectIdentifier, result []Cycle) []Cycle {
+		t, ok := sg.TableByIdent(table)
+		if !ok {
+			return result
+		}
+		for _, cycle := range cycles {
+			if cycle.Contains(t) && !slices.ContainsFunc(result, func(c Cycle) bool { return c.Contains(t) }) {
+				result = append(result, cycle)
+			}
+		}
+		return result
+	}
 
-	// 4. Add final pragmas.
-	// plan = append(plan, &PragmaOp{Key: "foreign_keys", Value: "ON"})
-	// plan = append(plan, &PragmaOp{Key: "foreign_key_check", Value: ""})
+	for _, op := range plan {
+		switch o := op.(type) {
+		case *diff.DelTableOp:
+			dropped = touches(srcCycles, src, o.CatalogObjectIdentifier, dropped)
+		case *diff.NewTableOp:
+			created = touches(tgtCycles, tgt, o.TableIdentifier, created)
+		}
+	}
 
-	return plan, nil
+	return dropped, created
 }
 
-func dropColumn(table *ast.CreateTable, colName *ast.Identifier) {
-	indexOfCol := slices.IndexFunc(table.TableDefinition.ColumnDefinitions, func(col ast.ColumnDefinition) bool {
-		return col.ColumnName.Eq(colName)
+// recreateDependants drops the views and triggers that use a table before it
+// is rebuilt and creates the indexes, views and triggers of the rebuilt table
+// afterwards, skipping any that the plan already drops or creates.
+func recreateDependants(src, tgt Dependants, planned func(match func(diff.Op) bool) bool) []diff.Op {
+	ops := []diff.Op{}
+
+	for _, view := range src.Views {
+		if !planned(func(op diff.Op) bool {
+			o, ok := op.(*diff.DelViewOp)
+			return ok && o.CatalogObjectIdentifier.Canonical() == view.CreateView.ViewIdentifier.Canonical()
+		}) {
+			ops = append(ops, &diff.DelViewOp{CatalogObjectIdentifier: &view.CreateView.ViewIdentifier})
+		}
+	}
+
+	for _, trigger := range src.Triggers {
+		if !planned(func(op diff.Op) bool {
+			o, ok := op.(*diff.DelTriggerOp)
+			return ok && o.CatalogObjectIdentifier.Canonical() == trigger.CreateTrigger.TriggerIdentifier.Canonical()
+		}) {
+			ops = append(ops, &diff.DelTriggerOp{CatalogObjectIdentifier: &trigger.CreateTrigger.TriggerIdentifier})
+		}
+	}
+
+	for _, index := range tgt.Indexes {
+		if !planned(func(op diff.Op) bool {
+			o, ok := op.(*diff.NewIndexOp)
+			return ok && o.IndexIdentifier.Canonical() == index.CreateIndex.IndexIdentifier.Canonical()
+		}) {
+			ops = append(ops, &diff.NewIndexOp{CreateIndex: index.CreateIndex})
+		}
+	}
+
+	for _, view := range tgt.Views {
+		if !planned(func(op diff.Op) bool {
+			o, ok := op.(*diff.NewViewOp)
+			return ok && o.ViewIdentifier.Canonical() == view.CreateView.ViewIdentifier.Canonical()
+		}) {
+			ops = append(ops, &diff.NewViewOp{CreateView: view.CreateView})
+		}
+	}
+
+	for _, trigger := range tgt.Triggers {
+		if !planned(func(op diff.Op) bool {
+			o, ok := op.(*diff.NewTriggerOp)
+			return ok && o.TriggerIdentifier.Canonical() == trigger.CreateTrigger.TriggerIdentifier.Canonical()
+		}) {
+			ops = append(ops, &diff.NewTriggerOp{CreateTrigger: trigger.CreateTrigger})
+		}
+	}
+
+	return ops
+}
+
+// columnOpTable returns the table that a column level operation applies to.
+func columnOpTable(op diff.Op) *ast.CatalogObjectIdentifier {
+	switch o := op.(type) {
+	case *diff.NewColOp:
+		return o.Table
+	case *diff.DelColOp:
+		return o.Table
+	case *diff.RenameColOp:
+		return o.Table
+	case *diff.ChangeColTypeOp:
+		return o.Table
+	case *diff.ChangeColConstraintsOp:
+		return o.Table
+	case *diff.ChangeTableConstraintsOp:
+		return o.Table
+	case *diff.ChangeTableOptionsOp:
+		return o.Table
+	default:
+		return nil
+	}
+}
+
+// canDropColumn reports whether `ALTER TABLE ... DROP COLUMN` is able to remove
+// the column, sqlite refuses to drop columns that are part of a key or that
+// other tables reference.
+func canDropColumn(sg *SchemaGraph, tableIdent *ast.CatalogObjectIdentifier, colName *ast.Identifier) bool {
+	table, ok := sg.TableByIdent(tableIdent)
+	if !ok {
+		return false
+	}
+
+	if col, ok := table.Columns[colName.Canonical()]; ok {
+		if len(col.DependantTables) > 0 {
+			return false
+		}
+
+		// sqlite refuses to drop a column that is indexed or used by a view or trigger
+		dependants := sg.Dependants(table)
+		for _, index := range dependants.Indexes {
+			if slices.Contains(index.Columns, col) || !indexOnlyUsesColumns(index.CreateIndex) {
+				return false
+			}
+		}
+		for _, view := range dependants.Views {
+			if slices.Contains(view.Columns, col) {
+				return false
+			}
+		}
+		for _, trigger := range dependants.Triggers {
+			if slices.Contains(trigger.Columns, col) {
+				return false
+			}
+		}
+	}
+
+	for _, col := range table.CreateTable.TableDefinition.ColumnDefinitions {
+		if !col.ColumnName.Eq(colName) {
+			continue
+		}
+		for _, constraint := range col.ColumnConstraints {
+			switch constraint.(type) {
+			case *ast.ColumnConstraint_PrimaryKey,
+				*ast.ColumnConstraint_Unique,
+				*ast.ColumnConstraint_ForeignKey:
+				return false
+			}
+		}
+	}
+
+	for _, constraint := range table.CreateTable.TableDefinition.TableConstraints {
+		switch c := constraint.(type) {
+		case *ast.TableConstraint_PrimaryKey:
+			if indexesColumn(c.IndexedColumns, colName) {
+				return false
+			}
+		case *ast.TableConstraint_Unique:
+			if indexesColumn(c.IndexedColumns, colName) {
+				return false
+			}
+		case *ast.TableConstraint_ForeignKey:
+			if slices.ContainsFunc(c.Columns, func(ident ast.Identifier) bool { return ident.Eq(colName) }) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// indexesColumn reports whether the column is one of the indexed columns.
+func indexesColumn(indexed []ast.IndexedColumn, colName *ast.Identifier) bool {
+	return slices.ContainsFunc(indexed, func(column ast.IndexedColumn) bool {
+		ident, ok := column.Subject.(*ast.Identifier)
+		return ok && ident.Eq(colName)
 	})
+}
+
+// indexOnlyUsesColumns reports whether the index is on plain columns, the
+// columns used by expressions and partial indexes are not tracked.
+func indexOnlyUsesColumns(index *ast.CreateIndex) bool {
+	if index.WhereExpr != nil {
+		return false
+	}
+	for _, indexed := range index.IndexedColumns {
+		if _, ok := indexed.Subject.(*ast.Identifier); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// canAddColumn reports whether `ALTER TABLE ... ADD COLUMN` is able to add the
+// column, see https://www.sqlite.org/lang_altertable.html#altertabaddcol
+func canAddColumn(col *ast.ColumnDefinition) bool {
+	notNull := false
+	hasDefault := false
+
+	for _, constraint := range col.ColumnConstraints {
+		switch c := constraint.(type) {
+		case *ast.ColumnConstraint_PrimaryKey, *ast.ColumnConstraint_Unique:
+			return false
+		case *ast.ColumnConstraint_NotNull:
+			notNull = true
+		case *ast.ColumnConstraint_Default:
+			_, isNull := c.Default.(*ast.LiteralNull)
+			hasDefault = !isNull
+		}
+	}
+
+	return !notNull || hasDefault
+}
+
+// lowerTableRecreation replaces the source table with the target definition,
+// copying every column that survives the migration, following any renames.
+func lowerTableRecreation(src, tgt *Table, renames []*diff.RenameColOp) *diff.RecreateTableOp {
+	columns := []ast.IdentifierPair{}
+	for _, tgtCol := range tgt.CreateTable.TableDefinition.ColumnDefinitions {
+		srcName := tgtCol.ColumnName
+		for _, rename := range renames {
+			if rename.ToCol.Eq(&tgtCol.ColumnName) {
+				srcName = *rename.FromCol
+			}
+		}
+
+		if _, ok := src.Columns[srcName.Canonical()]; !ok {
+			// this is a new column, it will take its default value
+			continue
+		}
+
+		columns = append(columns, ast.IdentifierPair{A: srcName, B: tgtCol.ColumnName})
+	}
 
-	if indexOfCol == -1 {
-		return
+	return &diff.RecreateTableOp{
+		Table:       src.CreateTable.TableIdentifier,
+		CreateTable: tgt.CreateTable,
+		Columns:     columns,
 	}
+}
+
+// lowerVirtualTableRecreation creates the virtual table again with its new
+// arguments. The rows of the columns the tables have in common are copied
+// across, unless the table is a full text index of external content, which is
+// rebuilt from the content instead. A contentless index keeps no rows to copy,
+// which is explained by the note.
+func lowerVirtualTableRecreation(op *diff.ChangeVirtualTableOp) (recreate *diff.RecreateVirtualTableOp, note string) {
+	src := schema.VirtualTableFromAst(op.From)
+	tgt := schema.VirtualTableFromAst(op.To)
+
+	recreate = &diff.RecreateVirtualTableOp{
+		Table:              &op.To.TableIdentifier,
+		CreateVirtualTable: op.To,
+	}
+
+	if content, external := tgt.Option("content"); external && isFullTextModule(tgt.Module) {
+		if content != "" {
+			recreate.Rebuild = true
+			return recreate, ""
+		}
+		return recreate, fmt.Sprintf("%s is a contentless full text index, its rows have to be inserted again", op.To.TableIdentifier.ObjectName.Text)
+	}
+
+	if content, external := src.Option("content"); external && content == "" && isFullTextModule(src.Module) {
+		return recreate, fmt.Sprintf("%s was a contentless full text index, its rows have to be inserted again", op.To.TableIdentifier.ObjectName.Text)
+	}
+
+	tgtColumns := tgt.Columns()
+	for _, column := range src.Columns() {
+		if slices.Contains(tgtColumns, column) {
+			recreate.Columns = append(recreate.Columns, column)
+		}
+	}
+
+	return recreate, ""
+}
 
-	table.TableDefinition.ColumnDefinitions = append(
-		table.TableDefinition.ColumnDefinitions[:indexOfCol],
-		table.TableDefinition.ColumnDefinitions[indexOfCol+1:]...,
-	)
+func isFullTextModule(module string) bool {
+	return module == "fts4" || module == "fts5"
 }
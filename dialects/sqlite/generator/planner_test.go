@@ -0,0 +1,517 @@
+package generator_test
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+	"woodybriggs/justmigrate/dialects/sqlite/verify/verifytest"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+type schemaCase struct {
+	name   string
+	schema string
+}
+
+// every schema is migrated to every other schema, so each planner feature
+// is exercised in both directions.
+var schemaCases = []schemaCase{
+	{
+		name:   "empty",
+		schema: "",
+	},
+	{
+		name: "users",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL,
+				name text
+			);`,
+	},
+	{
+		name: "users with added columns",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL,
+				name text,
+				age integer DEFAULT 0,
+				status text NOT NULL DEFAULT 'active'
+			);`,
+	},
+	{
+		name: "users with changed types",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email varchar(255) NOT NULL,
+				name varchar(64)
+			);`,
+	},
+	{
+		name: "users with unique email",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL UNIQUE COLLATE NOCASE,
+				name text DEFAULT 'anonymous'
+			);`,
+	},
+	{
+		name: "users with posts",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL
+			);
+			CREATE TABLE posts (
+				id integer PRIMARY KEY,
+				user_id integer NOT NULL REFERENCES users(id),
+				body text
+			);`,
+	},
+	{
+		name: "users with indexes",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email varchar(255) NOT NULL,
+				name text
+			);
+			CREATE UNIQUE INDEX users_email ON users (email);
+			CREATE INDEX users_name ON users (name COLLATE NOCASE DESC);`,
+	},
+	{
+		name: "users with view and trigger",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL,
+				name text
+			);
+			CREATE TABLE audit (user_id integer, note text);
+			CREATE INDEX users_email ON users (email);
+			CREATE VIEW named_users AS SELECT id, name FROM users WHERE name IS NOT NULL;
+			CREATE VIEW named_user_count (total) AS SELECT count(*) FROM named_users;
+			CREATE TRIGGER users_audit AFTER UPDATE OF email ON users
+			FOR EACH ROW WHEN new.email <> old.email
+			BEGIN
+				INSERT INTO audit (user_id, note) VALUES (new.id, CASE WHEN old.email IS NULL THEN 'set' ELSE 'changed' END);
+			END;`,
+	},
+	{
+		name: "employees and teams",
+		schema: `
+			CREATE TABLE employees (
+				id integer PRIMARY KEY,
+				manager_id integer REFERENCES employees(id),
+				team_id integer REFERENCES teams(id)
+			);
+			CREATE TABLE teams (
+				id integer PRIMARY KEY,
+				lead_id integer REFERENCES employees(id)
+			);`,
+	},
+	{
+		name: "memberships",
+		schema: `
+			CREATE TABLE users (
+				id integer PRIMARY KEY,
+				email text NOT NULL,
+				name text
+			);
+			CREATE TABLE groups (
+				id integer PRIMARY KEY,
+				label text NOT NULL
+			);
+			CREATE TABLE memberships (
+				user_id integer NOT NULL,
+				group_id integer NOT NULL,
+				PRIMARY KEY (user_id, group_id),
+				FOREIGN KEY (user_id) REFERENCES users(id),
+				FOREIGN KEY (group_id) REFERENCES groups(id)
+			);`,
+	},
+	{
+		name: "invitations",
+		schema: `
+			CREATE TABLE invitations (
+				id integer PRIMARY KEY,
+				email text NOT NULL,
+				group_id integer NOT NULL,
+				CONSTRAINT invitations_email UNIQUE (email COLLATE NOCASE, group_id) ON CONFLICT REPLACE
+			);`,
+	},
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	for _, src := range schemaCases {
+		for _, tgt := range schemaCases {
+			t.Run(src.name+" to "+tgt.name, func(t *testing.T) {
+				verifytest.AssertRoundTrip(t, src.schema, tgt.schema)
+			})
+		}
+	}
+}
+
+func TestPlanEscapedQuotesInIdentifiers(t *testing.T) {
+	verifytest.AssertRoundTrip(t,
+		"CREATE TABLE \"we\"\"ird\" (\"col\"\"umn\" text);",
+		"CREATE TABLE `we\"ird` ([col\"umn] text, `other``column` text);",
+	)
+}
+
+func TestPlanSignedDefaultsAndPartialIndexes(t *testing.T) {
+	verifytest.AssertRoundTrip(t,
+		"CREATE TABLE p (id integer PRIMARY KEY);",
+		`CREATE TABLE p (id integer PRIMARY KEY);
+		CREATE TABLE t (
+			a integer DEFAULT -1,
+			b integer DEFAULT 0x10,
+			c integer DEFAULT (1+2),
+			d integer REFERENCES p DEFERRABLE INITIALLY DEFERRED
+		);
+		CREATE INDEX t_a ON t (a) WHERE a IS NOT NULL;`,
+	)
+}
+
+func TestPlanLowersNotNullColumnWithoutDefault(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t,
+		"CREATE TABLE users (id integer PRIMARY KEY);",
+		"CREATE TABLE users (id integer PRIMARY KEY, email text NOT NULL);",
+	)
+
+	if !slices.ContainsFunc(diff.Flatten(result.Plan), func(op diff.Op) bool {
+		_, ok := op.(*diff.RecreateTableOp)
+		return ok
+	}) {
+		t.Fatalf("expected the table to be recreated, got plan:\n%s", result.Script)
+	}
+}
+
+func TestPlanRunsInTransaction(t *testing.T) {
+	created := verifytest.AssertRoundTrip(t, "", "CREATE TABLE users (id integer PRIMARY KEY);")
+	if len(created.Plan) != 1 {
+		t.Fatalf("expected a single transaction, got plan:\n%s", created.Script)
+	}
+	if _, ok := created.Plan[0].(*diff.TransactionOp); !ok {
+		t.Fatalf("expected a transaction, got %T", created.Plan[0])
+	}
+
+	// foreign keys can only be switched off outside of a transaction, they are
+	// checked within it so that a violation rolls the migration back
+	recreated := verifytest.AssertRoundTrip(t,
+		"CREATE TABLE users (id integer PRIMARY KEY);",
+		"CREATE TABLE users (id integer PRIMARY KEY, email text NOT NULL);",
+	)
+	if len(recreated.Plan) != 3 {
+		t.Fatalf("expected the transaction between the foreign key pragmas, got plan:\n%s", recreated.Script)
+	}
+	transaction, ok := recreated.Plan[1].(*diff.TransactionOp)
+	if !ok {
+		t.Fatalf("expected a transaction, got %T", recreated.Plan[1])
+	}
+	if check, ok := transaction.Ops[len(transaction.Ops)-1].(*diff.PragmaOp); !ok || check.Key != "foreign_key_check" {
+		t.Errorf("expected the transaction to end with the foreign key check, got plan:\n%s", recreated.Script)
+	}
+}
+
+func TestPlanRecreatesTableWithChangedConstraints(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t,
+		"CREATE TABLE users (id integer PRIMARY KEY, email text);",
+		"CREATE TABLE users (id integer PRIMARY KEY, email text NOT NULL DEFAULT '' CHECK (email <> ''));",
+	)
+
+	if !slices.ContainsFunc(diff.Flatten(result.Plan), func(op diff.Op) bool {
+		_, ok := op.(*diff.RecreateTableOp)
+		return ok
+	}) {
+		t.Fatalf("expected the table to be recreated, got plan:\n%s", result.Script)
+	}
+}
+
+func TestPlanKeepsCurrentTimeDefaults(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Apply(ctx, "CREATE TABLE events (id integer PRIMARY KEY, name text, created_at text DEFAULT CURRENT_TIMESTAMP);"); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := verify.Introspect(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the changed constraint recreates the table, which writes out every column
+	tgt, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune("CREATE TABLE events (id integer PRIMARY KEY, name text NOT NULL DEFAULT '', created_at text DEFAULT CURRENT_TIMESTAMP);"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := verify.Plan(src, tgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify.Converge(ctx, db, tgt, result.Script, diff.TypeComparisonStrict); err != nil {
+		t.Fatalf("%v, plan:\n%s", err, result.Script)
+	}
+
+	if err := db.Apply(ctx, "INSERT INTO events (id) VALUES (1);"); err != nil {
+		t.Fatal(err)
+	}
+	var createdAt string
+	if err := db.QueryRowContext(ctx, "SELECT created_at FROM events WHERE id = 1;").Scan(&createdAt); err != nil {
+		t.Fatal(err)
+	}
+	if createdAt == "CURRENT_TIMESTAMP" || !regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`).MatchString(createdAt) {
+		t.Errorf("expected the default to be the time of the insert, got %q, plan:\n%s", createdAt, result.Script)
+	}
+}
+
+func TestPlanIgnoresLiftedKeys(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, `
+		CREATE TABLE users (id integer PRIMARY KEY);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users(id));`, `
+		CREATE TABLE users (id integer, PRIMARY KEY (id));
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer, FOREIGN KEY (user_id) REFERENCES users(id));`,
+	)
+
+	if len(result.Plan) != 0 {
+		t.Fatalf("expected no operations, got plan:\n%s", result.Script)
+	}
+}
+
+func TestPlanCreatesReferencedTablesFirst(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, "", `
+		CREATE INDEX memberships_group ON memberships (group_id);
+		CREATE TABLE memberships (
+			user_id integer NOT NULL REFERENCES users(id),
+			group_id integer NOT NULL REFERENCES groups(id)
+		);
+		CREATE TABLE groups (id integer PRIMARY KEY);
+		CREATE TABLE users (id integer PRIMARY KEY);`,
+	)
+
+	created := []string{}
+	for _, op := range diff.Flatten(result.Plan) {
+		switch o := op.(type) {
+		case *diff.NewTableOp:
+			created = append(created, o.TableIdentifier.ObjectName.Text)
+		case *diff.NewIndexOp:
+			created = append(created, o.IndexIdentifier.ObjectName.Text)
+		}
+	}
+
+	if !slices.Equal(created, []string{"groups", "users", "memberships", "memberships_group"}) {
+		t.Fatalf("unexpected creation order %v, got plan:\n%s", created, result.Script)
+	}
+}
+
+func TestPlanDropsReferencingTablesFirst(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, `
+		CREATE TABLE users (id integer PRIMARY KEY);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users(id));
+		CREATE TABLE comments (id integer PRIMARY KEY, post_id integer REFERENCES posts(id));`,
+		"",
+	)
+
+	dropped := []string{}
+	for _, op := range diff.Flatten(result.Plan) {
+		if o, ok := op.(*diff.DelTableOp); ok {
+			dropped = append(dropped, o.ObjectName.Text)
+		}
+	}
+
+	if !slices.Equal(dropped, []string{"comments", "posts", "users"}) {
+		t.Fatalf("unexpected drop order %v, got plan:\n%s", dropped, result.Script)
+	}
+}
+
+func TestPlanDropsForeignKeyCycleWithRows(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Apply(ctx, `
+		PRAGMA foreign_keys = ON;
+		CREATE TABLE employees (
+			id integer PRIMARY KEY,
+			manager_id integer REFERENCES employees(id),
+			team_id integer REFERENCES teams(id)
+		);
+		CREATE TABLE teams (
+			id integer PRIMARY KEY,
+			lead_id integer REFERENCES employees(id)
+		);
+		CREATE TABLE offices (id integer PRIMARY KEY);
+		INSERT INTO employees (id, manager_id, team_id) VALUES (1, NULL, NULL), (2, 1, NULL);
+		INSERT INTO teams (id, lead_id) VALUES (1, 1);
+		UPDATE employees SET team_id = 1;`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := verify.Introspect(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := verify.Plan(src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(result.Script, "defer_foreign_keys") {
+		t.Fatalf("expected foreign key checks to be deferred, got plan:\n%s", result.Script)
+	}
+
+	if err := verify.Converge(ctx, db, nil, result.Script, diff.TypeComparisonStrict); err != nil {
+		t.Fatalf("%v, plan:\n%s", err, result.Script)
+	}
+}
+
+func TestPlanAttachedDatabase(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, `
+		ATTACH DATABASE ':memory:' AS aux;
+		CREATE TABLE users (id integer PRIMARY KEY, name text);
+		CREATE TABLE aux.users (id integer PRIMARY KEY);
+		CREATE TABLE aux.sessions (id integer PRIMARY KEY);`, `
+		CREATE TABLE users (id integer PRIMARY KEY, name text);
+		CREATE TABLE aux.users (id integer PRIMARY KEY, email text NOT NULL DEFAULT '');
+		CREATE TABLE aux.tokens (id integer PRIMARY KEY, user_id integer REFERENCES users(id));
+		CREATE INDEX aux.tokens_user ON tokens (user_id);`,
+	)
+
+	for _, want := range []string{`DROP TABLE "aux"."sessions"`, `ALTER TABLE "aux"."users" ADD COLUMN`, `CREATE TABLE "aux"."tokens"`} {
+		if !strings.Contains(result.Script, want) {
+			t.Errorf("expected %q in plan:\n%s", want, result.Script)
+		}
+	}
+	if strings.Contains(result.Script, `"main"`) || strings.Contains(result.Script, `TABLE "users"`) {
+		t.Errorf("expected main.users to be untouched, got plan:\n%s", result.Script)
+	}
+}
+
+func TestPlanRecreatesTableWithChangedOptions(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, `
+		CREATE TABLE users (id integer PRIMARY KEY, email text);
+		CREATE TABLE tags (name text PRIMARY KEY);
+		INSERT INTO users (id, email) VALUES (1, 'a@example.com');
+		INSERT INTO tags (name) VALUES ('go');`, `
+		CREATE TABLE users (id integer PRIMARY KEY, email text) STRICT;
+		CREATE TABLE tags (name text PRIMARY KEY) STRICT, WITHOUT ROWID;`,
+	)
+
+	for _, want := range []string{") STRICT;", ") STRICT, WITHOUT ROWID;"} {
+		if !strings.Contains(result.Script, want) {
+			t.Errorf("expected %q in plan:\n%s", want, result.Script)
+		}
+	}
+}
+
+func TestPlanIgnoresShadowTables(t *testing.T) {
+	result := verifytest.AssertRoundTrip(t, `
+		CREATE VIRTUAL TABLE docs USING fts4(title, body);
+		CREATE VIRTUAL TABLE boxes USING rtree(id, min_x, max_x);`, `
+		CREATE VIRTUAL TABLE docs USING fts4(title, body);
+		CREATE VIRTUAL TABLE boxes USING rtree(id, min_x, max_x);`,
+	)
+
+	if len(result.Plan) != 0 {
+		t.Fatalf("expected no operations, got plan:\n%s", result.Script)
+	}
+}
+
+func TestPlanRecreatesVirtualTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+		want   string
+	}{
+		{
+			name:   "copies rows",
+			source: `CREATE VIRTUAL TABLE docs USING fts4(title, body);`,
+			target: `CREATE VIRTUAL TABLE docs USING fts4(title, body, tags, tokenize=porter);`,
+			want:   `CREATE TABLE "temp"."_old_docs" AS SELECT rowid, "title", "body" FROM "docs"`,
+		},
+		{
+			name: "rebuilds external content",
+			source: `
+				CREATE TABLE posts (id integer PRIMARY KEY, title text, body text);
+				CREATE VIRTUAL TABLE docs USING fts4(title, content="posts");`,
+			target: `
+				CREATE TABLE posts (id integer PRIMARY KEY, title text, body text);
+				CREATE VIRTUAL TABLE docs USING fts4(title, body, content="posts");`,
+			want: `INSERT INTO "docs" ("docs") VALUES ('rebuild')`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := sqlite.OpenInMemory(t.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			if err := db.Apply(ctx, test.source); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(test.source, "posts") {
+				err = db.Apply(ctx, "INSERT INTO posts (id, title, body) VALUES (7, 'hello', 'world'); INSERT INTO docs (rowid, title) VALUES (7, 'hello');")
+			} else {
+				err = db.Apply(ctx, "INSERT INTO docs (rowid, title, body) VALUES (7, 'hello', 'world');")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src, err := verify.Introspect(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tgt, err := parser.Parse(lexer.SourceCode{FileName: t.Name(), Raw: []rune(test.target)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := verify.Plan(src, tgt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(result.Script, test.want) {
+				t.Errorf("expected %q in plan:\n%s", test.want, result.Script)
+			}
+
+			if err := verify.Converge(ctx, db, tgt, result.Script, diff.TypeComparisonStrict); err != nil {
+				t.Fatalf("%v, plan:\n%s", err, result.Script)
+			}
+
+			var rowid int
+			if err := db.QueryRow("SELECT rowid FROM docs WHERE docs MATCH 'hello';").Scan(&rowid); err != nil || rowid != 7 {
+				t.Fatalf("expected the rows to be kept, got rowid %d: %v, plan:\n%s", rowid, err, result.Script)
+			}
+		})
+	}
+}
@@ -3,15 +3,14 @@ package generator
 import (
 	"errors"
 	"fmt"
-	"os"
+	"maps"
 	"slices"
-	"unsafe"
+	"strings"
 	"woodybriggs/justmigrate/backend/formatter"
 	"woodybriggs/justmigrate/datastructures"
 	"woodybriggs/justmigrate/frontend/ast"
 	"woodybriggs/justmigrate/frontend/report"
-
-	"golang.org/x/sys/cpu"
+	"woodybriggs/justmigrate/frontend/token"
 )
 
 var (
@@ -21,6 +20,17 @@ var (
 type MissingColumnsErr struct {
 	Table   *ast.CatalogObjectIdentifier
 	Columns []ast.Identifier
+	// CreateTable is the table the columns are missing from
+	CreateTable *ast.CreateTable
+	// ReferencedBy is the column that references the missing columns, if
+	// it is known
+	ReferencedBy *Column
+	// Composite is set when the columns are part of a key over more than one
+	// column
+	Composite bool
+	// TypesDiffer is set when the foreign keys to the column are declared
+	// with different types
+	TypesDiffer bool
 }
 
 func (uce *MissingColumnsErr) Error() string {
@@ -30,12 +40,20 @@ func (uce *MissingColumnsErr) Error() string {
 func (uce *MissingColumnsErr) Unwrap() []error {
 	errs := []error{}
 	for _, col := range uce.Columns {
+		labels := []report.Label{
+			report.LabelFromIdentifier(uce.Table.ObjectName, fmt.Sprintf("table missing column \"%s\"", col.Text)),
+			report.LabelFromIdentifier(col, "column used here"),
+		}
+		if uce.CreateTable != nil {
+			missing := missingColumn{MissingColumnIdentifier: col, ReferencingTo: uce.CreateTable, ReferencedBy: uce.ReferencedBy, Composite: uce.Composite, TypesDiffer: uce.TypesDiffer}
+			if label, ok := missing.addColumnLabel(); ok {
+				labels = append(labels, label)
+			}
+		}
+
 		err := report.NewReport("invalid foreign key").
 			WithLocation(col.FileLoc).
-			WithLabels(
-				report.LabelFromIdentifier(uce.Table.ObjectName, fmt.Sprintf("table missing column \"%s\"", col.Text)),
-				report.LabelFromIdentifier(col, "column used here"),
-			).
+			WithLabels(labels...).
 			WithMessage(fmt.Sprintf("\"%s\" does not exist on table \"%s\"", col.Text, uce.Table.ObjectName.Text)).
 			WithNotes(
 				"add the missing column to the table or",
@@ -50,14 +68,24 @@ func (uce *MissingColumnsErr) Unwrap() []error {
 type SchemaGraph struct {
 	Tables                    map[string]*Table
 	Columns                   map[string]map[string]*Column
+	Indexes                   map[string]*Index
+	Views                     map[string]*View
+	Triggers                  map[string]*Trigger
 	unresolvedForeignKeyEdges []UnresolvedForeignKeyEdge
+	// foreignKeyTypes are the types of the columns referencing each column
+	// by a single column foreign key, see referencedWithOneType
+	foreignKeyTypes map[string][]*ast.TypeName
 }
 
 func NewSchemaGraph() *SchemaGraph {
 	return &SchemaGraph{
 		Tables:                    map[string]*Table{},
 		Columns:                   map[string]map[string]*Column{},
+		Indexes:                   map[string]*Index{},
+		Views:                     map[string]*View{},
+		Triggers:                  map[string]*Trigger{},
 		unresolvedForeignKeyEdges: []UnresolvedForeignKeyEdge{},
+		foreignKeyTypes:           map[string][]*ast.TypeName{},
 	}
 }
 
@@ -78,6 +106,7 @@ func NewSchemaGraphFromStatements(statements []ast.Statement) (*SchemaGraph, err
 	errs := []error{}
 
 	sg := NewSchemaGraph()
+	sg.foreignKeyTypes = foreignKeyTypes(statements)
 	for _, statement := range statements {
 		switch stmt := statement.(type) {
 		case *ast.CreateTable:
@@ -89,6 +118,12 @@ func NewSchemaGraphFromStatements(statements []ast.Statement) (*SchemaGraph, err
 					}
 				}
 			}
+		case *ast.CreateIndex:
+			sg.AddIndex(stmt)
+		case *ast.CreateView:
+			sg.AddView(stmt)
+		case *ast.CreateTrigger:
+			sg.AddTrigger(stmt)
 		}
 	}
 
@@ -110,89 +145,134 @@ func NewSchemaGraphFromStatements(statements []ast.Statement) (*SchemaGraph, err
 	return sg, nil
 }
 
-func (sg *SchemaGraph) Sort(statements []ast.Statement) ([]ast.Statement, error) {
-	order, err := sg.sort()
-	if err != nil {
-		return nil, err
+// Cycle is a set of tables whose foreign keys reference each other, directly
+// or through the other tables in the set. A table that only references itself
+// is not a cycle, its rows are checked once the statement writing them is done.
+type Cycle []*Table
+
+func (c Cycle) Contains(table *Table) bool {
+	return slices.Contains(c, table)
+}
+
+func (c Cycle) String() string {
+	names := []string{}
+	for _, table := range c {
+		names = append(names, fmt.Sprintf("\"%s\"", table.Name))
 	}
+	return strings.Join(names, ", ")
+}
+
+// Sort orders the statements so that every table is created after the tables
+// it references, the remaining statements follow in their original order.
+func (sg *SchemaGraph) Sort(statements []ast.Statement) []ast.Statement {
+	order, _ := sg.sort()
+	slices.Reverse(order)
 
 	outputStatements := []ast.Statement{}
 	for _, table := range order {
 		outputStatements = append(outputStatements, table.CreateTable)
 	}
-	slices.Reverse(outputStatements)
 
-	core := formatter.NewCoreFormatter(os.Stdout, 80, "``")
-	fmtter := NewSqliteFormatter(true, core)
-	fmtter.VisitStatements(outputStatements)
+	for _, statement := range statements {
+		if _, ok := statement.(*ast.CreateTable); !ok {
+			outputStatements = append(outputStatements, statement)
+		}
+	}
 
-	return nil, nil
+	return outputStatements
 }
 
-func (sg *SchemaGraph) sort() ([]*Table, error) {
-	// initialise indegree bookkeeping
-	inDegree := map[string]int{}
-	for _, table := range sg.Tables {
-		inDegree[table.Name] = 0
-	}
+// sort orders the tables so that every table comes before the tables it
+// references, which is the order they can be dropped in. Reversing the order
+// gives the order they can be created in.
+//
+// The tables of a cycle have no such order, they are kept next to each other
+// in the order and returned as one of the cycles.
+func (sg *SchemaGraph) sort() ([]*Table, []Cycle) {
+	components := sg.stronglyConnectedComponents()
 
-	// record initial incoming edges for each table
-	for _, table := range sg.Tables {
-		for _, edge := range table.ForeignKeys {
-			inDegree[edge.ToTable.Name] += 1
+	order := []*Table{}
+	cycles := []Cycle{}
+	for _, component := range slices.Backward(components) {
+		if len(component) > 1 {
+			cycles = append(cycles, component)
+		}
+		for _, table := range slices.Backward(component) {
+			order = append(order, table)
 		}
 	}
 
-	// initialise queue for khans algo
-	// not nessecary, but is nice for the data of the Queue to fit into a single cache line
-	cacheLineSize := int(unsafe.Sizeof(cpu.CacheLinePad{}))
-	ptrSize := int(unsafe.Sizeof(uintptr(0)))
-	q := datastructures.NewQueue[*Table](cacheLineSize / ptrSize)
+	return order, cycles
+}
 
-	// for each table that has 0 incoming fks we can visit them first
-	for name, degree := range inDegree {
-		if degree == 0 {
-			q.Enqueue(sg.Tables[name])
-		}
-	}
+// stronglyConnectedComponents groups the tables that reference each other
+// using tarjan's algorithm. A component is only completed once every table
+// it references is, so the components are ordered referenced tables first.
+func (sg *SchemaGraph) stronglyConnectedComponents() []Cycle {
+	index := map[*Table]int{}
+	lowLink := map[*Table]int{}
+	onStack := map[*Table]bool{}
+	stack := datastructures.Stack[*Table]{}
+	components := []Cycle{}
 
-	order := []*Table{}
-	// while there are tables to visit on the queue,
-	// 1. add the node to the order (it has 0 referencing parents)
-	// 2. follow the edge to the referencing tables
-	// 3. decrement the indegree and and if the resulting indegree
-	// 4. is 0 (no more referencing tables), it can be added to the queue
-	for node, ok := q.Dequeue(); ok; node, ok = q.Dequeue() {
-		order = append(order, node)
-		for _, edge := range node.ForeignKeys {
-			inDegree[edge.ToTable.Name] -= 1
-			if inDegree[edge.ToTable.Name] == 0 {
-				q.Enqueue(edge.ToTable)
+	var connect func(table *Table)
+	connect = func(table *Table) {
+		index[table] = len(index)
+		lowLink[table] = index[table]
+		stack.Push(table)
+		onStack[table] = true
+
+		for _, edge := range table.ForeignKeys {
+			referenced := edge.ToTable
+			if referenced == table {
+				// self references never prevent a table from being ordered
+				continue
+			}
+
+			if _, visited := index[referenced]; !visited {
+				connect(referenced)
+				lowLink[table] = min(lowLink[table], lowLink[referenced])
+			} else if onStack[referenced] {
+				lowLink[table] = min(lowLink[table], index[referenced])
 			}
 		}
-	}
 
-	// if there is a depenency cycle
-	if len(order) != len(sg.Tables) {
-		// since sqlite allows creating tables with foreign keys to
-		// non-existent tables we can just append the remaining tables.
-		// in any order
-		remaining := []*Table{}
-		for _, table := range sg.Tables {
-			if inDegree[table.Name] > 0 {
-				remaining = append(remaining, table)
+		// table is the root of a component, everything above it on the
+		// stack references it and is referenced by it
+		if lowLink[table] == index[table] {
+			component := Cycle{}
+			for top, ok := stack.Pop(); ok; top, ok = stack.Pop() {
+				onStack[top] = false
+				component = append(component, top)
+				if top == table {
+					break
+				}
 			}
+			slices.SortFunc(component, func(a, b *Table) int { return strings.Compare(a.Name, b.Name) })
+			components = append(components, component)
 		}
+	}
 
-		order = append(order, remaining...)
+	// visit tables by name so that the order is stable between runs
+	for _, name := range slices.Sorted(maps.Keys(sg.Tables)) {
+		if _, visited := index[sg.Tables[name]]; !visited {
+			connect(sg.Tables[name])
+		}
 	}
 
-	return order, nil
+	return components
 }
 
 type missingColumn struct {
 	MissingColumnIdentifier ast.Identifier
 	ReferencingTo           *ast.CreateTable
+	// ReferencedBy is the column that references the missing column
+	ReferencedBy *Column
+	// Composite is set when the foreign key references more than one column
+	Composite bool
+	// TypesDiffer is set when the foreign keys to the column are declared
+	// with different types
+	TypesDiffer bool
 }
 
 type missingTable struct {
@@ -200,9 +280,23 @@ type missingTable struct {
 	ReferencedBy           *ast.CreateTable
 }
 
+// missingObjectTable is an index or trigger on a table that does not exist.
+type missingObjectTable struct {
+	ObjectKind string
+	ObjectName ast.Identifier
+	Table      ast.Identifier
+}
+
+type missingIndexColumn struct {
+	Index  *ast.CreateIndex
+	Column ast.Identifier
+}
+
 type ErrSchemaResolutionFailed struct {
-	MissingTables  []missingTable
-	MissingColumns []missingColumn
+	MissingTables       []missingTable
+	MissingColumns      []missingColumn
+	MissingObjectTables []missingObjectTable
+	MissingIndexColumns []missingIndexColumn
 }
 
 func (err *ErrSchemaResolutionFailed) Error() string {
@@ -235,13 +329,76 @@ func (srf *ErrSchemaResolutionFailed) Unwrap() []error {
 			WithNotes(
 				fmt.Sprintf("column \"%s\" does exist on the foreign key table \"%s\"", col.MissingColumnIdentifier.Text, col.ReferencingTo.TableIdentifier.ObjectName.Text),
 			)
+		if label, ok := col.addColumnLabel(); ok {
+			err.WithLabels(label)
+		} else {
+			err.WithNotes(fmt.Sprintf("add column \"%s\" with a PRIMARY KEY or UNIQUE constraint to table \"%s\"", col.MissingColumnIdentifier.Text, col.ReferencingTo.TableIdentifier.ObjectName.Text))
+		}
 
 		errs = append(errs, err)
 	}
 
+	for _, object := range srf.MissingObjectTables {
+		err := report.
+			NewReport(fmt.Sprintf("invalid %s", object.ObjectKind)).
+			WithLocation(object.Table.FileLoc).
+			WithLabels(
+				report.LabelFromIdentifier(object.Table, "this table is missing"),
+				report.LabelFromIdentifier(object.ObjectName, fmt.Sprintf("%s defined here", object.ObjectKind)),
+			)
+		errs = append(errs, err)
+	}
+
+	for _, col := range srf.MissingIndexColumns {
+		err := report.
+			NewReport("invalid index").
+			WithLocation(col.Column.FileLoc).
+			WithLabels(
+				report.LabelFromIdentifier(col.Column, fmt.Sprintf("this column is missing in table \"%s\"", col.Index.OnTable.Text)),
+				report.LabelFromIdentifier(col.Index.IndexIdentifier.ObjectName, "index defined here"),
+			)
+		errs = append(errs, err)
+	}
+
 	return errs
 }
 
+// addColumnLabel suggests adding the missing column as the first column of
+// the referenced table, with the type of the column that references it. The
+// column is added as UNIQUE so that it is a valid parent key. A foreign key
+// over more than one column, from a column without a type or to a column the
+// other foreign keys reference with another type can't be fixed by adding a
+// single column and gets no suggestion.
+func (col missingColumn) addColumnLabel() (report.Label, bool) {
+	if col.Composite || col.TypesDiffer || col.ReferencedBy == nil || col.ReferencedBy.Type == nil {
+		return report.Label{}, false
+	}
+
+	definition := col.ReferencingTo.TableDefinition
+	if len(definition.ColumnDefinitions) == 0 || definition.LParen.Kind != '(' {
+		return report.Label{}, false
+	}
+
+	column := &ast.ColumnDefinition{ColumnName: col.MissingColumnIdentifier, TypeName: col.ReferencedBy.Type}
+
+	sb := &strings.Builder{}
+	column.Accept(NewSqliteFormatter(false, formatter.NewCoreFormatter(sb, 80, "\"\"")))
+	sb.WriteString(" UNIQUE")
+
+	// keep the layout of the table, one column per line or all on one line
+	insertion := sb.String() + ", "
+	if strings.Contains(definition.LParen.TrailingTrivia, "\n") {
+		insertion = "\n" + definition.ColumnDefinitions[0].ColumnName.LeadingTrivia + sb.String() + ","
+	}
+
+	return report.InsertionLabel(
+		definition.LParen,
+		definition.LParen.SourceRange.End,
+		insertion,
+		fmt.Sprintf("add column \"%s\"", col.MissingColumnIdentifier.Text),
+	), true
+}
+
 func (sg *SchemaGraph) Resolve() error {
 
 	missingTables := []missingTable{}
@@ -262,10 +419,16 @@ func (sg *SchemaGraph) Resolve() error {
 		toColumns, founds := sg.ColumnsByIdents(toTable, unresolved.ToColumns)
 		for i, found := range founds {
 			if !found {
-				missingColumns = append(missingColumns, missingColumn{
+				missing := missingColumn{
 					MissingColumnIdentifier: unresolved.ToColumns[i],
 					ReferencingTo:           toTable.CreateTable,
-				})
+					Composite:               len(unresolved.ToColumns) > 1,
+					TypesDiffer:             !sg.referencedWithOneType(toTable.CreateTable.TableIdentifier, unresolved.ToColumns[i]),
+				}
+				if i < len(unresolved.FromColumns) {
+					missing.ReferencedBy = unresolved.FromColumns[i]
+				}
+				missingColumns = append(missingColumns, missing)
 			}
 		}
 
@@ -274,25 +437,143 @@ func (sg *SchemaGraph) Resolve() error {
 			continue
 		}
 
-		final := &ForeignKeyEdge{
-			FromTable:   unresolved.FromTable,
-			FromColumns: unresolved.FromColumns,
-			ToTable:     toTable,
-			ToColumns:   toColumns,
+		unresolved.FromTable.AddForeignKeyEdge(unresolved.FromColumns, toTable, toColumns)
+	}
+
+	missingObjectTables := []missingObjectTable{}
+	missingIndexColumns := []missingIndexColumn{}
+
+	// resolve indexes, views and triggers once every table is known
+	for _, name := range slices.Sorted(maps.Keys(sg.Indexes)) {
+		index := sg.Indexes[name]
+		table, hasTable := sg.Tables[ast.QualifiedName(index.CreateIndex.IndexIdentifier.SchemaName, index.CreateIndex.OnTable.Text)]
+		if !hasTable {
+			missingObjectTables = append(missingObjectTables, missingObjectTable{
+				ObjectKind: "index",
+				ObjectName: index.CreateIndex.IndexIdentifier.ObjectName,
+				Table:      index.CreateIndex.OnTable,
+			})
+			continue
+		}
+
+		index.Table = table
+		table.Indexes = append(table.Indexes, index)
+		if table.CreateTable.AsSelect != nil {
+			// the columns of CREATE TABLE ... AS SELECT are only known to
+			// sqlite, its table definition is empty
+			continue
+		}
+		for _, indexed := range index.CreateIndex.IndexedColumns {
+			ident, ok := indexed.Subject.(*ast.Identifier)
+			if !ok {
+				// expressions may only reference columns of the table
+				continue
+			}
+			column, hasColumn := table.Columns[ident.Canonical()]
+			if !hasColumn {
+				missingIndexColumns = append(missingIndexColumns, missingIndexColumn{
+					Index:  index.CreateIndex,
+					Column: *ident,
+				})
+				continue
+			}
+			index.Columns = append(index.Columns, column)
 		}
-		unresolved.FromTable.ForeignKeys = append(unresolved.FromTable.ForeignKeys, final)
 	}
 
-	if len(missingTables) > 0 || len(totalMissingColumns) > 0 {
+	for _, name := range slices.Sorted(maps.Keys(sg.Views)) {
+		view := sg.Views[name]
+		view.Tables, view.Views, view.Columns = sg.references(view.CreateView.ViewIdentifier.SchemaName, view.CreateView.AsSelect.Tokens)
+		for _, table := range view.Tables {
+			table.Views = append(table.Views, view)
+		}
+		for _, dependency := range view.Views {
+			dependency.DependantViews = append(dependency.DependantViews, view)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(sg.Triggers)) {
+		trigger := sg.Triggers[name]
+		onTable := trigger.CreateTrigger.OnTable.ObjectName
+		onTableName := trigger.CreateTrigger.OnTable.InSchema(trigger.CreateTrigger.TriggerIdentifier.SchemaName).Canonical()
+		if table, hasTable := sg.Tables[onTableName]; hasTable {
+			trigger.Table = table
+		} else if view, hasView := sg.Views[onTableName]; hasView {
+			trigger.View = view
+		} else {
+			missingObjectTables = append(missingObjectTables, missingObjectTable{
+				ObjectKind: "trigger",
+				ObjectName: trigger.CreateTrigger.TriggerIdentifier.ObjectName,
+				Table:      onTable,
+			})
+			continue
+		}
+
+		// the table the trigger is on is also a reference of its body,
+		// through the NEW and OLD rows
+		body := append([]token.Token{token.Token(onTable)}, trigger.CreateTrigger.Body...)
+		trigger.Tables, trigger.Views, trigger.Columns = sg.references(trigger.CreateTrigger.TriggerIdentifier.SchemaName, body)
+		for _, table := range trigger.Tables {
+			table.Triggers = append(table.Triggers, trigger)
+		}
+		for _, view := range trigger.Views {
+			view.DependantTriggers = append(view.DependantTriggers, trigger)
+		}
+	}
+
+	if len(missingTables) > 0 || len(totalMissingColumns) > 0 || len(missingObjectTables) > 0 || len(missingIndexColumns) > 0 {
 		return &ErrSchemaResolutionFailed{
-			MissingTables:  missingTables,
-			MissingColumns: totalMissingColumns,
+			MissingTables:       missingTables,
+			MissingColumns:      totalMissingColumns,
+			MissingObjectTables: missingObjectTables,
+			MissingIndexColumns: missingIndexColumns,
 		}
 	}
 
 	return nil
 }
 
+// references finds the tables, views and columns named by statements that are
+// kept verbatim. Without resolving aliases or scopes it errs on the side of
+// reporting too much, an identifier names a column if any of the referenced
+// tables has a column by that name. Names are resolved in the schema of the
+// statement.
+func (sg *SchemaGraph) references(schema *ast.Identifier, tokens []token.Token) (tables []*Table, views []*View, columns []*Column) {
+	for _, tok := range tokens {
+		if tok.Kind != token.TokenKind_Identifier {
+			continue
+		}
+		if table, ok := sg.Tables[ast.QualifiedName(schema, tok.Text)]; ok && !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+		if view, ok := sg.Views[ast.QualifiedName(schema, tok.Text)]; ok && !slices.Contains(views, view) {
+			views = append(views, view)
+		}
+	}
+
+	for i, tok := range tokens {
+		if tok.Kind != token.TokenKind_Identifier {
+			continue
+		}
+
+		// a qualified column only belongs to the table it is qualified with
+		candidates := tables
+		if i >= 2 && tokens[i-1].Kind == token.TokenKind_Period {
+			if table, ok := sg.Tables[ast.QualifiedName(schema, tokens[i-2].Text)]; ok {
+				candidates = []*Table{table}
+			}
+		}
+
+		for _, table := range candidates {
+			if column, ok := table.Columns[ast.CanonicalName(tok.Text)]; ok && !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return tables, views, columns
+}
+
 func validateColumnsExist(table *ast.CreateTable, idents ast.IdentifierList) (err error) {
 
 	columns := ast.IdentifierList{}
@@ -311,8 +592,10 @@ func validateColumnsExist(table *ast.CreateTable, idents ast.IdentifierList) (er
 
 	if len(missingColumns) > 0 {
 		err = &MissingColumnsErr{
-			Table:   table.TableIdentifier,
-			Columns: missingColumns,
+			Table:       table.TableIdentifier,
+			Columns:     missingColumns,
+			CreateTable: table,
+			Composite:   len(idents) > 1,
 		}
 	}
 
@@ -378,24 +661,31 @@ func (sg *SchemaGraph) AddTable(t *ast.CreateTable) error {
 		CreateTable: t,
 		Columns:     map[string]*Column{},
 	}
-	sg.Tables[t.TableIdentifier.ObjectName.Text] = table
-	sg.Columns[t.TableIdentifier.ObjectName.Text] = map[string]*Column{}
+	sg.Tables[t.TableIdentifier.Canonical()] = table
+	sg.Columns[t.TableIdentifier.Canonical()] = map[string]*Column{}
 
 	table.Name = t.TableIdentifier.ObjectName.Text
 	for _, column := range t.TableDefinition.ColumnDefinitions {
-		sg.AddColumn(table, &column)
+		if err := sg.AddColumn(table, &column); err != nil {
+			if u, ok := err.(interface{ Unwrap() []error }); ok {
+				validationErrors = append(validationErrors, u.Unwrap()...)
+			} else {
+				validationErrors = append(validationErrors, err)
+			}
+		}
 	}
 
 	for _, constraint := range t.TableDefinition.TableConstraints {
 		if fk, ok := constraint.(*ast.TableConstraint_ForeignKey); ok {
 
 			// find the foreign table or yield it incase it comes later
-			toTable, hasTable := sg.TableByIdent(&fk.FkClause.ForeignTable)
+			foreignTable := fk.FkClause.ForeignTable.InSchema(t.TableIdentifier.SchemaName)
+			toTable, hasTable := sg.TableByIdent(foreignTable)
 			if !hasTable {
 				unresolved := UnresolvedForeignKeyEdge{
 					FromTable:   table,
 					FromColumns: table.GetColumns(fk.Columns),
-					ToTable:     fk.FkClause.ForeignTable,
+					ToTable:     *foreignTable,
 					ToColumns:   fk.FkClause.ForeignColumns,
 				}
 				sg.unresolvedForeignKeyEdges = append(sg.unresolvedForeignKeyEdges, unresolved)
@@ -405,6 +695,10 @@ func (sg *SchemaGraph) AddTable(t *ast.CreateTable) error {
 			// validate that the foreign table has the foreign columns
 			err := validateColumnsExist(toTable.CreateTable, fk.FkClause.ForeignColumns)
 			if err != nil {
+				if missing, ok := err.(*MissingColumnsErr); ok && len(fk.Columns) == 1 {
+					missing.ReferencedBy = table.GetColumns(fk.Columns)[0]
+					missing.TypesDiffer = !sg.referencedWithOneType(toTable.CreateTable.TableIdentifier, missing.Columns[0])
+				}
 				if u, ok := err.(interface{ Unwrap() []error }); ok {
 					validationErrors = append(validationErrors, u.Unwrap()...)
 				} else {
@@ -429,8 +723,64 @@ func (sg *SchemaGraph) AddTable(t *ast.CreateTable) error {
 	return nil
 }
 
+// foreignKeyTypes collects the types of the columns referencing each column by
+// a single column foreign key, keyed by the qualified name of the referenced
+// column.
+func foreignKeyTypes(statements []ast.Statement) map[string][]*ast.TypeName {
+	types := map[string][]*ast.TypeName{}
+	add := func(table *ast.CreateTable, fk *ast.ForeignKeyClause, typeName *ast.TypeName) {
+		if len(fk.ForeignColumns) != 1 || typeName == nil {
+			return
+		}
+		key := fk.ForeignTable.InSchema(table.TableIdentifier.SchemaName).Canonical() + "." + fk.ForeignColumns[0].Canonical()
+		types[key] = append(types[key], typeName)
+	}
+
+	for _, statement := range statements {
+		table, ok := statement.(*ast.CreateTable)
+		if !ok || table.TableDefinition == nil {
+			continue
+		}
+
+		columns := table.TableDefinition.ColumnDefinitions
+		for _, column := range columns {
+			for _, constraint := range column.ColumnConstraints {
+				if fk, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok {
+					add(table, &fk.FkClause, column.TypeName)
+				}
+			}
+		}
+
+		for _, constraint := range table.TableDefinition.TableConstraints {
+			fk, ok := constraint.(*ast.TableConstraint_ForeignKey)
+			if !ok || len(fk.Columns) != 1 {
+				continue
+			}
+			index := slices.IndexFunc(columns, func(column ast.ColumnDefinition) bool { return column.ColumnName.Eq(fk.Columns[0]) })
+			if index >= 0 {
+				add(table, &fk.FkClause, columns[index].TypeName)
+			}
+		}
+	}
+
+	return types
+}
+
+// referencedWithOneType reports whether the foreign keys to the column of the
+// table all declare the same type, so that adding the column with that type
+// satisfies every one of them.
+func (sg *SchemaGraph) referencedWithOneType(table *ast.CatalogObjectIdentifier, column ast.Identifier) bool {
+	types := sg.foreignKeyTypes[table.Canonical()+"."+column.Canonical()]
+	for _, typeName := range types {
+		if !strings.EqualFold(typeName.Name.Text, types[0].Name.Text) {
+			return false
+		}
+	}
+	return true
+}
+
 func (sg *SchemaGraph) TableByIdent(ident *ast.CatalogObjectIdentifier) (*Table, bool) {
-	if t, has := sg.Tables[ident.ObjectName.Text]; has {
+	if t, has := sg.Tables[ident.Canonical()]; has {
 		return t, true
 	}
 	return nil, false
@@ -441,7 +791,7 @@ func (sg *SchemaGraph) ColumnsByIdents(table *Table, idents []ast.Identifier) ([
 	found := []bool{}
 
 	for _, ident := range idents {
-		if col, has := table.Columns[ident.Text]; has {
+		if col, has := table.Columns[ident.Canonical()]; has {
 			result = append(result, col)
 			found = append(found, true)
 		} else {
@@ -455,22 +805,24 @@ func (sg *SchemaGraph) ColumnsByIdents(table *Table, idents []ast.Identifier) ([
 func (sg *SchemaGraph) AddColumn(table *Table, col *ast.ColumnDefinition) error {
 
 	column := &Column{
-		Name: col.ColumnName,
-		Type: col.TypeName,
+		Name:        col.ColumnName,
+		Type:        col.TypeName,
+		ParentTable: table,
 	}
-	table.Columns[column.Name.Text] = column
-	sg.Columns[table.Name][column.Name.Text] = column
+	table.Columns[column.Name.Canonical()] = column
+	sg.Columns[table.CreateTable.TableIdentifier.Canonical()][column.Name.Canonical()] = column
 
 	for _, constraint := range col.ColumnConstraints {
 		if fk, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok {
 
 			// find the foreign table, yield if it hasn't been added yet
-			toTable, hasTable := sg.TableByIdent(&fk.FkClause.ForeignTable)
+			foreignTable := fk.FkClause.ForeignTable.InSchema(table.CreateTable.TableIdentifier.SchemaName)
+			toTable, hasTable := sg.TableByIdent(foreignTable)
 			if !hasTable {
 				sg.unresolvedForeignKeyEdges = append(sg.unresolvedForeignKeyEdges, UnresolvedForeignKeyEdge{
 					FromTable:   table,
 					FromColumns: []*Column{column},
-					ToTable:     fk.FkClause.ForeignTable,
+					ToTable:     *foreignTable,
 					ToColumns:   fk.FkClause.ForeignColumns,
 				})
 
@@ -480,6 +832,10 @@ func (sg *SchemaGraph) AddColumn(table *Table, col *ast.ColumnDefinition) error
 			err := validateColumnsExist(toTable.CreateTable, fk.FkClause.ForeignColumns)
 			if err != nil {
 				// @todo(woody) this early exists the loop we want to accumulate these errors up to not early exit
+				if missing, ok := err.(*MissingColumnsErr); ok {
+					missing.ReferencedBy = column
+					missing.TypesDiffer = !sg.referencedWithOneType(toTable.CreateTable.TableIdentifier, missing.Columns[0])
+				}
 				return err
 			}
 
@@ -493,8 +849,72 @@ func (sg *SchemaGraph) AddColumn(table *Table, col *ast.ColumnDefinition) error
 	return nil
 }
 
+// AddIndex adds the index to the graph, it is linked to its table by Resolve.
 func (sg *SchemaGraph) AddIndex(t *ast.CreateIndex) {
+	sg.Indexes[t.IndexIdentifier.Canonical()] = &Index{
+		CreateIndex: t,
+		Name:        t.IndexIdentifier.ObjectName.Text,
+	}
+}
+
+// AddView adds the view to the graph, it is linked to what it reads by Resolve.
+func (sg *SchemaGraph) AddView(v *ast.CreateView) {
+	sg.Views[v.ViewIdentifier.Canonical()] = &View{
+		CreateView: v,
+		Name:       v.ViewIdentifier.ObjectName.Text,
+	}
+}
+
+// AddTrigger adds the trigger to the graph, it is linked to its table and
+// what its body uses by Resolve.
+func (sg *SchemaGraph) AddTrigger(t *ast.CreateTrigger) {
+	sg.Triggers[t.TriggerIdentifier.Canonical()] = &Trigger{
+		CreateTrigger: t,
+		Name:          t.TriggerIdentifier.ObjectName.Text,
+	}
+}
+
+// Dependants are the objects that have to be recreated when a table is rebuilt.
+type Dependants struct {
+	Indexes  []*Index
+	Views    []*View
+	Triggers []*Trigger
+}
 
+// Dependants returns what must be recreated if the table is rebuilt, its
+// indexes and triggers are dropped along with it, and the views and triggers
+// that use it, directly or through other views, stop the rebuilt table from
+// being renamed into place while it is missing.
+func (sg *SchemaGraph) Dependants(table *Table) Dependants {
+	dependants := Dependants{
+		Indexes: slices.Clone(table.Indexes),
+	}
+
+	addTriggers := func(triggers []*Trigger) {
+		for _, trigger := range triggers {
+			if !slices.Contains(dependants.Triggers, trigger) {
+				dependants.Triggers = append(dependants.Triggers, trigger)
+			}
+		}
+	}
+	addTriggers(table.Triggers)
+
+	queue := slices.Clone(table.Views)
+	for len(queue) > 0 {
+		view := queue[0]
+		queue = queue[1:]
+		if slices.Contains(dependants.Views, view) {
+			continue
+		}
+		dependants.Views = append(dependants.Views, view)
+		addTriggers(view.DependantTriggers)
+		queue = append(queue, view.DependantViews...)
+	}
+
+	slices.SortFunc(dependants.Indexes, func(a, b *Index) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(dependants.Triggers, func(a, b *Trigger) int { return strings.Compare(a.Name, b.Name) })
+
+	return dependants
 }
 
 func (table *Table) AddForeignKeyEdge(cols []*Column, foreignTable *Table, foreignCols []*Column) {
@@ -518,13 +938,18 @@ type Table struct {
 	Columns     map[string]*Column
 	Indexes     []*Index
 	ForeignKeys []*ForeignKeyEdge
+
+	// views that read from this table
+	Views []*View
+	// triggers on this table or that use it in their body
+	Triggers []*Trigger
 }
 
 func (t *Table) GetColumns(idents []ast.Identifier) []*Column {
 	result := []*Column{}
 
 	for _, ident := range idents {
-		result = append(result, t.Columns[ident.Text])
+		result = append(result, t.Columns[ident.Canonical()])
 	}
 
 	return result
@@ -542,9 +967,39 @@ type Column struct {
 }
 
 type Index struct {
-	Name   string
-	Table  *Table
-	Column []*Column
+	CreateIndex *ast.CreateIndex
+
+	Name    string
+	Table   *Table
+	Columns []*Column
+}
+
+type View struct {
+	CreateView *ast.CreateView
+
+	Name string
+	// tables, views and columns that the select of the view reads from
+	Tables  []*Table
+	Views   []*View
+	Columns []*Column
+
+	// views and triggers that read from this view
+	DependantViews    []*View
+	DependantTriggers []*Trigger
+}
+
+type Trigger struct {
+	CreateTrigger *ast.CreateTrigger
+
+	Name string
+	// the table or view that the trigger is on
+	Table *Table
+	View  *View
+
+	// tables, views and columns that the body of the trigger uses
+	Tables  []*Table
+	Views   []*View
+	Columns []*Column
 }
 
 type ForeignKeyEdge struct {
@@ -0,0 +1,136 @@
+package generator_test
+
+import (
+	"slices"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func TestSchemaGraphDependants(t *testing.T) {
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw: []rune(`
+			CREATE TABLE billing_plan (id integer PRIMARY KEY, name text, price integer);
+			CREATE TABLE subscription (id integer PRIMARY KEY, plan_id integer REFERENCES billing_plan(id));
+			CREATE TABLE plan_log (plan_id integer, price integer);
+			CREATE INDEX billing_plan_name ON billing_plan (name);
+			CREATE INDEX subscription_plan ON subscription (plan_id);
+			CREATE VIEW plan_prices AS SELECT name, price FROM billing_plan;
+			CREATE VIEW cheap_plans AS SELECT name FROM plan_prices WHERE price < 10;
+			CREATE VIEW subscriptions AS SELECT id FROM subscription;
+			CREATE TRIGGER log_price AFTER UPDATE OF price ON billing_plan BEGIN
+				INSERT INTO plan_log VALUES (new.id, new.price);
+			END;
+			CREATE TRIGGER cheap_plans_insert INSTEAD OF INSERT ON cheap_plans BEGIN
+				SELECT 1;
+			END;`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := generator.NewSchemaGraphFromStatements(statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dependants := sg.Dependants(sg.Tables["billing_plan"])
+
+	names := func(n int, name func(int) string) []string {
+		result := []string{}
+		for i := range n {
+			result = append(result, name(i))
+		}
+		return result
+	}
+
+	indexes := names(len(dependants.Indexes), func(i int) string { return dependants.Indexes[i].Name })
+	views := names(len(dependants.Views), func(i int) string { return dependants.Views[i].Name })
+	triggers := names(len(dependants.Triggers), func(i int) string { return dependants.Triggers[i].Name })
+
+	if !slices.Equal(indexes, []string{"billing_plan_name"}) {
+		t.Errorf("unexpected indexes %v", indexes)
+	}
+	if !slices.Equal(views, []string{"plan_prices", "cheap_plans"}) {
+		t.Errorf("unexpected views %v", views)
+	}
+	if !slices.Equal(triggers, []string{"cheap_plans_insert", "log_price"}) {
+		t.Errorf("unexpected triggers %v", triggers)
+	}
+
+	logPrice := sg.Triggers["log_price"]
+	if !slices.Contains(logPrice.Tables, sg.Tables["plan_log"]) {
+		t.Errorf("expected log_price to use plan_log, got %v", logPrice.Tables)
+	}
+}
+
+func TestSchemaGraphCanonicalNames(t *testing.T) {
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw: []rune(`
+			CREATE TABLE "Users" (Id integer PRIMARY KEY, email text);
+			CREATE TABLE [posts] (id integer PRIMARY KEY, user_id integer REFERENCES users("ID"));
+			CREATE INDEX posts_user ON POSTS (User_Id);
+			CREATE VIEW user_emails AS SELECT EMAIL FROM USERS;`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := generator.NewSchemaGraphFromStatements(statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, ok := sg.Tables["users"]
+	if !ok {
+		t.Fatal("expected the graph to key tables by their canonical name")
+	}
+	if len(users.Columns["id"].DependantTables) != 1 {
+		t.Errorf("expected posts to reference users.id")
+	}
+	if len(sg.Tables["posts"].Indexes) != 1 {
+		t.Errorf("expected posts_user to be on posts")
+	}
+	if len(users.Views) != 1 {
+		t.Errorf("expected user_emails to read from users")
+	}
+}
+
+func TestSchemaGraphQualifiedNames(t *testing.T) {
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw: []rune(`
+			CREATE TABLE main.users (id integer PRIMARY KEY);
+			CREATE TABLE aux.users (id integer PRIMARY KEY);
+			CREATE TABLE aux.tokens (id integer PRIMARY KEY, user_id integer REFERENCES users(id));
+			CREATE INDEX aux.tokens_user ON tokens (user_id);`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := generator.NewSchemaGraphFromStatements(statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := sg.Tables["users"]; !ok {
+		t.Fatal("expected main.users to be keyed by its bare name")
+	}
+	auxUsers, ok := sg.Tables["aux.users"]
+	if !ok {
+		t.Fatal("expected aux.users to be keyed by its qualified name")
+	}
+	if len(auxUsers.Columns["id"].DependantTables) != 1 {
+		t.Errorf("expected aux.tokens to reference aux.users")
+	}
+	if len(sg.Tables["users"].Columns["id"].DependantTables) != 0 {
+		t.Errorf("expected main.users to have no dependants")
+	}
+	if len(sg.Tables["aux.tokens"].Indexes) != 1 {
+		t.Errorf("expected tokens_user to be on aux.tokens")
+	}
+}
@@ -0,0 +1,491 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+// Validate checks the schema for mistakes that sqlite either refuses when the
+// schema is created, or accepts and only fails on once rows are written.
+// Every problem found is returned as a report wrapped by a SchemaError.
+func Validate(statements []ast.Statement) error {
+	errs := []error{}
+
+	sg, err := NewSchemaGraphFromStatements(statements)
+	if schemaErr, ok := errors.AsType[*SchemaError](err); ok {
+		errs = append(errs, schemaErr.Errs...)
+	}
+
+	indexNames := map[string]ast.Identifier{}
+	for _, statement := range statements {
+		switch stmt := statement.(type) {
+		case *ast.CreateTable:
+			errs = append(errs, validateDuplicateColumns(stmt)...)
+			errs = append(errs, validateWithoutRowId(stmt)...)
+			errs = append(errs, validateStrictTypes(stmt)...)
+			errs = append(errs, validateAutoIncrement(stmt)...)
+			errs = append(errs, validateGeneratedColumns(stmt)...)
+			errs = append(errs, sg.validateForeignKeys(stmt)...)
+		case *ast.CreateIndex:
+			name := stmt.IndexIdentifier.ObjectName
+			if first, has := indexNames[stmt.IndexIdentifier.Canonical()]; has {
+				errs = append(errs, report.
+					NewReport("duplicate index").
+					WithLocation(name.FileLoc).
+					WithMessage(fmt.Sprintf("index \"%s\" is defined more than once", name.Text)).
+					WithLabels(
+						report.LabelFromIdentifier(first, "first defined here"),
+						report.LabelFromIdentifier(name, "defined again here"),
+					),
+				)
+				continue
+			}
+			indexNames[stmt.IndexIdentifier.Canonical()] = name
+		}
+	}
+
+	if len(errs) > 0 {
+		return &SchemaError{
+			Errs: errs,
+		}
+	}
+
+	return nil
+}
+
+func validateDuplicateColumns(table *ast.CreateTable) []error {
+	errs := []error{}
+
+	columns := map[string]ast.Identifier{}
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		name := column.ColumnName
+		first, has := columns[name.Canonical()]
+		if !has {
+			columns[name.Canonical()] = name
+			continue
+		}
+
+		errs = append(errs, report.
+			NewReport("duplicate column").
+			WithLocation(name.FileLoc).
+			WithMessage(fmt.Sprintf("column \"%s\" is defined more than once on table \"%s\"", name.Text, table.TableIdentifier.ObjectName.Text)).
+			WithLabels(
+				report.LabelFromIdentifier(first, "first defined here"),
+				report.LabelFromIdentifier(name, "defined again here"),
+			),
+		)
+	}
+
+	return errs
+}
+
+func validateWithoutRowId(table *ast.CreateTable) []error {
+	if table.TableOptions == nil || table.TableOptions.WithoutRowId == nil {
+		return nil
+	}
+
+	if len(primaryKeyColumns(table)) > 0 {
+		return nil
+	}
+
+	withoutRowId := table.TableOptions.WithoutRowId
+	return []error{report.
+		NewReport("invalid table").
+		WithLocation(withoutRowId.Without.FileLoc).
+		WithMessage(fmt.Sprintf("table \"%s\" is WITHOUT ROWID but has no primary key", table.TableIdentifier.ObjectName.Text)).
+		WithLabels(
+			report.LabelFromIdentifier(table.TableIdentifier.ObjectName, "this table has no primary key"),
+			report.LabelFromKeyword(withoutRowId.Without, "WITHOUT ROWID declared here"),
+		).
+		WithNotes(
+			"a WITHOUT ROWID table stores its rows by the primary key,",
+			"add a PRIMARY KEY or remove WITHOUT ROWID",
+		),
+	}
+}
+
+// strictTypes are the only types a column of a STRICT table may be declared with.
+var strictTypes = []string{"INT", "INTEGER", "REAL", "TEXT", "BLOB", "ANY"}
+
+// validateStrictTypes reports columns of a STRICT table that are declared
+// without a type, or with a type sqlite does not allow in a STRICT table.
+func validateStrictTypes(table *ast.CreateTable) []error {
+	if table.TableOptions == nil || table.TableOptions.Strict == nil {
+		return nil
+	}
+
+	errs := []error{}
+	strict := *table.TableOptions.Strict
+
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		if column.TypeName == nil {
+			errs = append(errs, report.
+				NewReport("invalid strict table").
+				WithLocation(column.ColumnName.FileLoc).
+				WithMessage(fmt.Sprintf("column \"%s\" of STRICT table \"%s\" has no type", column.ColumnName.Text, table.TableIdentifier.ObjectName.Text)).
+				WithLabels(
+					report.LabelFromIdentifier(column.ColumnName, "column has no type"),
+					report.LabelFromKeyword(strict, "STRICT declared here"),
+				).
+				WithNotes(
+					fmt.Sprintf("every column of a STRICT table must be declared as one of %s", strings.Join(strictTypes, ", ")),
+				),
+			)
+			continue
+		}
+
+		typeName := column.TypeName
+		isStrictType := slices.ContainsFunc(strictTypes, func(strictType string) bool {
+			return strings.EqualFold(typeName.Name.Text, strictType)
+		})
+		if isStrictType && typeName.Arg0 == nil {
+			continue
+		}
+
+		errs = append(errs, report.
+			NewReport("invalid strict table").
+			WithLocation(typeName.Name.FileLoc).
+			WithMessage(fmt.Sprintf("column \"%s\" of STRICT table \"%s\" has type \"%s\"", column.ColumnName.Text, table.TableIdentifier.ObjectName.Text, typeName.Name.Text)).
+			WithLabels(
+				report.LabelFromIdentifier(typeName.Name, "type not allowed in a STRICT table"),
+				report.LabelFromKeyword(strict, "STRICT declared here"),
+			).
+			WithNotes(
+				fmt.Sprintf("a column of a STRICT table must be declared as one of %s, without arguments", strings.Join(strictTypes, ", ")),
+			),
+		)
+	}
+
+	return errs
+}
+
+func validateAutoIncrement(table *ast.CreateTable) []error {
+	errs := []error{}
+
+	invalid := func(autoIncrement ast.Keyword, column *ast.ColumnDefinition) {
+		typeName := "no type"
+		if column.TypeName != nil {
+			if strings.EqualFold(column.TypeName.Name.Text, "integer") {
+				return
+			}
+			typeName = fmt.Sprintf("type \"%s\"", column.TypeName.Name.Text)
+		}
+
+		errs = append(errs, report.
+			NewReport("invalid autoincrement").
+			WithLocation(autoIncrement.FileLoc).
+			WithMessage(fmt.Sprintf("AUTOINCREMENT is only allowed on an INTEGER PRIMARY KEY, column \"%s\" has %s", column.ColumnName.Text, typeName)).
+			WithLabels(
+				report.LabelFromIdentifier(column.ColumnName, fmt.Sprintf("column has %s", typeName)),
+				report.LabelFromKeyword(autoIncrement, "AUTOINCREMENT used here"),
+			).
+			WithNotes(
+				"declare the column as INTEGER to make it an alias of the rowid",
+			),
+		)
+	}
+
+	columns := table.TableDefinition.ColumnDefinitions
+	for i := range columns {
+		for _, constraint := range columns[i].ColumnConstraints {
+			if pk, ok := constraint.(*ast.ColumnConstraint_PrimaryKey); ok && pk.AutoIncrement != nil {
+				invalid(*pk.AutoIncrement, &columns[i])
+			}
+		}
+	}
+
+	for _, constraint := range table.TableDefinition.TableConstraints {
+		pk, ok := constraint.(*ast.TableConstraint_PrimaryKey)
+		if !ok || pk.AutoIncrement == nil || len(pk.IndexedColumns) == 0 {
+			continue
+		}
+		ident, ok := pk.IndexedColumns[0].Subject.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		if column := columnDefinition(table, ident.Text); column != nil {
+			invalid(*pk.AutoIncrement, column)
+		}
+	}
+
+	return errs
+}
+
+// validateGeneratedColumns reports generated columns that are computed from
+// themselves or from columns defined after them.
+func validateGeneratedColumns(table *ast.CreateTable) []error {
+	errs := []error{}
+
+	columns := table.TableDefinition.ColumnDefinitions
+	position := map[string]int{}
+	for i, column := range columns {
+		position[column.ColumnName.Canonical()] = i
+	}
+
+	for i, column := range columns {
+		for _, constraint := range column.ColumnConstraints {
+			generated, ok := constraint.(*ast.ColumnConstraint_Generated)
+			if !ok {
+				continue
+			}
+
+			for _, ident := range exprIdentifiers(generated.AsExpr) {
+				at, isColumn := position[ident.Canonical()]
+				if !isColumn || at < i {
+					continue
+				}
+
+				note := fmt.Sprintf("\"%s\" is defined after \"%s\"", ident.Text, column.ColumnName.Text)
+				if at == i {
+					note = fmt.Sprintf("\"%s\" is the generated column itself", ident.Text)
+				}
+
+				errs = append(errs, report.
+					NewReport("invalid generated column").
+					WithLocation(ident.FileLoc).
+					WithMessage(fmt.Sprintf("generated column \"%s\" references column \"%s\"", column.ColumnName.Text, ident.Text)).
+					WithLabels(
+						report.LabelFromIdentifier(column.ColumnName, "generated column defined here"),
+						report.LabelFromIdentifier(ident, "referenced here"),
+						report.LabelFromIdentifier(columns[at].ColumnName, "column defined here"),
+					).
+					WithNotes(
+						note,
+						"a generated column may only be computed from the columns before it",
+					),
+				)
+			}
+		}
+	}
+
+	return errs
+}
+
+// foreignKey is a foreign key constraint of a table, written either on a
+// column or as a table constraint.
+type foreignKey struct {
+	Columns []ast.Identifier
+	Clause  *ast.ForeignKeyClause
+}
+
+func foreignKeys(table *ast.CreateTable) []foreignKey {
+	result := []foreignKey{}
+
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		for _, constraint := range column.ColumnConstraints {
+			if fk, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok {
+				result = append(result, foreignKey{
+					Columns: []ast.Identifier{column.ColumnName},
+					Clause:  &fk.FkClause,
+				})
+			}
+		}
+	}
+
+	for _, constraint := range table.TableDefinition.TableConstraints {
+		if fk, ok := constraint.(*ast.TableConstraint_ForeignKey); ok {
+			result = append(result, foreignKey{
+				Columns: fk.Columns,
+				Clause:  &fk.FkClause,
+			})
+		}
+	}
+
+	return result
+}
+
+// validateForeignKeys reports foreign keys that do not reference a primary
+// key or unique set of columns, and foreign key columns whose declared type
+// differs from the column they reference. Missing tables and columns are
+// already reported when the graph is resolved.
+func (sg *SchemaGraph) validateForeignKeys(table *ast.CreateTable) []error {
+	errs := []error{}
+
+	for _, fk := range foreignKeys(table) {
+		foreignTable, hasTable := sg.TableByIdent(fk.Clause.ForeignTable.InSchema(table.TableIdentifier.SchemaName))
+		if !hasTable || len(fk.Clause.ForeignColumns) == 0 {
+			// without columns the primary key of the foreign table is referenced
+			continue
+		}
+
+		if _, founds := sg.ColumnsByIdents(foreignTable, fk.Clause.ForeignColumns); slices.Contains(founds, false) {
+			continue
+		}
+
+		if !slices.ContainsFunc(uniqueKeys(foreignTable), func(key []string) bool {
+			return sameColumns(key, fk.Clause.ForeignColumns)
+		}) {
+			names := []string{}
+			for _, column := range fk.Clause.ForeignColumns {
+				names = append(names, fmt.Sprintf("\"%s\"", column.Text))
+			}
+
+			errs = append(errs, report.
+				NewReport("invalid foreign key").
+				WithLocation(fk.Clause.ForeignTable.ObjectName.FileLoc).
+				WithMessage(fmt.Sprintf("%s of table \"%s\" is not a primary key or unique", strings.Join(names, ", "), foreignTable.Name)).
+				WithLabels(
+					report.LabelFromIdentifier(fk.Clause.ForeignColumns[0], "referenced here"),
+					report.LabelFromIdentifier(foreignTable.CreateTable.TableIdentifier.ObjectName, "table defined here"),
+				).
+				WithNotes(
+					"sqlite fails every write to the table with a foreign key mismatch,",
+					"make the referenced columns the primary key or add a unique index on them",
+				),
+			)
+			continue
+		}
+
+		for i, local := range fk.Columns {
+			if i >= len(fk.Clause.ForeignColumns) {
+				break
+			}
+			localColumn := columnDefinition(table, local.Text)
+			foreignColumn := columnDefinition(foreignTable.CreateTable, fk.Clause.ForeignColumns[i].Text)
+			if localColumn == nil || foreignColumn == nil || localColumn.TypeName == nil || foreignColumn.TypeName == nil {
+				continue
+			}
+			if strings.EqualFold(localColumn.TypeName.Name.Text, foreignColumn.TypeName.Name.Text) {
+				continue
+			}
+
+			errs = append(errs, report.
+				NewReport("foreign key type mismatch").
+				WithLocation(localColumn.TypeName.Name.FileLoc).
+				WithMessage(fmt.Sprintf("column \"%s\" references \"%s\".\"%s\" of a different type", local.Text, foreignTable.Name, foreignColumn.ColumnName.Text)).
+				WithLabels(
+					report.LabelFromIdentifier(localColumn.TypeName.Name, fmt.Sprintf("\"%s\" declared as %s", local.Text, localColumn.TypeName.Name.Text)),
+					report.LabelFromIdentifier(foreignColumn.TypeName.Name, fmt.Sprintf("\"%s\" declared as %s", foreignColumn.ColumnName.Text, foreignColumn.TypeName.Name.Text)),
+				),
+			)
+		}
+	}
+
+	return errs
+}
+
+// uniqueKeys returns the sets of columns that are unique in the table, its
+// primary key, unique columns and the columns of its unique indexes.
+func uniqueKeys(table *Table) [][]string {
+	keys := [][]string{}
+
+	if pk := primaryKeyColumns(table.CreateTable); len(pk) > 0 {
+		keys = append(keys, pk)
+	}
+
+	for _, column := range table.CreateTable.TableDefinition.ColumnDefinitions {
+		for _, constraint := range column.ColumnConstraints {
+			if _, ok := constraint.(*ast.ColumnConstraint_Unique); ok {
+				keys = append(keys, []string{column.ColumnName.Canonical()})
+			}
+		}
+	}
+
+	for _, constraint := range table.CreateTable.TableDefinition.TableConstraints {
+		unique, ok := constraint.(*ast.TableConstraint_Unique)
+		if !ok {
+			continue
+		}
+		key := []string{}
+		for _, indexed := range unique.IndexedColumns {
+			if ident, ok := indexed.Subject.(*ast.Identifier); ok {
+				key = append(key, ident.Canonical())
+			}
+		}
+		if len(key) == len(unique.IndexedColumns) {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, index := range table.Indexes {
+		if index.CreateIndex.UniqueKeyword == nil || index.CreateIndex.WhereExpr != nil {
+			continue
+		}
+		if len(index.Columns) != len(index.CreateIndex.IndexedColumns) {
+			// indexes on expressions can't be referenced
+			continue
+		}
+		key := []string{}
+		for _, column := range index.Columns {
+			key = append(key, column.Name.Canonical())
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func primaryKeyColumns(table *ast.CreateTable) []string {
+	for _, column := range table.TableDefinition.ColumnDefinitions {
+		for _, constraint := range column.ColumnConstraints {
+			if _, ok := constraint.(*ast.ColumnConstraint_PrimaryKey); ok {
+				return []string{column.ColumnName.Canonical()}
+			}
+		}
+	}
+
+	for _, constraint := range table.TableDefinition.TableConstraints {
+		if pk, ok := constraint.(*ast.TableConstraint_PrimaryKey); ok {
+			columns := []string{}
+			for _, indexed := range pk.IndexedColumns {
+				if ident, ok := indexed.Subject.(*ast.Identifier); ok {
+					columns = append(columns, ident.Canonical())
+				}
+			}
+			return columns
+		}
+	}
+
+	return nil
+}
+
+func sameColumns(key []string, columns []ast.Identifier) bool {
+	if len(key) != len(columns) {
+		return false
+	}
+	for _, column := range columns {
+		if !slices.Contains(key, column.Canonical()) {
+			return false
+		}
+	}
+	return true
+}
+
+func columnDefinition(table *ast.CreateTable, name string) *ast.ColumnDefinition {
+	columns := table.TableDefinition.ColumnDefinitions
+	for i := range columns {
+		if columns[i].ColumnName.Canonical() == ast.CanonicalName(name) {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+// exprIdentifiers returns the identifiers that an expression reads, function
+// names are not included.
+func exprIdentifiers(expr ast.Expr) []ast.Identifier {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return []ast.Identifier{*e}
+	case *ast.ColumnName:
+		return []ast.Identifier{e.Column}
+	case *ast.UnaryOp:
+		return exprIdentifiers(e.Rhs)
+	case *ast.BinaryOp:
+		return append(exprIdentifiers(e.Lhs), exprIdentifiers(e.Rhs)...)
+	case *ast.FunctionCall:
+		return exprIdentifiers(e.Args)
+	case ast.ExprList:
+		result := []ast.Identifier{}
+		for _, item := range e {
+			result = append(result, exprIdentifiers(item)...)
+		}
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package generator_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
+)
+
+func validate(t *testing.T, schema string) []string {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(schema),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = generator.Validate(statements)
+	if err == nil {
+		return nil
+	}
+
+	schemaErr, ok := errors.AsType[*generator.SchemaError](err)
+	if !ok {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+
+	kinds := []string{}
+	for _, err := range schemaErr.Errs {
+		rep, ok := err.(*report.Report)
+		if !ok {
+			t.Fatalf("expected a report, got %v", err)
+		}
+		kinds = append(kinds, rep.Kind)
+	}
+	return kinds
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		kinds  []string
+	}{
+		{
+			name: "valid",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY AUTOINCREMENT, email text UNIQUE);
+				CREATE TABLE login (id integer PRIMARY KEY, email text REFERENCES account(email));
+				CREATE TABLE total (a integer, b integer, c integer AS (a + b) STORED);
+				CREATE INDEX login_email ON login (email);`,
+		},
+		{
+			name: "foreign key to non unique column",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text);
+				CREATE TABLE login (id integer PRIMARY KEY, email text REFERENCES account(email));`,
+			kinds: []string{"invalid foreign key"},
+		},
+		{
+			name: "foreign key to unique index",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text);
+				CREATE TABLE login (id integer PRIMARY KEY, email text REFERENCES account(email));
+				CREATE UNIQUE INDEX account_email ON account (email);`,
+		},
+		{
+			name: "foreign key type mismatch",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY);
+				CREATE TABLE login (id integer PRIMARY KEY, account_id text REFERENCES account(id));`,
+			kinds: []string{"foreign key type mismatch"},
+		},
+		{
+			name: "duplicate column",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text, EMAIL text);`,
+			kinds: []string{"duplicate column"},
+		},
+		{
+			name: "duplicate index",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text);
+				CREATE INDEX account_email ON account (email);
+				CREATE INDEX account_email ON account (id);`,
+			kinds: []string{"duplicate index"},
+		},
+		{
+			name: "index on missing column",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY);
+				CREATE INDEX account_email ON account (email);`,
+			kinds: []string{"invalid index"},
+		},
+		{
+			name: "index on table created from a select",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email text);
+				CREATE TABLE emails AS SELECT email FROM account;
+				CREATE INDEX emails_email ON emails (email);`,
+		},
+		{
+			name: "without rowid without primary key",
+			schema: `
+				CREATE TABLE account (id integer, email text) WITHOUT ROWID;`,
+			kinds: []string{"invalid table"},
+		},
+		{
+			name: "strict table",
+			schema: `
+				CREATE TABLE account (id INTEGER PRIMARY KEY, email text, avatar blob, balance real, meta any) STRICT, WITHOUT ROWID;`,
+		},
+		{
+			name: "strict table with disallowed types",
+			schema: `
+				CREATE TABLE account (id integer PRIMARY KEY, email varchar, code int(4), meta) STRICT;`,
+			kinds: []string{"invalid strict table", "invalid strict table", "invalid strict table"},
+		},
+		{
+			name: "autoincrement on non integer primary key",
+			schema: `
+				CREATE TABLE account (id int PRIMARY KEY AUTOINCREMENT);`,
+			kinds: []string{"invalid autoincrement"},
+		},
+		{
+			name: "generated column referencing a later column",
+			schema: `
+				CREATE TABLE total (a integer, c integer GENERATED ALWAYS AS (a + b) VIRTUAL, b integer);`,
+			kinds: []string{"invalid generated column"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kinds := validate(t, test.schema)
+			if !slices.Equal(kinds, test.kinds) {
+				t.Errorf("expected %v, got %v", test.kinds, kinds)
+			}
+		})
+	}
+}
+
+func TestValidateSuggestsMissingColumn(t *testing.T) {
+	schema := "CREATE TABLE account (id integer PRIMARY KEY);\n" +
+		"CREATE TABLE login (id integer PRIMARY KEY, team_id integer REFERENCES account (team_id));"
+
+	statements, err := parser.Parse(lexer.SourceCode{FileName: t.Name(), Raw: []rune(schema)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []report.Edit{}
+	for _, rep := range report.Collect(generator.Validate(statements)) {
+		edits = append(edits, rep.Edits(t.Name())...)
+	}
+
+	fixed, applied := report.ApplyEdits([]rune(schema), edits)
+	if applied != 1 {
+		t.Fatalf("expected one edit, got %d", applied)
+	}
+
+	expected := "CREATE TABLE account (\"team_id\" integer UNIQUE, id integer PRIMARY KEY);\n" +
+		"CREATE TABLE login (id integer PRIMARY KEY, team_id integer REFERENCES account (team_id));"
+	if string(fixed) != expected {
+		t.Errorf("expected %q, got %q", expected, string(fixed))
+	}
+}
@@ -0,0 +1,291 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+// IntrospectSchema builds the schema model from the pragmas that describe the
+// tables of every database, rather than from their stored definitions, so that
+// it is independent of the parser.
+//
+// The models have no nodes, and the pragmas don't report CHECK constraints,
+// column collations, defaults, constraint names, deferrability or any
+// expressions, so those are left empty, an indexed expression is a column
+// without a name. Virtual tables and their shadow tables are skipped.
+func (sqlite *Sqlite) IntrospectSchema() (*schema.Schema, error) {
+	schemas, err := sqlite.Databases()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &schema.Schema{}
+	for _, schemaName := range schemas {
+		tables, err := sqlite.introspectTables(schemaName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, table := range tables {
+			indexes, err := sqlite.introspectIndexes(table)
+			if err != nil {
+				return nil, err
+			}
+			result.Tables = append(result.Tables, table)
+			result.Indexes = append(result.Indexes, indexes...)
+		}
+	}
+
+	return result, nil
+}
+
+func (sqlite *Sqlite) introspectTables(schemaName string) ([]*schema.Table, error) {
+	shadowTables, err := sqlite.shadowTables(schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlite.Query("select name, wr, strict from pragma_table_list where schema = ? and type = 'table' and name not like 'sqlite_%';", schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []*schema.Table{}
+	for rows.Next() {
+		var name string
+		var withoutRowId, strict bool
+		if err := rows.Scan(&name, &withoutRowId, &strict); err != nil {
+			return nil, err
+		}
+		if shadowTables[ast.CanonicalName(name)] {
+			continue
+		}
+
+		tables = append(tables, &schema.Table{
+			Schema:       ast.CanonicalName(schemaName),
+			Name:         ast.CanonicalName(name),
+			Strict:       strict,
+			WithoutRowId: withoutRowId,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, table := range tables {
+		if err := sqlite.introspectColumns(schemaName, table); err != nil {
+			return nil, err
+		}
+		if err := sqlite.introspectForeignKeys(schemaName, table); err != nil {
+			return nil, err
+		}
+	}
+
+	return tables, nil
+}
+
+type tableXInfoRow struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Pk      int
+	Hidden  int
+}
+
+// hidden column kinds reported by pragma_table_xinfo
+const (
+	hiddenGeneratedVirtual = 2
+	hiddenGeneratedStored  = 3
+)
+
+func (sqlite *Sqlite) introspectColumns(schemaName string, table *schema.Table) error {
+	rows, err := sqlite.Query("select name, type, \"notnull\", pk, hidden from pragma_table_xinfo(?, ?) order by cid;", table.Name, schemaName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	primaryKey := map[int]string{}
+	for rows.Next() {
+		row := tableXInfoRow{}
+		if err := rows.Scan(&row.Name, &row.Type, &row.NotNull, &row.Pk, &row.Hidden); err != nil {
+			return fmt.Errorf("introspecting columns of %s.%s: %w", schemaName, table.Name, err)
+		}
+
+		column := &schema.Column{Name: ast.CanonicalName(row.Name)}
+		if row.Type != "" {
+			column.Type = &schema.Type{Name: row.Type, Affinity: schema.AffinityOf(row.Type)}
+		}
+		column.NotNull = row.NotNull
+
+		switch row.Hidden {
+		case hiddenGeneratedVirtual:
+			column.Generated = &schema.Generated{Storage: schema.StorageVirtual}
+		case hiddenGeneratedStored:
+			column.Generated = &schema.Generated{Storage: schema.StorageStored}
+		}
+
+		if row.Pk > 0 {
+			primaryKey[row.Pk] = column.Name
+		}
+
+		table.Columns = append(table.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(primaryKey) > 0 {
+		table.PrimaryKey = &schema.PrimaryKey{}
+		for i := 1; i <= len(primaryKey); i++ {
+			table.PrimaryKey.Columns = append(table.PrimaryKey.Columns, schema.IndexedColumn{
+				Name:  primaryKey[i],
+				Order: schema.OrderAscending,
+			})
+		}
+	}
+
+	return nil
+}
+
+var foreignKeyActions = map[string]schema.ForeignKeyAction{
+	"NO ACTION":   schema.ForeignKeyActionNoAction,
+	"RESTRICT":    schema.ForeignKeyActionRestrict,
+	"SET NULL":    schema.ForeignKeyActionSetNull,
+	"SET DEFAULT": schema.ForeignKeyActionSetDefault,
+	"CASCADE":     schema.ForeignKeyActionCascade,
+}
+
+func (sqlite *Sqlite) introspectForeignKeys(schemaName string, table *schema.Table) error {
+	rows, err := sqlite.Query("select id, \"table\", \"from\", \"to\", on_update, on_delete from pragma_foreign_key_list(?, ?) order by id, seq;", table.Name, schemaName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byId := map[int]*schema.ForeignKey{}
+	for rows.Next() {
+		var id int
+		var foreignTable, from, onUpdate, onDelete string
+		var to sql.NullString
+		if err := rows.Scan(&id, &foreignTable, &from, &to, &onUpdate, &onDelete); err != nil {
+			return fmt.Errorf("introspecting foreign keys of %s.%s: %w", schemaName, table.Name, err)
+		}
+
+		fk, ok := byId[id]
+		if !ok {
+			fk = &schema.ForeignKey{
+				ForeignTable: ast.CanonicalName(foreignTable),
+				OnUpdate:     foreignKeyActions[onUpdate],
+				OnDelete:     foreignKeyActions[onDelete],
+			}
+			byId[id] = fk
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+
+		fk.Columns = append(fk.Columns, ast.CanonicalName(from))
+		// the foreign columns are null when the key references the primary key
+		if to.Valid {
+			fk.ForeignColumns = append(fk.ForeignColumns, ast.CanonicalName(to.String))
+		}
+	}
+
+	return rows.Err()
+}
+
+type indexListRow struct {
+	Name   string
+	Unique bool
+	Origin string
+}
+
+// index origins reported by pragma_index_list
+const (
+	originCreateIndex = "c"
+	originUnique      = "u"
+	originPrimaryKey  = "pk"
+)
+
+func (sqlite *Sqlite) introspectIndexes(table *schema.Table) ([]*schema.Index, error) {
+	rows, err := sqlite.Query("select name, \"unique\", origin from pragma_index_list(?, ?) order by seq;", table.Name, table.Schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []indexListRow{}
+	for rows.Next() {
+		row := indexListRow{}
+		if err := rows.Scan(&row.Name, &row.Unique, &row.Origin); err != nil {
+			return nil, fmt.Errorf("introspecting indexes of %s.%s: %w", table.Schema, table.Name, err)
+		}
+		list = append(list, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	indexes := []*schema.Index{}
+	for _, row := range list {
+		columns, err := sqlite.introspectIndexedColumns(table.Schema, row.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch row.Origin {
+		case originPrimaryKey:
+			// only a primary key that is not the rowid has an index, which
+			// is the only place the order and collation of the key is reported
+			table.PrimaryKey.Columns = columns
+		case originUnique:
+			table.Uniques = append(table.Uniques, &schema.Unique{Columns: columns})
+		case originCreateIndex:
+			index := &schema.Index{
+				Schema:  table.Schema,
+				Name:    ast.CanonicalName(row.Name),
+				Table:   table.Name,
+				Unique:  row.Unique,
+				Columns: columns,
+			}
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes, nil
+}
+
+func (sqlite *Sqlite) introspectIndexedColumns(schemaName string, index string) ([]schema.IndexedColumn, error) {
+	rows, err := sqlite.Query("select name, \"desc\", coll from pragma_index_xinfo(?, ?) where key order by seqno;", index, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []schema.IndexedColumn{}
+	for rows.Next() {
+		var name sql.NullString
+		var desc bool
+		var collate string
+		if err := rows.Scan(&name, &desc, &collate); err != nil {
+			return nil, fmt.Errorf("introspecting columns of index %s.%s: %w", schemaName, index, err)
+		}
+
+		column := schema.IndexedColumn{
+			Collate: strings.ToUpper(collate),
+			Order:   schema.OrderAscending,
+		}
+		if desc {
+			column.Order = schema.OrderDescending
+		}
+		column.Name = ast.CanonicalName(name.String)
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
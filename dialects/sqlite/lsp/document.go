@@ -0,0 +1,312 @@
+package lsp
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"unicode/utf16"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+var (
+	ErrAnalysisFailed = errors.New("analysing the document failed")
+)
+
+// document is an open schema file and what is known about it, it is analysed
+// again whenever its text changes.
+type document struct {
+	uri     string
+	version int
+	source  lexer.SourceCode
+	// lines holds the offset of the first character of every line
+	lines []int
+
+	statements []ast.Statement
+	graph      *generator.SchemaGraph
+	errors     []report.Report
+	warnings   []report.Report
+	// hasSyntaxErrors is set when the document doesn't parse, the schema
+	// isn't validated then
+	hasSyntaxErrors bool
+	symbols         []symbol
+}
+
+// symbol is an identifier naming a table or a column of the schema, either
+// where it is defined or where it is referenced.
+type symbol struct {
+	ident  ast.Identifier
+	table  *generator.Table
+	column *generator.Column
+}
+
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{
+		uri:     uri,
+		version: version,
+		source:  lexer.SourceCode{FileName: fileNameOf(uri), Raw: []rune(text)},
+	}
+
+	doc.lines = []int{0}
+	for offset, r := range doc.source.Raw {
+		if r == '\n' {
+			doc.lines = append(doc.lines, offset+1)
+		}
+	}
+
+	doc.analyse()
+	return doc
+}
+
+// fileNameOf is the path of a file uri, the uri itself names documents that
+// are not files.
+func fileNameOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func (doc *document) analyse() {
+	// the text is whatever the editor holds while it is being typed, a
+	// problem analysing it is reported instead of stopping the server
+	defer func() {
+		if r := recover(); r != nil {
+			doc.errors = append(doc.errors, *report.NewReport("internal error").
+				WithLocation(doc.locationOf(0)).
+				WithMessage(fmt.Sprintf("%s: %v", ErrAnalysisFailed, r)))
+		}
+	}()
+
+	doc.graph = generator.NewSchemaGraph()
+
+	p := parser.NewSqliteParser(lexer.NewLexer(doc.source))
+	doc.statements = p.Statements()
+	doc.errors = p.ErrorsAsReportSlice()
+	doc.warnings = p.WarningsAsReportSlice()
+	doc.hasSyntaxErrors = len(doc.errors) > 0
+
+	doc.graph, _ = generator.NewSchemaGraphFromStatements(doc.statements)
+	if !doc.hasSyntaxErrors {
+		for _, rep := range report.Collect(generator.Validate(doc.statements)) {
+			doc.errors = append(doc.errors, *rep)
+		}
+	}
+
+	doc.collectSymbols()
+}
+
+func (doc *document) locationOf(offset int) token.Location {
+	pos := doc.positionOf(offset)
+	return token.Location{FileName: doc.source.FileName, Line: pos.Line + 1, Col: pos.Character + 1}
+}
+
+func (doc *document) collectSymbols() {
+	doc.symbols = []symbol{}
+
+	for _, statement := range doc.statements {
+		switch stmt := statement.(type) {
+		case *ast.CreateTable:
+			table, ok := doc.graph.TableByIdent(stmt.TableIdentifier)
+			if !ok || table.CreateTable != stmt {
+				continue
+			}
+			doc.symbols = append(doc.symbols, symbol{ident: stmt.TableIdentifier.ObjectName, table: table})
+
+			if stmt.TableDefinition == nil {
+				continue
+			}
+			schema := stmt.TableIdentifier.SchemaName
+			for _, column := range stmt.TableDefinition.ColumnDefinitions {
+				doc.addColumn(table, column.ColumnName)
+				for _, constraint := range column.ColumnConstraints {
+					if fk, ok := constraint.(*ast.ColumnConstraint_ForeignKey); ok {
+						doc.addForeignKeyClause(&fk.FkClause, schema)
+					}
+				}
+			}
+			for _, constraint := range stmt.TableDefinition.TableConstraints {
+				switch c := constraint.(type) {
+				case *ast.TableConstraint_ForeignKey:
+					for _, column := range c.Columns {
+						doc.addColumn(table, column)
+					}
+					doc.addForeignKeyClause(&c.FkClause, schema)
+				case *ast.TableConstraint_PrimaryKey:
+					doc.addIndexedColumns(table, c.IndexedColumns)
+				case *ast.TableConstraint_Unique:
+					doc.addIndexedColumns(table, c.IndexedColumns)
+				}
+			}
+
+		case *ast.CreateIndex:
+			index, ok := doc.graph.Indexes[stmt.IndexIdentifier.Canonical()]
+			if !ok || index.CreateIndex != stmt || index.Table == nil {
+				continue
+			}
+			doc.symbols = append(doc.symbols, symbol{ident: stmt.OnTable, table: index.Table})
+			doc.addIndexedColumns(index.Table, stmt.IndexedColumns)
+
+		case *ast.CreateTrigger:
+			trigger, ok := doc.graph.Triggers[stmt.TriggerIdentifier.Canonical()]
+			if !ok || trigger.CreateTrigger != stmt || trigger.Table == nil {
+				continue
+			}
+			doc.symbols = append(doc.symbols, symbol{ident: stmt.OnTable.ObjectName, table: trigger.Table})
+		}
+	}
+}
+
+func (doc *document) addColumn(table *generator.Table, ident ast.Identifier) {
+	if column, ok := table.Columns[ident.Canonical()]; ok {
+		doc.symbols = append(doc.symbols, symbol{ident: ident, table: table, column: column})
+	}
+}
+
+func (doc *document) addIndexedColumns(table *generator.Table, columns []ast.IndexedColumn) {
+	for _, indexed := range columns {
+		if ident, ok := indexed.Subject.(*ast.Identifier); ok {
+			doc.addColumn(table, *ident)
+		}
+	}
+}
+
+func (doc *document) addForeignKeyClause(clause *ast.ForeignKeyClause, schema *ast.Identifier) {
+	table, ok := doc.graph.TableByIdent(clause.ForeignTable.InSchema(schema))
+	if !ok {
+		return
+	}
+	doc.symbols = append(doc.symbols, symbol{ident: clause.ForeignTable.ObjectName, table: table})
+	for _, column := range clause.ForeignColumns {
+		doc.addColumn(table, column)
+	}
+}
+
+// symbolAt is the symbol the offset is on, the end of an identifier counts
+// as on it so that the cursor right after a name finds it.
+func (doc *document) symbolAt(offset int) (symbol, bool) {
+	for _, sym := range doc.symbols {
+		if sym.ident.SourceRange.Start <= offset && offset <= sym.ident.SourceRange.End {
+			return sym, true
+		}
+	}
+	return symbol{}, false
+}
+
+// definition is the identifier that defines the table or column of the symbol.
+func (sym symbol) definition() ast.Identifier {
+	if sym.column != nil {
+		return sym.column.Name
+	}
+	return sym.table.CreateTable.TableIdentifier.ObjectName
+}
+
+// columnDefinition is the definition of the column of the symbol in its table.
+func (sym symbol) columnDefinition() (*ast.ColumnDefinition, bool) {
+	if sym.table.CreateTable.TableDefinition == nil {
+		return nil, false
+	}
+	definitions := sym.table.CreateTable.TableDefinition.ColumnDefinitions
+	i := slices.IndexFunc(definitions, func(def ast.ColumnDefinition) bool {
+		return def.ColumnName.Canonical() == sym.column.Name.Canonical()
+	})
+	if i == -1 {
+		return nil, false
+	}
+	return &definitions[i], true
+}
+
+// positionOf converts a character offset to a protocol position, whose
+// character counts utf-16 code units.
+func (doc *document) positionOf(offset int) Position {
+	offset = max(0, min(offset, len(doc.source.Raw)))
+	line := sort.Search(len(doc.lines), func(i int) bool { return doc.lines[i] > offset }) - 1
+	start := doc.lines[line]
+	return Position{
+		Line:      line,
+		Character: len(utf16.Encode(doc.source.Raw[start:offset])),
+	}
+}
+
+// offsetOf converts a protocol position to a character offset, positions
+// past the end of a line are on the end of the line.
+func (doc *document) offsetOf(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(doc.lines) {
+		return len(doc.source.Raw)
+	}
+
+	offset, units := doc.lines[pos.Line], 0
+	for offset < len(doc.source.Raw) && doc.source.Raw[offset] != '\n' && units < pos.Character {
+		units += utf16.RuneLen(doc.source.Raw[offset])
+		offset++
+	}
+	return offset
+}
+
+func (doc *document) rangeOf(start, end int) Range {
+	return Range{Start: doc.positionOf(start), End: doc.positionOf(end)}
+}
+
+func (doc *document) spanRange(span report.Span) Range {
+	if span.Start >= 0 {
+		return doc.rangeOf(span.Start, span.End)
+	}
+	if span.StartLine < 1 || span.StartLine > len(doc.lines) {
+		return doc.rangeOf(0, 0)
+	}
+	offset := doc.offsetOf(Position{Line: span.StartLine - 1, Character: max(0, span.StartCol-1)})
+	return doc.rangeOf(offset, offset)
+}
+
+func (doc *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, rep := range doc.errors {
+		diagnostics = append(diagnostics, doc.diagnostic(rep, SeverityError))
+	}
+	for _, rep := range doc.warnings {
+		diagnostics = append(diagnostics, doc.diagnostic(rep, SeverityWarning))
+	}
+
+	// the parser keeps its reports in a map, order them by where they are
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.Range.Start.Line, b.Range.Start.Line),
+			cmp.Compare(a.Range.Start.Character, b.Range.Start.Character),
+		)
+	})
+	return diagnostics
+}
+
+func (doc *document) diagnostic(rep report.Report, severity DiagnosticSeverity) Diagnostic {
+	diagnostic := Diagnostic{
+		Range:    doc.spanRange(rep.PrimarySpan()),
+		Severity: severity,
+		Code:     rep.Kind,
+		Source:   "justmigrate",
+		Message:  rep.Description(),
+	}
+
+	for _, label := range rep.Labels {
+		if label.Source.FileName != doc.source.FileName || label.Note == "" {
+			continue
+		}
+		diagnostic.RelatedInformation = append(diagnostic.RelatedInformation, DiagnosticRelatedInformation{
+			Location: Location{Uri: doc.uri, Range: doc.rangeOf(label.Range.Start, label.Range.End)},
+			Message:  label.Note,
+		})
+	}
+
+	return diagnostic
+}
@@ -0,0 +1,224 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/backend/formatter"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+var (
+	ErrFormatSyntaxErrors = errors.New("the document has syntax errors, fix them before formatting")
+)
+
+// formatWidth is the width the formatter fits statements into.
+const formatWidth = 80
+
+// formatOptions are those of the fmt command when none are given.
+var formatOptions = generator.FormatOptions{Quoting: generator.QuoteAlways}
+
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.Uri)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := doc.symbolAt(doc.offsetOf(p.Position))
+	if !ok {
+		return nil, nil
+	}
+
+	definition := sym.definition()
+	return Location{
+		Uri:   doc.uri,
+		Range: doc.rangeOf(definition.SourceRange.Start, definition.SourceRange.End),
+	}, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.Uri)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, ok := doc.symbolAt(doc.offsetOf(p.Position))
+	if !ok {
+		return nil, nil
+	}
+
+	var node interface{ Accept(ast.Visitor) } = sym.table.CreateTable
+	description := fmt.Sprintf("table `%s`", sym.table.Name)
+	if sym.column != nil {
+		definition, ok := sym.columnDefinition()
+		if !ok {
+			return nil, nil
+		}
+		node = definition
+		description = fmt.Sprintf("column of table `%s`", sym.table.Name)
+	}
+
+	sb := &strings.Builder{}
+	node.Accept(generator.NewSqliteFormatter(false, formatter.NewCoreFormatter(sb, formatWidth, "")))
+
+	hoverRange := doc.rangeOf(sym.ident.SourceRange.Start, sym.ident.SourceRange.End)
+	return Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```sql\n%s\n```\n%s", sb.String(), description),
+		},
+		Range: &hoverRange,
+	}, nil
+}
+
+func (s *Server) completion(params json.RawMessage) (any, error) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.Uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return CompletionList{Items: doc.completions(doc.offsetOf(p.Position))}, nil
+}
+
+// completions are the names that can be written at offset, the tables after
+// a keyword naming a table and the columns of the table a list of columns
+// belongs to. Elsewhere every table and column is offered.
+func (doc *document) completions(offset int) []CompletionItem {
+	tokens := []token.Token{}
+	lex := lexer.NewLexer(lexer.SourceCode{FileName: doc.source.FileName, Raw: doc.source.Raw[:offset]})
+	for tok := lex.NextToken(); tok.Kind != token.TokenKind_EOF; tok = lex.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	// the name being typed is replaced by the completion, it is not context
+	if n := len(tokens); n > 0 && tokens[n-1].SourceRange.End == offset && tokens[n-1].Kind == token.TokenKind_Identifier {
+		tokens = tokens[:n-1]
+	}
+
+	tables := slices.SortedFunc(maps.Values(doc.graph.Tables), func(a, b *generator.Table) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if n := len(tokens); n > 0 {
+		switch tokens[n-1].Kind {
+		case token.TokenKind_Keyword_REFERENCES, token.TokenKind_Keyword_ON, token.TokenKind_Keyword_TABLE:
+			return tableCompletions(tables)
+		}
+	}
+
+	if table, ok := doc.enclosingTable(tokens); ok {
+		return columnCompletions(table)
+	}
+
+	items := tableCompletions(tables)
+	for _, table := range tables {
+		items = append(items, columnCompletions(table)...)
+	}
+	return items
+}
+
+// enclosingTable finds the table whose columns are listed in the innermost
+// open parenthesis, as in REFERENCES t (, ON t ( or a table constraint of
+// CREATE TABLE t (.
+func (doc *document) enclosingTable(tokens []token.Token) (*generator.Table, bool) {
+	depth := 0
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch tokens[i].Kind {
+		case ')':
+			depth++
+			continue
+		case '(':
+			if depth > 0 {
+				depth--
+				continue
+			}
+		default:
+			continue
+		}
+
+		// an open parenthesis, the name of its table comes before it
+		if i < 2 || tokens[i-1].Kind != token.TokenKind_Identifier {
+			continue
+		}
+		switch tokens[i-2].Kind {
+		case token.TokenKind_Keyword_REFERENCES, token.TokenKind_Keyword_ON, token.TokenKind_Keyword_TABLE:
+		default:
+			continue
+		}
+
+		ident := ast.Identifier(tokens[i-1])
+		table, ok := doc.graph.Tables[ast.CanonicalName(ident.Text)]
+		return table, ok
+	}
+	return nil, false
+}
+
+func tableCompletions(tables []*generator.Table) []CompletionItem {
+	items := []CompletionItem{}
+	for _, table := range tables {
+		items = append(items, CompletionItem{Label: table.Name, Kind: CompletionItemKindClass, Detail: "table"})
+	}
+	return items
+}
+
+func columnCompletions(table *generator.Table) []CompletionItem {
+	items := []CompletionItem{}
+	if table.CreateTable.TableDefinition == nil {
+		return items
+	}
+	for _, definition := range table.CreateTable.TableDefinition.ColumnDefinitions {
+		detail := table.Name
+		if definition.TypeName != nil {
+			detail = fmt.Sprintf("%s %s", table.Name, definition.TypeName.Name.Text)
+		}
+		items = append(items, CompletionItem{Label: definition.ColumnName.Text, Kind: CompletionItemKindField, Detail: detail})
+	}
+	return items
+}
+
+func (s *Server) formatting(params json.RawMessage) (any, error) {
+	p, err := decode[DocumentFormattingParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.Uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.hasSyntaxErrors {
+		return nil, ErrFormatSyntaxErrors
+	}
+
+	formatted, err := sqlite.Format(doc.source, formatWidth, formatOptions)
+	if err != nil {
+		return nil, ErrFormatSyntaxErrors
+	}
+
+	if formatted == string(doc.source.Raw) {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   doc.rangeOf(0, len(doc.source.Raw)),
+		NewText: formatted,
+	}}, nil
+}
@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+	ErrUnknownDocument     = errors.New("document is not open")
+)
+
+// Server is a language server for sqlite schema files, it speaks the
+// protocol over a reader and writer such as stdin and stdout.
+type Server struct {
+	conn      *conn
+	documents map[string]*document
+	shutdown  bool
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		documents: map[string]*document{},
+	}
+}
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+var requestHandlers = map[string]handler{
+	"initialize":              (*Server).initialize,
+	"shutdown":                (*Server).handleShutdown,
+	"textDocument/definition": (*Server).definition,
+	"textDocument/hover":      (*Server).hover,
+	"textDocument/completion": (*Server).completion,
+	"textDocument/formatting": (*Server).formatting,
+}
+
+var notificationHandlers = map[string]handler{
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+}
+
+// Serve handles messages until the client sends the exit notification or
+// the connection is closed. An exit that follows a shutdown request is the
+// only clean way for the server to stop.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if respErr, ok := errors.AsType[*ResponseError](err); ok {
+				s.conn.reply(nil, nil, respErr)
+				continue
+			}
+			if errors.Is(err, io.EOF) && s.shutdown {
+				return nil
+			}
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if msg.Id == nil {
+			// notifications have no reply, ones the server doesn't handle
+			// are ignored as the protocol asks
+			if handle, ok := notificationHandlers[msg.Method]; ok {
+				handle(s, msg.Params)
+			}
+			continue
+		}
+
+		handle, ok := requestHandlers[msg.Method]
+		if !ok {
+			err = &ResponseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", msg.Method)}
+		} else if s.shutdown {
+			err = &ResponseError{Code: codeInvalidRequest, Message: "the server is shutting down"}
+		}
+		if err != nil {
+			if err := s.conn.reply(msg.Id, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := handle(s, msg.Params)
+		if err := s.conn.reply(msg.Id, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func decode[T any](params json.RawMessage) (T, error) {
+	var value T
+	if err := json.Unmarshal(params, &value); err != nil {
+		return value, &ResponseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return value, nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:   textDocumentSyncFull,
+			DefinitionProvider: true,
+			HoverProvider:      true,
+			CompletionProvider: CompletionOptions{
+				TriggerCharacters: []string{"(", ",", "."},
+			},
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "justmigrate"},
+	}, nil
+}
+
+func (s *Server) handleShutdown(params json.RawMessage) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (any, error) {
+	p, err := decode[DidOpenTextDocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.open(p.TextDocument.Uri, p.TextDocument.Version, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) (any, error) {
+	p, err := decode[DidChangeTextDocumentParams](params)
+	if err != nil || len(p.ContentChanges) == 0 {
+		return nil, err
+	}
+	// the server asks for the full text, so the last change holds all of it
+	text := p.ContentChanges[len(p.ContentChanges)-1].Text
+	return nil, s.open(p.TextDocument.Uri, p.TextDocument.Version, text)
+}
+
+func (s *Server) didClose(params json.RawMessage) (any, error) {
+	p, err := decode[DidCloseTextDocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.documents, p.TextDocument.Uri)
+	// clear the diagnostics of the document now that nothing keeps them current
+	return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		Uri:         p.TextDocument.Uri,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+func (s *Server) open(uri string, version int, text string) error {
+	doc := newDocument(uri, version, text)
+	s.documents[uri] = doc
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		Uri:         uri,
+		Version:     &doc.version,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.documents[uri]
+	if !ok {
+		return nil, &ResponseError{Code: codeInvalidParams, Message: fmt.Sprintf("%s: %s", ErrUnknownDocument, uri)}
+	}
+	return doc, nil
+}
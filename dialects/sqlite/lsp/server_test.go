@@ -0,0 +1,354 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const schemaUri = "file:///project/schema.sql"
+
+const schema = `CREATE TABLE accounts (
+  id integer PRIMARY KEY,
+  email text NOT NULL UNIQUE
+);
+
+CREATE TABLE users (
+  id integer PRIMARY KEY,
+  account_id integer REFERENCES accounts (id)
+);
+
+CREATE INDEX users_account ON users (account_id);
+`
+
+// client scripts a session with a server over pipes, as an editor would over
+// the stdio of the server.
+type client struct {
+	t             *testing.T
+	conn          *conn
+	nextId        int
+	notifications []*message
+	done          chan error
+}
+
+func startServer(t *testing.T) *client {
+	t.Helper()
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, conn: newConn(clientIn, clientOut), done: make(chan error, 1)}
+	go func() {
+		err := NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+
+	c.request("initialize", map[string]any{"capabilities": map[string]any{}}, nil)
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// request sends a request and reads messages up to its response, keeping the
+// notifications read along the way. The result is decoded into result.
+func (c *client) request(method string, params any, result any) *ResponseError {
+	c.t.Helper()
+
+	c.nextId++
+	id := json.RawMessage(strings.TrimSpace(string(mustMarshal(c.t, c.nextId))))
+	if err := c.conn.write(&message{Id: &id, Method: method, Params: mustMarshal(c.t, params)}); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		msg, err := c.conn.read()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.Id == nil {
+			c.notifications = append(c.notifications, msg)
+			continue
+		}
+		if string(*msg.Id) != string(id) {
+			c.t.Fatalf("expected a response to %s, got %s", id, *msg.Id)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return nil
+	}
+}
+
+// diagnostics reads the diagnostics published for the document, the server
+// publishes them as soon as the document is opened or changed.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+
+	msg, err := c.conn.read()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got %s", msg.Method)
+	}
+
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func (c *client) open(text string) PublishDiagnosticsParams {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{Uri: schemaUri, LanguageId: "sql", Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+func (c *client) stop() {
+	c.t.Helper()
+	if err := c.request("shutdown", nil, nil); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func mustMarshal(t *testing.T, value any) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// positionOf is the position of the nth occurrence of needle in text.
+func positionOf(text, needle string, nth int) Position {
+	offset := 0
+	for range nth {
+		offset += strings.Index(text[offset:], needle) + 1
+	}
+	offset += strings.Index(text[offset:], needle)
+	before := text[:offset]
+	return Position{
+		Line:      strings.Count(before, "\n"),
+		Character: len(before) - strings.LastIndex(before, "\n") - 1,
+	}
+}
+
+func at(position Position) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{Uri: schemaUri}, Position: position}
+}
+
+func TestInitialize(t *testing.T) {
+	c := startServer(t)
+
+	var result InitializeResult
+	if err := c.request("initialize", map[string]any{}, &result); err != nil {
+		t.Fatal(err)
+	}
+	capabilities := result.Capabilities
+	if capabilities.TextDocumentSync != textDocumentSyncFull || !capabilities.DefinitionProvider ||
+		!capabilities.HoverProvider || !capabilities.DocumentFormattingProvider {
+		t.Errorf("unexpected capabilities %+v", capabilities)
+	}
+
+	if err := c.request("workspace/symbol", map[string]any{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected method not found, got %v", err)
+	}
+
+	c.stop()
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := startServer(t)
+
+	published := c.open(schema)
+	if published.Uri != schemaUri || len(published.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", published)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{Uri: schemaUri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "CREATE TABLE users (id integer PRIMRY KEY);\n"}},
+	})
+	published = c.diagnostics()
+	if len(published.Diagnostics) != 1 {
+		t.Fatalf("expected a parse error, got %+v", published.Diagnostics)
+	}
+	diagnostic := published.Diagnostics[0]
+	if diagnostic.Severity != SeverityError || diagnostic.Range.Start != (Position{Line: 0, Character: 31}) ||
+		diagnostic.Range.End != (Position{Line: 0, Character: 37}) {
+		t.Errorf("unexpected diagnostic %+v", diagnostic)
+	}
+	if !strings.Contains(diagnostic.Message, "did you mean PRIMARY?") {
+		t.Errorf("expected the suggestion in the message, got %q", diagnostic.Message)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{Uri: schemaUri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "CREATE TABLE users (id integer REFERENCES accounts (id));\n"}},
+	})
+	published = c.diagnostics()
+	if len(published.Diagnostics) != 1 || published.Diagnostics[0].Code != "invalid foreign key" {
+		t.Errorf("expected a validation error, got %+v", published.Diagnostics)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{Uri: schemaUri}})
+	if published = c.diagnostics(); len(published.Diagnostics) != 0 {
+		t.Errorf("expected the diagnostics to be cleared, got %+v", published.Diagnostics)
+	}
+
+	c.stop()
+}
+
+func TestDefinition(t *testing.T) {
+	c := startServer(t)
+	c.open(schema)
+
+	cases := []struct {
+		name       string
+		position   Position
+		definition Position
+	}{
+		{name: "referenced table", position: positionOf(schema, "accounts", 1), definition: positionOf(schema, "accounts", 0)},
+		{name: "referenced column", position: positionOf(schema, "id)", 0), definition: positionOf(schema, "id", 0)},
+		{name: "indexed table", position: positionOf(schema, "users (", 1), definition: positionOf(schema, "users", 0)},
+		{name: "indexed column", position: positionOf(schema, "account_id)", 0), definition: positionOf(schema, "account_id", 0)},
+	}
+
+	for _, tc := range cases {
+		var location *Location
+		if err := c.request("textDocument/definition", at(tc.position), &location); err != nil {
+			t.Fatal(err)
+		}
+		if location == nil || location.Uri != schemaUri || location.Range.Start != tc.definition {
+			t.Errorf("%s: expected the definition at %+v, got %+v", tc.name, tc.definition, location)
+		}
+	}
+
+	var location *Location
+	if err := c.request("textDocument/definition", at(positionOf(schema, "PRIMARY", 0)), &location); err != nil {
+		t.Fatal(err)
+	}
+	if location != nil {
+		t.Errorf("expected no definition for a keyword, got %+v", location)
+	}
+
+	c.stop()
+}
+
+func TestHover(t *testing.T) {
+	c := startServer(t)
+	c.open(schema)
+
+	var hover *Hover
+	if err := c.request("textDocument/hover", at(positionOf(schema, "email", 0)), &hover); err != nil {
+		t.Fatal(err)
+	}
+	if hover == nil || !strings.Contains(hover.Contents.Value, "email text NOT NULL UNIQUE") ||
+		!strings.Contains(hover.Contents.Value, "column of table `accounts`") {
+		t.Errorf("unexpected hover %+v", hover)
+	}
+
+	c.stop()
+}
+
+func TestCompletion(t *testing.T) {
+	c := startServer(t)
+
+	text := schema + "CREATE TABLE logins (user_id integer REFERENCES users (a"
+	c.open(text)
+
+	labels := func(position Position) []string {
+		var list CompletionList
+		if err := c.request("textDocument/completion", at(position), &list); err != nil {
+			t.Fatal(err)
+		}
+		labels := []string{}
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	end := positionOf(text, "(a", 0)
+	end.Character += 2
+	if got := labels(end); !slices.Equal(got, []string{"id", "account_id"}) {
+		t.Errorf("expected the columns of users, got %v", got)
+	}
+
+	afterReferences := positionOf(text, "REFERENCES users", 1)
+	afterReferences.Character += len("REFERENCES ")
+	if got := labels(afterReferences); !slices.Equal(got, []string{"accounts", "users"}) {
+		t.Errorf("expected the tables, got %v", got)
+	}
+
+	c.stop()
+}
+
+func TestFormatting(t *testing.T) {
+	c := startServer(t)
+	c.open("create table users(id integer primary key,email text);")
+
+	params := DocumentFormattingParams{TextDocument: TextDocumentIdentifier{Uri: schemaUri}}
+
+	var edits []TextEdit
+	if err := c.request("textDocument/formatting", params, &edits); err != nil {
+		t.Fatal(err)
+	}
+	expected := "CREATE TABLE \"users\" (\n    \"id\" integer PRIMARY KEY,\n    \"email\" text\n);\n"
+	if len(edits) != 1 || edits[0].NewText != expected {
+		t.Fatalf("expected %q, got %+v", expected, edits)
+	}
+	if edits[0].Range.End != (Position{Line: 0, Character: 54}) {
+		t.Errorf("expected the edit to replace the document, got %+v", edits[0].Range)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{Uri: schemaUri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "-- the users\n" + expected}},
+	})
+	c.diagnostics()
+	if err := c.request("textDocument/formatting", params, &edits); err != nil || len(edits) != 0 {
+		t.Errorf("expected a formatted document with comments to be left alone, got %+v %v", edits, err)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{Uri: schemaUri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "create table users(id integer primary key;"}},
+	})
+	c.diagnostics()
+	if err := c.request("textDocument/formatting", params, &edits); err == nil || err.Message != ErrFormatSyntaxErrors.Error() {
+		t.Errorf("expected formatting to refuse a document with syntax errors, got %v", err)
+	}
+
+	c.stop()
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := startServer(t)
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Errorf("expected %v, got %v", ErrExitWithoutShutdown, err)
+	}
+}
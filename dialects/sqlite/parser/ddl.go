@@ -1,17 +1,34 @@
 package parser
 
 import (
-	"fmt"
+	"strings"
 	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/parser"
 	"woodybriggs/justmigrate/frontend/report"
 	"woodybriggs/justmigrate/frontend/token"
 )
 
+var createKeywords = []token.TokenKind{
+	token.TokenKind_Keyword_TABLE,
+	token.TokenKind_Keyword_VIEW,
+	token.TokenKind_Keyword_TRIGGER,
+	token.TokenKind_Keyword_INDEX,
+	token.TokenKind_Keyword_UNIQUE,
+	token.TokenKind_Keyword_VIRTUAL,
+	token.TokenKind_Keyword_TEMPORARY,
+}
+
 func (p *SqliteParser) CreateStatement() ast.Statement {
 	p.PushParseContext("create statement")
 	defer p.PopParseContext()
 
-	switch p.Peeked().Kind {
+	// a misspelled object type is reported when the statement expects it
+	kind := p.Peeked().Kind
+	if misspelled, ok := parser.MisspelledKeyword(p.Peeked(), createKeywords...); ok {
+		kind = misspelled
+	}
+
+	switch kind {
 	case token.TokenKind_Keyword_TABLE:
 		return p.CreateTableStatement(false)
 	case token.TokenKind_Keyword_VIEW:
@@ -37,7 +54,7 @@ func (p *SqliteParser) CreateStatement() ast.Statement {
 				Note:   "unknown token for create statement",
 			},
 			)
-		p.ReportError(err)
+		p.ReportError(parser.WithKeywordSuggestion(err, p.Peeked()))
 		return nil
 	}
 }
@@ -60,6 +77,21 @@ func (p *SqliteParser) CreateTableStatement(isTemporary bool) *ast.CreateTable {
 
 	tableIdent := p.CatalogObjectIdentifier()
 
+	if p.Current().Kind == token.TokenKind_Keyword_AS {
+		createTable := ast.MakeCreateTable(
+			createKeyword,
+			temporaryKeyword,
+			tableKeyword,
+			ifnotexists,
+			tableIdent,
+			ast.MakeTableDefinition(token.Token{}, nil, nil, token.Token{}),
+			nil,
+		)
+		createTable.AsKeyword = ast.MakeKeyword(p.Expect(token.TokenKind_Keyword_AS))
+		createTable.AsSelect = p.SelectStatement()
+		return createTable
+	}
+
 	tableDefinition := p.TableDefinition()
 
 	tableOptions := p.MaybeTableOptions()
@@ -75,12 +107,232 @@ func (p *SqliteParser) CreateTableStatement(isTemporary bool) *ast.CreateTable {
 	)
 }
 
-func (p *SqliteParser) CreateViewStatement(false bool) ast.Statement {
-	panic(fmt.Errorf("%w: CreateViewStatement", ErrNotImplemented))
+func (p *SqliteParser) CreateViewStatement(isTemporary bool) ast.Statement {
+	p.PushParseContext("create view statement")
+	defer p.PopParseContext()
+
+	var temporaryKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_CREATE))
+
+	if isTemporary {
+		temporaryKeyword = ast.MakeKeyword(p.Expect(token.TokenKind_Keyword_TEMPORARY))
+	}
+
+	viewKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_VIEW))
+
+	ifNotExists := p.MaybeIfNotExists()
+
+	viewIdent := p.CatalogObjectIdentifier()
+
+	columns := []ast.Identifier{}
+	if p.Current().Kind == '(' {
+		p.Advance()
+		for !p.EndOfFile() {
+			if p.Current().Kind == ')' {
+				break
+			} else if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else {
+				columns = append(columns, p.Identifier())
+			}
+		}
+		p.Expect(')')
+	}
+
+	asKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_AS))
+
+	selectStatement := p.SelectStatement()
+
+	return ast.MakeCreateView(
+		createKeyword,
+		temporaryKeyword,
+		viewKeyword,
+		ifNotExists,
+		viewIdent,
+		columns,
+		asKeyword,
+		selectStatement,
+	)
+}
+
+// SelectStatement keeps the select verbatim, it consumes every token up to the
+// semi-colon that ends the statement.
+func (p *SqliteParser) SelectStatement() *ast.Select {
+	p.PushParseContext("select statement")
+	defer p.PopParseContext()
+
+	return ast.MakeSelect(p.statementTokens())
+}
+
+// statementTokens consumes every token up to the semi-colon that ends the
+// statement, which is not consumed.
+func (p *SqliteParser) statementTokens() []token.Token {
+	tokens := []token.Token{}
+	depth := 0
+	for !p.EndOfFile() {
+		switch p.Current().Kind {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ';':
+			if depth <= 0 {
+				return tokens
+			}
+		}
+		tokens = append(tokens, p.Current())
+		p.Advance()
+	}
+
+	return tokens
+}
+
+func (p *SqliteParser) CreateTriggerStatement(isTemporary bool) ast.Statement {
+	p.PushParseContext("create trigger statement")
+	defer p.PopParseContext()
+
+	var temporaryKeyword *ast.Keyword = nil
+
+	createKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_CREATE))
+
+	if isTemporary {
+		temporaryKeyword = ast.MakeKeyword(p.Expect(token.TokenKind_Keyword_TEMPORARY))
+	}
+
+	triggerKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_TRIGGER))
+
+	ifNotExists := p.MaybeIfNotExists()
+
+	triggerIdent := p.CatalogObjectIdentifier()
+
+	triggerTime := p.MaybeTriggerTime()
+
+	triggerEvent := p.TriggerEvent()
+
+	onKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_ON))
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	body := p.TriggerBody()
+
+	return ast.MakeCreateTrigger(
+		createKeyword,
+		temporaryKeyword,
+		triggerKeyword,
+		ifNotExists,
+		triggerIdent,
+		triggerTime,
+		triggerEvent,
+		onKeyword,
+		tableIdent,
+		body,
+	)
+}
+
+// isWord reports whether the current token is the unquoted word, sqlite accepts
+// most of its keywords as identifiers so the less common ones are matched by text.
+func (p *SqliteParser) isWord(word string) bool {
+	return isWordToken(p.Current(), word)
 }
 
-func (p *SqliteParser) CreateTriggerStatement(false bool) ast.Statement {
-	panic(fmt.Errorf("%w: CreateTriggerStatement", ErrNotImplemented))
+func isWordToken(tok token.Token, word string) bool {
+	return tok.Kind == token.TokenKind_Identifier &&
+		tok.OpenQuote == 0 &&
+		strings.EqualFold(tok.Text, word)
+}
+
+func (p *SqliteParser) MaybeTriggerTime() ast.TriggerTime {
+	switch {
+	case p.isWord("before"):
+		before := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.TriggerTimeBefore{BeforeKeyword: before}
+	case p.isWord("after"):
+		after := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.TriggerTimeAfter{AfterKeyword: after}
+	case p.isWord("instead"):
+		instead := ast.Keyword(p.Current())
+		p.Advance()
+		if !p.isWord("of") {
+			p.ReportError(report.NewReport("parse error").WithLabels(
+				report.LabelFromToken(p.Current(), "expected OF after INSTEAD"),
+			))
+			return &ast.TriggerTimeInsteadOf{InsteadKeyword: instead}
+		}
+		of := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.TriggerTimeInsteadOf{InsteadKeyword: instead, Of: of}
+	default:
+		return nil
+	}
+}
+
+func (p *SqliteParser) TriggerEvent() ast.TriggerEvent {
+	p.PushParseContext("trigger event")
+	defer p.PopParseContext()
+
+	switch {
+	case p.Current().Kind == token.TokenKind_Keyword_DELETE:
+		deleteKeyword := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.TriggerEventDelete{DeleteKeyword: deleteKeyword}
+	case p.isWord("insert"):
+		insertKeyword := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.TriggerEventInsert{InsertKeyword: insertKeyword}
+	default:
+		updateKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_UPDATE))
+		if !p.isWord("of") {
+			return &ast.TriggerEventUpdate{UpdateKeyword: updateKeyword}
+		}
+		of := ast.Keyword(p.Current())
+		p.Advance()
+
+		columns := []ast.Identifier{p.Identifier()}
+		for p.Current().Kind == ',' {
+			p.Advance()
+			columns = append(columns, p.Identifier())
+		}
+
+		return &ast.TriggerEventUpdateOf{UpdateKeyword: updateKeyword, Of: of, Columns: columns}
+	}
+}
+
+// TriggerBody keeps the rest of the trigger verbatim, it consumes every token
+// up to the END that closes the BEGIN of the trigger.
+func (p *SqliteParser) TriggerBody() []token.Token {
+	p.PushParseContext("trigger body")
+	defer p.PopParseContext()
+
+	tokens := []token.Token{}
+	begun := false
+	cases := 0
+	for !p.EndOfFile() {
+		current := p.Current()
+		tokens = append(tokens, current)
+		p.Advance()
+
+		switch current.Kind {
+		case token.TokenKind_Keyword_BEGIN:
+			begun = true
+		case token.TokenKind_Keyword_CASE:
+			cases++
+		case token.TokenKind_Keyword_END:
+			if cases > 0 {
+				cases--
+			} else if begun {
+				return tokens
+			}
+		}
+	}
+
+	p.ReportError(report.NewReport("parse error").WithLabels(
+		report.LabelFromToken(p.Current(), "expected END to close the trigger body"),
+	))
+	return tokens
 }
 
 func (p *SqliteParser) CreateIndexStatement(isUnique bool) ast.Statement {
@@ -144,11 +396,246 @@ func (p *SqliteParser) CreateIndexStatement(isUnique bool) ast.Statement {
 }
 
 func (p *SqliteParser) CreateVirtualTableStatement() ast.Statement {
-	panic(fmt.Errorf("%w: CreateVirtualTableStatement", ErrNotImplemented))
+	p.PushParseContext("create virtual table statement")
+	defer p.PopParseContext()
+
+	createKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_CREATE))
+	virtualKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_VIRTUAL))
+	tableKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_TABLE))
+
+	ifNotExists := p.MaybeIfNotExists()
+
+	tableIdent := p.CatalogObjectIdentifier()
+
+	usingKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_USING))
+
+	moduleName := p.Identifier()
+
+	moduleArgs := []string{}
+	if p.Current().Kind == '(' {
+		moduleArgs = p.ModuleArguments()
+	}
+
+	return ast.MakeCreateVirtualTable(
+		createKeyword,
+		virtualKeyword,
+		tableKeyword,
+		ifNotExists,
+		tableIdent,
+		usingKeyword,
+		moduleName,
+		moduleArgs,
+	)
+}
+
+// ModuleArguments keeps the comma separated arguments of a module verbatim,
+// an argument may be any sequence of tokens with balanced parentheses.
+func (p *SqliteParser) ModuleArguments() []string {
+	p.PushParseContext("module arguments")
+	defer p.PopParseContext()
+
+	p.Expect('(')
+
+	args := []string{}
+	arg := []token.Token{}
+	depth := 0
+	for !p.EndOfFile() {
+		current := p.Current()
+		switch {
+		case current.Kind == ')' && depth == 0:
+			p.Advance()
+			if len(arg) > 0 {
+				args = append(args, ast.SourceText(arg))
+			}
+			return args
+		case current.Kind == ',' && depth == 0:
+			p.Advance()
+			args = append(args, ast.SourceText(arg))
+			arg = []token.Token{}
+			continue
+		case current.Kind == '(':
+			depth++
+		case current.Kind == ')':
+			depth--
+		}
+		arg = append(arg, current)
+		p.Advance()
+	}
+
+	p.ReportError(report.NewReport("parse error").WithLabels(
+		report.LabelFromToken(p.Current(), "expected ')' to close the module arguments"),
+	))
+	return args
 }
 
+// CreateTemporaryStatement parses CREATE TEMP[ORARY] TABLE, VIEW or TRIGGER,
+// the kind of object follows the TEMPORARY keyword.
 func (p *SqliteParser) CreateTemporaryStatement() ast.Statement {
-	panic(fmt.Errorf("%w: CreateTemporaryStatement", ErrNotImplemented))
+	switch p.PeekAhead(2).Kind {
+	case token.TokenKind_Keyword_TABLE:
+		return p.CreateTableStatement(true)
+	case token.TokenKind_Keyword_VIEW:
+		return p.CreateViewStatement(true)
+	case token.TokenKind_Keyword_TRIGGER:
+		return p.CreateTriggerStatement(true)
+	default:
+		p.ReportError(parser.WithKeywordSuggestion(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(p.PeekAhead(2), "expected TABLE, VIEW or TRIGGER after TEMPORARY"),
+		), p.PeekAhead(2)))
+		return nil
+	}
+}
+
+func (p *SqliteParser) DropStatement() ast.Statement {
+	p.PushParseContext("drop statement")
+	defer p.PopParseContext()
+
+	dropKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_DROP))
+
+	switch p.Current().Kind {
+	case token.TokenKind_Keyword_TABLE:
+		tableKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_TABLE))
+		ifExists := p.MaybeIfExists()
+		return &ast.DropTable{
+			DropKeyword:     dropKeyword,
+			TableKeyword:    tableKeyword,
+			IfExists:        ifExists,
+			TableIdentifier: *p.CatalogObjectIdentifier(),
+		}
+	case token.TokenKind_Keyword_INDEX:
+		indexKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_INDEX))
+		ifExists := p.MaybeIfExists()
+		return &ast.DropIndex{
+			DropKeyword:     dropKeyword,
+			IndexKeyword:    indexKeyword,
+			IfExists:        ifExists,
+			IndexIdentifier: *p.CatalogObjectIdentifier(),
+		}
+	case token.TokenKind_Keyword_VIEW:
+		viewKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_VIEW))
+		ifExists := p.MaybeIfExists()
+		return &ast.DropView{
+			DropKeyword:    dropKeyword,
+			ViewKeyword:    viewKeyword,
+			IfExists:       ifExists,
+			ViewIdentifier: *p.CatalogObjectIdentifier(),
+		}
+	case token.TokenKind_Keyword_TRIGGER:
+		triggerKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_TRIGGER))
+		ifExists := p.MaybeIfExists()
+		return &ast.DropTrigger{
+			DropKeyword:       dropKeyword,
+			TriggerKeyword:    triggerKeyword,
+			IfExists:          ifExists,
+			TriggerIdentifier: *p.CatalogObjectIdentifier(),
+		}
+	default:
+		p.ReportError(parser.WithKeywordSuggestion(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(p.Current(), "expected TABLE, INDEX, VIEW or TRIGGER after DROP"),
+		), p.Current()))
+		return nil
+	}
+}
+
+// AlterTableStatement parses every form of ALTER TABLE, renaming the table,
+// renaming, adding or dropping a column.
+func (p *SqliteParser) AlterTableStatement() ast.Statement {
+	p.PushParseContext("alter table statement")
+	defer p.PopParseContext()
+
+	alterKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_ALTER))
+	tableKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_TABLE))
+	tableIdent := p.CatalogObjectIdentifier()
+
+	var alteration ast.TableAlteration
+	switch {
+	case p.isWord("rename"):
+		alteration = p.RenameAlteration()
+	case p.Current().Kind == token.TokenKind_Keyword_ADD:
+		addKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_ADD))
+		columnKeyword := p.maybeWord("column")
+		alteration = &ast.AddColumn{
+			AddKeyword:       addKeyword,
+			ColumnKeyword:    columnKeyword,
+			ColumnDefinition: *p.ColumnDefinition(),
+		}
+	case p.Current().Kind == token.TokenKind_Keyword_DROP:
+		dropKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_DROP))
+		columnKeyword := p.maybeWord("column")
+		alteration = &ast.DropColumn{
+			DropKeyword:   dropKeyword,
+			ColumnKeyword: columnKeyword,
+			ColumnName:    p.Identifier(),
+		}
+	default:
+		p.ReportError(parser.WithKeywordSuggestion(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(p.Current(), "expected RENAME, ADD or DROP after the table"),
+		), p.Current()))
+		return nil
+	}
+
+	return &ast.AlterTable{
+		AlterKeyword:    alterKeyword,
+		TableKeyword:    tableKeyword,
+		TableIdentifier: tableIdent,
+		Alteration:      alteration,
+	}
+}
+
+// RenameAlteration parses RENAME TO table and RENAME [COLUMN] column TO column.
+func (p *SqliteParser) RenameAlteration() ast.TableAlteration {
+	renameKeyword := ast.Keyword(p.Current())
+	p.Advance()
+
+	if p.isWord("to") {
+		toKeyword := ast.Keyword(p.Current())
+		p.Advance()
+		return &ast.RenameTable{
+			RenameKeyword: renameKeyword,
+			ToKeyword:     toKeyword,
+			NewTableName:  p.Identifier(),
+		}
+	}
+
+	columnKeyword := p.maybeWord("column")
+	columnName := p.Identifier()
+
+	if !p.isWord("to") {
+		p.ReportError(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(p.Current(), "expected TO after the renamed column"),
+		))
+		return &ast.RenameColumn{RenameKeyword: renameKeyword, ColumnKeyword: columnKeyword, ColumnName: columnName}
+	}
+	toKeyword := ast.Keyword(p.Current())
+	p.Advance()
+
+	return &ast.RenameColumn{
+		RenameKeyword: renameKeyword,
+		ColumnKeyword: columnKeyword,
+		ColumnName:    columnName,
+		ToKeyword:     toKeyword,
+		NewColumnName: p.Identifier(),
+	}
+}
+
+// maybeWord consumes the current token if it is the unquoted word.
+func (p *SqliteParser) maybeWord(word string) *ast.Keyword {
+	if !p.isWord(word) {
+		return nil
+	}
+	keyword := ast.MakeKeyword(p.Current())
+	p.Advance()
+	return keyword
+}
+
+func (p *SqliteParser) MaybeIfExists() *ast.IfExists {
+	if p.Current().Kind != token.TokenKind_Keyword_IF {
+		return nil
+	}
+	ifKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_IF))
+	existsKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_EXISTS))
+
+	return &ast.IfExists{If: ifKeyword, Exists: existsKeyword}
 }
 
 func (p *SqliteParser) MaybeIfNotExists() *ast.IfNotExists {
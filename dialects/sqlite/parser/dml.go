@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/report"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// DataStatement parses INSERT, REPLACE, UPDATE and DELETE, along with the WITH
+// clause that may precede them. The statement is kept verbatim and only the
+// table it changes is parsed, a WITH clause followed by a SELECT is a select.
+func (p *SqliteParser) DataStatement() ast.Statement {
+	p.PushParseContext("data statement")
+	defer p.PopParseContext()
+
+	start := p.Current()
+	tokens := p.statementTokens()
+
+	verb := dataStatementVerb(tokens)
+	if verb < 0 {
+		p.ReportError(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(start, "expected INSERT, REPLACE, UPDATE, DELETE or SELECT after the WITH clause"),
+		))
+		return nil
+	}
+
+	if tokens[verb].Kind == token.TokenKind_Keyword_SELECT || isWordToken(tokens[verb], "values") {
+		return ast.MakeSelect(tokens)
+	}
+
+	table, ok := dataStatementTable(tokens, verb)
+	if !ok {
+		p.ReportError(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(tokens[verb], "expected the table the statement changes"),
+		))
+		return nil
+	}
+
+	switch {
+	case tokens[verb].Kind == token.TokenKind_Keyword_UPDATE:
+		return &ast.Update{Table: *table, Tokens: tokens}
+	case tokens[verb].Kind == token.TokenKind_Keyword_DELETE:
+		return &ast.Delete{Table: *table, Tokens: tokens}
+	default:
+		return &ast.Insert{Table: *table, Tokens: tokens}
+	}
+}
+
+// dataStatementVerb is the index of the keyword that starts the statement
+// proper, the common table expressions of a WITH clause are parenthesized so
+// it is the first verb outside of any parentheses.
+func dataStatementVerb(tokens []token.Token) int {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.Kind == '(':
+			depth++
+		case tok.Kind == ')':
+			depth--
+		case depth > 0:
+			continue
+		case tok.Kind == token.TokenKind_Keyword_REPLACE,
+			tok.Kind == token.TokenKind_Keyword_UPDATE,
+			tok.Kind == token.TokenKind_Keyword_DELETE,
+			tok.Kind == token.TokenKind_Keyword_SELECT,
+			isWordToken(tok, "insert"),
+			isWordToken(tok, "values"):
+			return i
+		}
+	}
+	return -1
+}
+
+// dataStatementTable is the table following INSERT [OR conflict] INTO,
+// REPLACE INTO, UPDATE [OR conflict] or DELETE FROM.
+func dataStatementTable(tokens []token.Token, verb int) (*ast.CatalogObjectIdentifier, bool) {
+	i := verb + 1
+	if i < len(tokens) && tokens[i].Kind == token.TokenKind_Keyword_OR {
+		i += 2
+	}
+	if i < len(tokens) && (isWordToken(tokens[i], "into") || isWordToken(tokens[i], "from")) {
+		i++
+	}
+
+	name, ok := identifierToken(tokens, i)
+	if !ok {
+		return nil, false
+	}
+
+	if i+2 < len(tokens) && tokens[i+1].Kind == token.TokenKind_Period {
+		schema := name
+		if name, ok = identifierToken(tokens, i+2); !ok {
+			return nil, false
+		}
+		return ast.MakeCatalogObjectIdentifier(&schema, name), true
+	}
+
+	return ast.MakeCatalogObjectIdentifier(nil, name), true
+}
+
+// identifierToken is the identifier at i, the temp schema is named by the
+// TEMP keyword.
+func identifierToken(tokens []token.Token, i int) (ast.Identifier, bool) {
+	if i >= len(tokens) {
+		return ast.Identifier{}, false
+	}
+
+	switch tokens[i].Kind {
+	case token.TokenKind_Identifier:
+		return ast.Identifier(tokens[i]), true
+	case token.TokenKind_Keyword_TEMPORARY:
+		ident := ast.Identifier(tokens[i])
+		ident.Kind = token.TokenKind_Identifier
+		return ident, true
+	default:
+		return ast.Identifier{}, false
+	}
+}
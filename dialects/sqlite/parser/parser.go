@@ -11,10 +11,17 @@ import (
 	"woodybriggs/justmigrate/frontend/token"
 )
 
-var ErrNotImplemented = errors.New("not implemented")
+var (
+	ErrNotImplemented = errors.New("not implemented")
+	// ErrInvalidStatement is the error of a statement that failed to parse,
+	// the reasons are reported by the parser.
+	ErrInvalidStatement = errors.New("invalid statement")
+)
 
 type SqliteParser struct {
 	*parser.Parser
+
+	statementRanges []token.TextRange
 }
 
 func NewSqliteParser(lexer *lexer.Lexer) *SqliteParser {
@@ -49,7 +56,7 @@ func (p *SqliteParser) TableConstraints() []ast.TableConstraint {
 	result := []ast.TableConstraint{}
 
 	for !p.EndOfFile() {
-		if p.Current().Kind == ')' {
+		if p.Current().Kind == ')' || p.Current().Kind == ';' {
 			break
 		} else if p.Current().Kind == ',' {
 			p.Advance()
@@ -84,9 +91,13 @@ func (p *SqliteParser) TableConstraint() ast.TableConstraint {
 		)
 	}
 
+	p.RepairKeyword(tableConstraintKeywords...)
+
 	switch p.Current().Kind {
 	case token.TokenKind_Keyword_PRIMARY:
 		return p.TableConstraint_PrimaryKey(constraintName)
+	case token.TokenKind_Keyword_UNIQUE:
+		return p.TableConstraint_Unique(constraintName)
 	case token.TokenKind_Keyword_FOREIGN:
 		return p.TableConstraint_ForeignKey(constraintName)
 	case token.TokenKind_Keyword_CHECK:
@@ -96,7 +107,7 @@ func (p *SqliteParser) TableConstraint() ast.TableConstraint {
 			NewReport("parse error").
 			WithLocation(p.Current().FileLoc).
 			WithLabels(report.LabelFromToken(p.Current(), "unexpected token for table constraint"))
-		p.ReportError(err)
+		p.ReportError(parser.WithKeywordSuggestion(err, p.Current()))
 		return nil
 	}
 }
@@ -152,6 +163,40 @@ func (p *SqliteParser) TableConstraint_PrimaryKey(constraintName *ast.Constraint
 	)
 }
 
+func (p *SqliteParser) TableConstraint_Unique(constraintName *ast.ConstraintName) ast.TableConstraint {
+	p.PushParseContext("unique table constraint")
+	defer p.PopParseContext()
+
+	uniqueKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_UNIQUE))
+	lParen := p.Expect('(')
+
+	indexedCols := []ast.IndexedColumn{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ')' {
+			break
+		} else if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else {
+			indexedCol := p.IndexedColumn(false)
+			indexedCols = append(indexedCols, indexedCol)
+		}
+	}
+
+	rParen := p.Expect(')')
+
+	conflictClause := p.MaybeConflictClause()
+
+	return ast.MakeTableConstraintUnique(
+		constraintName,
+		uniqueKeyword,
+		lParen,
+		indexedCols,
+		rParen,
+		conflictClause,
+	)
+}
+
 func (p *SqliteParser) IndexedColumn(allowExpressions bool) ast.IndexedColumn {
 
 	p.PushParseContext("indexed column")
@@ -218,23 +263,27 @@ func (p *SqliteParser) ForeignKeyClause() *ast.ForeignKeyClause {
 
 	foreignTable := p.CatalogObjectIdentifier()
 
-	lParen := p.Expect('(')
-
+	// the referenced columns are optional, without them the key references
+	// the primary key of the foreign table
+	var lParen, rParen token.Token
 	columns := []ast.Identifier{}
+	if p.Current().Kind == '(' {
+		lParen = p.Expect('(')
 
-	for !p.EndOfFile() {
-		if p.Current().Kind == ',' {
-			p.Advance()
-			continue
-		} else if p.Current().Kind == ')' {
-			break
-		} else {
-			column := p.Identifier()
-			columns = append(columns, column)
+		for !p.EndOfFile() {
+			if p.Current().Kind == ',' {
+				p.Advance()
+				continue
+			} else if p.Current().Kind == ')' {
+				break
+			} else {
+				column := p.Identifier()
+				columns = append(columns, column)
+			}
 		}
-	}
 
-	rParen := p.Expect(')')
+		rParen = p.Expect(')')
+	}
 
 	var deferrable *ast.ForeignKeyDeferrable = nil
 	var matchName *ast.Identifier = nil
@@ -245,6 +294,7 @@ func (p *SqliteParser) ForeignKeyClause() *ast.ForeignKeyClause {
 			action := p.ForeignKeyAction()
 			actions = append(actions, action)
 		} else if p.Current().Kind == token.TokenKind_Keyword_MATCH {
+			p.Advance()
 			ident := p.Identifier()
 			matchName = &ident
 		} else if p.Current().Kind == token.TokenKind_Keyword_NOT {
@@ -401,10 +451,6 @@ func (p *SqliteParser) TableConstraint_Check(constraintName *ast.ConstraintName)
 	)
 }
 
-func (p *SqliteParser) TableOptions() *ast.TableOptions {
-	panic("unimplemented")
-}
-
 func (p *SqliteParser) ColumnDefinitions() []ast.ColumnDefinition {
 	p.PushParseContext("column definitions")
 	defer p.PopParseContext()
@@ -412,12 +458,16 @@ func (p *SqliteParser) ColumnDefinitions() []ast.ColumnDefinition {
 	definitions := []ast.ColumnDefinition{}
 
 	for !p.EndOfFile() {
-		if p.Current().Kind == ')' {
+		if p.Current().Kind == ')' || p.Current().Kind == ';' {
 			break
 		} else if isTableConstraintStartingToken(p.Current()) {
 			break
 		} else if p.Current().Kind == ',' {
 			p.Advance()
+		} else if isColumnDefinitionEnd(p.Peeked()) && p.RepairKeyword(tableConstraintKeywords...) {
+			// a column name is never followed by KEY or '(', it is a
+			// misspelled table constraint
+			break
 		} else {
 			columnDef := p.ColumnDefinition()
 			definitions = append(definitions, *columnDef)
@@ -488,12 +538,7 @@ func (p *SqliteParser) LiteralNumericLiteral(negate *token.Token) ast.NumericLit
 		p.Advance()
 		val, err := strconv.ParseInt(tok.Text, 10, 64)
 		if err != nil {
-			p.Parser.ReportError(
-				report.NewReport("parse error").
-					WithLocation(p.Current().FileLoc).
-					WithLabels(report.LabelFromToken(p.Current(), "here")).
-					WithNotes(err.Error()),
-			)
+			p.Parser.ReportError(invalidLiteral(tok, err))
 			return ast.MakeLiteralSignedInteger(tok, 0)
 		}
 		if negate != nil {
@@ -504,11 +549,7 @@ func (p *SqliteParser) LiteralNumericLiteral(negate *token.Token) ast.NumericLit
 		p.Advance()
 		val, err := strconv.ParseFloat(tok.Text, 64)
 		if err != nil {
-			p.Parser.ReportError(report.NewReport("parse error").
-				WithLocation(p.Current().FileLoc).
-				WithNotes(err.Error()).
-				WithLabels(report.LabelFromToken(p.Current(), "here")),
-			)
+			p.Parser.ReportError(invalidLiteral(tok, err))
 			return ast.MakeLiteralFloat(tok, 0)
 		}
 		if negate != nil {
@@ -533,6 +574,32 @@ func (p *SqliteParser) LiteralNumericLiteral(negate *token.Token) ast.NumericLit
 	}
 }
 
+func isNumericLiteral(tok token.Token) bool {
+	switch tok.Kind {
+	case token.TokenKind_IntegerNumericLiteral,
+		token.TokenKind_FloatNumericLiteral,
+		token.TokenKind_HexNumericLiteral,
+		token.TokenKind_BinaryNumericLiteral,
+		token.TokenKind_OctalNumericLiteral:
+		return true
+	default:
+		return false
+	}
+}
+
+// invalidLiteral reports a literal whose value can not be read, err is the
+// conversion error which is not shown as it is.
+func invalidLiteral(tok token.Token, err error) *report.Report {
+	rep := report.NewReport("parse error").
+		WithLocation(tok.FileLoc).
+		WithLabels(report.LabelFromToken(tok, "here")).
+		WithMessage(fmt.Sprintf("invalid numeric literal '%s'", tok.Text))
+	if errors.Is(err, strconv.ErrRange) {
+		rep = rep.WithNotes("the value does not fit in 64 bits")
+	}
+	return rep
+}
+
 func (p *SqliteParser) ColumnConstraints() []ast.ColumnConstraint {
 
 	p.PushParseContext("column constraints")
@@ -540,7 +607,10 @@ func (p *SqliteParser) ColumnConstraints() []ast.ColumnConstraint {
 
 	result := []ast.ColumnConstraint{}
 
-	for p.Current().Kind != ',' && p.Current().Kind != ')' && !p.EndOfFile() {
+	for p.Current().Kind != ',' && p.Current().Kind != ')' && p.Current().Kind != ';' && !p.EndOfFile() {
+		if p.isMissingColumnSeparator() {
+			break
+		}
 		columnConstraint := p.ColumnConstraint()
 		result = append(result, columnConstraint)
 	}
@@ -548,6 +618,60 @@ func (p *SqliteParser) ColumnConstraints() []ast.ColumnConstraint {
 	return result
 }
 
+var tableConstraintKeywords = []token.TokenKind{
+	token.TokenKind_Keyword_PRIMARY,
+	token.TokenKind_Keyword_FOREIGN,
+	token.TokenKind_Keyword_UNIQUE,
+	token.TokenKind_Keyword_CHECK,
+}
+
+var columnConstraintKeywords = []token.TokenKind{
+	token.TokenKind_Keyword_CONSTRAINT,
+	token.TokenKind_Keyword_PRIMARY,
+	token.TokenKind_Keyword_REFERENCES,
+	token.TokenKind_Keyword_NOT,
+	token.TokenKind_Keyword_DEFAULT,
+	token.TokenKind_Keyword_UNIQUE,
+	token.TokenKind_Keyword_COLLATE,
+	token.TokenKind_Keyword_CHECK,
+	token.TokenKind_Keyword_GENERATED,
+}
+
+func isColumnDefinitionEnd(tok token.Token) bool {
+	return tok.Kind == token.TokenKind_Keyword_KEY || tok.Kind == '('
+}
+
+// isMissingColumnSeparator reports a missing ',' when a name and a type, or a
+// name on its own, follow a column definition where a constraint is expected.
+// The column definitions go on with the next column, a keyword that does not
+// start a constraint is taken as the name of the column.
+func (p *SqliteParser) isMissingColumnSeparator() bool {
+	isName := p.Current().Kind == token.TokenKind_Identifier ||
+		p.Current().Kind.IsKeyword() && !isColumnConstraintStartingToken(p.Current())
+	if !isName {
+		return false
+	}
+	if _, misspelled := parser.MisspelledKeyword(p.Current(), columnConstraintKeywords...); misspelled {
+		return false
+	}
+	switch p.Peeked().Kind {
+	case token.TokenKind_Identifier, ',', ')':
+	default:
+		return false
+	}
+
+	previous := p.Previous()
+	p.ReportError(
+		report.NewReport("parse error").
+			WithLocation(p.Current().FileLoc).
+			WithLabels(
+				report.LabelFromToken(p.Current(), "expected ',' before the next column definition"),
+				report.InsertionLabel(previous, previous.SourceRange.End, ",", "insert ','"),
+			),
+	)
+	return true
+}
+
 func isTableConstraintStartingToken(tok token.Token) bool {
 	switch tok.Kind {
 	case token.TokenKind_Keyword_CONSTRAINT:
@@ -585,6 +709,8 @@ func isColumnConstraintStartingToken(tok token.Token) bool {
 		return true
 	case token.TokenKind_Keyword_GENERATED:
 		return true
+	case token.TokenKind_Keyword_REFERENCES:
+		return true
 	default:
 		return false
 	}
@@ -594,7 +720,9 @@ func (p *SqliteParser) ColumnConstraint() ast.ColumnConstraint {
 	p.PushParseContext("column constraint")
 	defer p.PopParseContext()
 
+	p.RepairKeyword(columnConstraintKeywords...)
 	constraintName := p.MaybeConstraintName()
+	p.RepairKeyword(columnConstraintKeywords...)
 
 	switch p.Current().Kind {
 	case token.TokenKind_Keyword_PRIMARY:
@@ -605,38 +733,41 @@ func (p *SqliteParser) ColumnConstraint() ast.ColumnConstraint {
 		return p.ColumnConstraint_NotNull(constraintName)
 	case token.TokenKind_Keyword_DEFAULT:
 		return p.ColumnConstraint_Default(constraintName)
-		//	case token.TokenKind_Keyword_UNIQUE:
-		//		return p.ColumnConstraint_Unique(constraintName)
-		//	case token.TokenKind_Keyword_COLLATE:
-		//		return p.ColumnConstraint_Collate(constraintName)
+	case token.TokenKind_Keyword_UNIQUE:
+		return p.ColumnConstraint_Unique(constraintName)
+	case token.TokenKind_Keyword_COLLATE:
+		return p.ColumnConstraint_Collate(constraintName)
 	case token.TokenKind_Keyword_CHECK:
 		return p.ColumnConstraint_Check(constraintName)
-		//	case token.TokenKind_Keyword_AS:
-		//		return p.ColumnConstraint_Generated(constraintName)
-		//	case token.TokenKind_Keyword_GENERATED:
-		//		return p.ColumnConstraint_Generated(constraintName)
+	case token.TokenKind_Keyword_AS:
+		return p.ColumnConstraint_Generated(constraintName)
+	case token.TokenKind_Keyword_GENERATED:
+		return p.ColumnConstraint_Generated(constraintName)
 	default:
 		{
-			err :=
+			tok := p.Current()
+			err := parser.WithKeywordSuggestion(
 				report.
 					NewReport("parse error").
-					WithLocation(p.Current().FileLoc).
-					WithLabels(report.LabelFromToken(p.Current(), "here")).
-					WithNotes("unexpected token at start of column constraint")
+					WithLocation(tok.FileLoc).
+					WithLabels(report.LabelFromToken(tok, "here")).
+					WithNotes("unexpected token at start of column constraint"),
+				tok,
+			)
 
 			// if the next token is a valid constraint name, then we can skip the current and try parse again.
 			if isColumnConstraintStartingToken(p.Peeked()) {
 				err.WithNotes("faliure token will be skipped")
+				p.ReportError(err)
 				p.Advance()
 				return p.ColumnConstraint()
 			}
 
-			// otherwise we need to pretend that we got something, check NULL constraint seems to be safest.
-			return ast.MakeColumnConstraintCheck(
-				constraintName,
-				ast.Keyword{Text: "CHECK"},
-				ast.MakeLiteralNull(token.Token{Text: "NULL"}),
-			)
+			// otherwise the token is skipped on its own, so that the column
+			// constraints always make progress.
+			p.ReportError(err)
+			p.Advance()
+			return ast.MakeParseError(err, tok)
 		}
 	}
 }
@@ -699,11 +830,24 @@ func (p *SqliteParser) ColumnConstraint_Default(constraintName *ast.ConstraintNa
 
 	defaultKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_DEFAULT))
 
-	if p.Current().Kind == '(' {
+	switch p.Current().Kind {
+	case '(':
 		p.Advance()
 		expr := p.Expr(0)
 		p.Expect(')')
 		return ast.MakeColumnConstraintDefault(constraintName, defaultKeyword, expr)
+	case '+', '-':
+		sign := p.Current()
+		p.Advance()
+		if !isNumericLiteral(p.Current()) {
+			p.ReportError(
+				report.NewReport("parse error").
+					WithLocation(p.Current().FileLoc).
+					WithLabels(report.LabelFromToken(p.Current(), "here")).
+					WithMessage(fmt.Sprintf("expected a number after '%s' in DEFAULT column constraint", sign.Text)),
+			)
+		}
+		return ast.MakeColumnConstraintDefault(constraintName, defaultKeyword, ast.MakeUnaryOpExpr(sign, p.Term()))
 	}
 
 	lit, err := ast.TokenToLiteral(p.Current())
@@ -712,6 +856,9 @@ func (p *SqliteParser) ColumnConstraint_Default(constraintName *ast.ConstraintNa
 			WithLocation(p.Current().FileLoc).
 			WithNotes("expected '(expr)' or literal value for DEFAULT column constraint)").
 			WithLabels(report.LabelFromToken(p.Current(), "here"))
+		if isNumericLiteral(p.Current()) {
+			rep = invalidLiteral(p.Current(), err)
+		}
 		p.ReportError(rep)
 
 		// if the next token ahead is the start of a new column constraint or the end of column/table def
@@ -721,6 +868,8 @@ func (p *SqliteParser) ColumnConstraint_Default(constraintName *ast.ConstraintNa
 			p.Advance()
 		}
 		lit = ast.MakeParseError(err, tok)
+	} else {
+		p.Advance()
 	}
 
 	return ast.MakeColumnConstraintDefault(constraintName, defaultKeyword, lit)
@@ -731,10 +880,63 @@ func (p *SqliteParser) ColumnConstraint_Check(constraintName *ast.ConstraintName
 	defer p.PopParseContext()
 
 	checkKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_CHECK))
+	p.Expect('(')
 	expr := p.Expr(0)
+	p.Expect(')')
 	return ast.MakeColumnConstraintCheck(constraintName, checkKeyword, expr)
 }
 
+func (p *SqliteParser) ColumnConstraint_Unique(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Unique {
+	p.PushParseContext("unique column constraint")
+	defer p.PopParseContext()
+
+	uniqueKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_UNIQUE))
+	return ast.MakeColumnConstraintUnique(constraintName, uniqueKeyword)
+}
+
+func (p *SqliteParser) ColumnConstraint_Collate(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Collate {
+	p.PushParseContext("collate column constraint")
+	defer p.PopParseContext()
+
+	collateKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_COLLATE))
+	collationName := p.Identifier()
+	return ast.MakeColumnConstraintCollate(constraintName, collateKeyword, collationName)
+}
+
+func (p *SqliteParser) ColumnConstraint_Generated(constraintName *ast.ConstraintName) *ast.ColumnConstraint_Generated {
+	p.PushParseContext("generated column constraint")
+	defer p.PopParseContext()
+
+	var generatedKeyword *ast.Keyword = nil
+	var alwaysKeyword *ast.Keyword = nil
+	if p.Current().Kind == token.TokenKind_Keyword_GENERATED {
+		generatedKeyword = ast.MakeKeyword(p.Current())
+		p.Advance()
+		alwaysKeyword = ast.MakeKeyword(p.Expect(token.TokenKind_Keyword_ALWAYS))
+	}
+
+	asKeyword := ast.Keyword(p.Expect(token.TokenKind_Keyword_AS))
+
+	p.Expect('(')
+	expr := p.Expr(0)
+	p.Expect(')')
+
+	var storage *ast.Keyword = nil
+	if p.Current().Kind == token.TokenKind_Keyword_STORED || p.Current().Kind == token.TokenKind_Keyword_VIRTUAL {
+		storage = ast.MakeKeyword(p.Current())
+		p.Advance()
+	}
+
+	return ast.MakeColumnConstraintGenerated(
+		constraintName,
+		generatedKeyword,
+		alwaysKeyword,
+		asKeyword,
+		expr,
+		storage,
+	)
+}
+
 func (p *SqliteParser) MaybeConstraintName() *ast.ConstraintName {
 	p.PushParseContext("constraint name")
 	defer p.PopParseContext()
@@ -771,6 +973,7 @@ func (p *SqliteParser) MaybeConflictClause() *ast.ConflictClause {
 		token.TokenKind_Keyword_REPLACE:
 		{
 			actionKeyword := ast.Keyword(p.Current())
+			p.Advance()
 			return ast.MakeConflictClause(
 				onKeyword,
 				conflictKeyword,
@@ -797,7 +1000,9 @@ func (p *SqliteParser) MaybeOrderKeyword() *ast.Keyword {
 	case token.TokenKind_Keyword_ASC:
 		fallthrough
 	case token.TokenKind_Keyword_DESC:
-		return ast.MakeKeyword(p.Current())
+		order := ast.MakeKeyword(p.Current())
+		p.Advance()
+		return order
 	default:
 		return nil
 	}
@@ -812,23 +1017,116 @@ func (p *SqliteParser) Term() ast.Expr {
 	case token.TokenKind_StringLiteral:
 		result := &ast.LiteralString{
 			Token: p.Current(),
-			Value: p.Current().Text,
+			Value: ast.StringLiteralValue(p.Current()),
 		}
 		p.Advance()
 		return result
 	case token.TokenKind_Identifier:
-		result := ast.Identifier(p.Current())
+		if ast.IsCurrentTimeKeyword(p.Current()) {
+			result := ast.MakeLiteralKeyword(p.Current())
+			p.Advance()
+			return result
+		}
+		name := p.Identifier()
+		if p.Current().Kind == '(' {
+			return p.FunctionCall(name)
+		}
+		return &name
+	case token.TokenKind_IntegerNumericLiteral,
+		token.TokenKind_FloatNumericLiteral,
+		token.TokenKind_HexNumericLiteral,
+		token.TokenKind_BinaryNumericLiteral,
+		token.TokenKind_OctalNumericLiteral,
+		token.TokenKind_Keyword_NULL,
+		token.TokenKind_Keyword_TRUE,
+		token.TokenKind_Keyword_FALSE:
+		tok := p.Current()
+		lit, err := ast.TokenToLiteral(tok)
+		if err != nil {
+			p.ReportError(invalidLiteral(tok, err))
+			lit = ast.MakeParseError(err, tok)
+		}
+		p.Advance()
+		return lit
+	case '-', '+', '~':
+		// unary operators bind tighter than any binary operator
+		op := p.Current()
 		p.Advance()
-		return &result
+		return ast.MakeUnaryOpExpr(op, p.Expr(unaryBindingPower))
+	case token.TokenKind_Keyword_NOT:
+		// NOT binds looser than the comparisons it negates
+		op := p.Current()
+		p.Advance()
+		return ast.MakeUnaryOpExpr(op, p.Expr(notBindingPower))
+	case '(':
+		// parenthesized expressions are kept as a single element list so
+		// that they are written back with their parentheses
+		p.Advance()
+		expr := p.Expr(0)
+		p.Expect(')')
+		return ast.ExprList{expr}
 	default:
-		panic("expression type not handled")
+		tok := p.Current()
+		err := fmt.Errorf("expected an expression got '%s'", tok.DebugString())
+		p.ReportError(
+			report.NewReport("parse error").
+				WithLocation(tok.FileLoc).
+				WithLabels(report.LabelFromToken(tok, err.Error())),
+		)
+		return ast.MakeParseError(err, tok)
+	}
+}
+
+func (p *SqliteParser) FunctionCall(name ast.Identifier) *ast.FunctionCall {
+	p.PushParseContext("function call")
+	defer p.PopParseContext()
+
+	p.Expect('(')
+
+	args := ast.ExprList{}
+	for !p.EndOfFile() {
+		if p.Current().Kind == ')' {
+			break
+		} else if p.Current().Kind == ',' {
+			p.Advance()
+			continue
+		} else {
+			args = append(args, p.Expr(0))
+		}
+	}
+
+	p.Expect(')')
+
+	return &ast.FunctionCall{
+		Name: name,
+		Args: args,
 	}
 }
 
+const (
+	// notBindingPower is above AND and below the comparisons
+	notBindingPower = 30
+	// unaryBindingPower is above every binary operator
+	unaryBindingPower = 130
+)
+
 func (p *SqliteParser) OperatorBindingPower(tok token.Token) (bp ast.BindingPower, found bool) {
 	switch tok.Kind {
-	case '+':
+	case token.TokenKind_Keyword_OR:
+		return ast.BindingPower{L: 10, R: 11}, true
+	case token.TokenKind_Keyword_AND:
+		return ast.BindingPower{L: 20, R: 21}, true
+	case '=', token.TokenKind_neq, token.TokenKind_Keyword_IS,
+		token.TokenKind_Keyword_LIKE, token.TokenKind_Keyword_GLOB:
+		return ast.BindingPower{L: 40, R: 41}, true
+	case token.TokenKind_lt, token.TokenKind_gt, token.TokenKind_lte, token.TokenKind_gte:
+		return ast.BindingPower{L: 50, R: 51}, true
+	case '+', '-':
 		return ast.BindingPower{L: 100, R: 101}, true
+	case '*', '/', '%':
+		return ast.BindingPower{L: 110, R: 111}, true
+	case token.TokenKind_concat:
+		return ast.BindingPower{L: 120, R: 121}, true
 	default:
 		return ast.BindingPower{}, false
 	}
@@ -3,11 +3,13 @@ package parser
 import (
 	"fmt"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"woodybriggs/justmigrate/backend/formatter"
 	"woodybriggs/justmigrate/frontend/ast"
 	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
 	"woodybriggs/justmigrate/frontend/token"
 )
 
@@ -53,7 +55,8 @@ func TestCreateTable(t *testing.T) {
 					},
 					ColumnConstraints: []ast.ColumnConstraint{
 						&ast.ColumnConstraint_PrimaryKey{
-							AutoIncrement: &ast.Keyword{Kind: token.TokenKind_Keyword_AUTOINCREMENT},
+							// keywords are equal by their text, the expectation has to spell it
+							AutoIncrement: &ast.Keyword{Kind: token.TokenKind_Keyword_AUTOINCREMENT, Text: "AUTOINCREMENT"},
 						},
 					},
 				},
@@ -66,8 +69,95 @@ func TestCreateTable(t *testing.T) {
 	}
 }
 
+func TestIndexedColumnCollationAndOrder(t *testing.T) {
+	parser := makeParser("CREATE INDEX users_name ON users (name COLLATE NOCASE DESC, id ASC)")
+
+	stmt, ok := parser.Statement().(*ast.CreateIndex)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok || len(stmt.IndexedColumns) != 2 {
+		t.Fatalf("expected an index on two columns, got %+v", stmt)
+	}
+
+	name, id := stmt.IndexedColumns[0], stmt.IndexedColumns[1]
+	if name.Collation == nil || name.Collation.Name.Text != "NOCASE" {
+		t.Errorf("expected the NOCASE collation, got %+v", name.Collation)
+	}
+	if name.Order == nil || name.Order.Kind != token.TokenKind_Keyword_DESC {
+		t.Errorf("expected DESC, got %+v", name.Order)
+	}
+	if id.Collation != nil || id.Order == nil || id.Order.Kind != token.TokenKind_Keyword_ASC {
+		t.Errorf("expected ASC without a collation, got %+v", id)
+	}
+}
+
+func TestColumnConstraintsAfterDefaultAndOrder(t *testing.T) {
+	parser := makeParser("CREATE TABLE t (id integer PRIMARY KEY DESC NOT NULL, n integer DEFAULT 0 NOT NULL, s text DEFAULT 'x' UNIQUE)")
+
+	stmt, ok := parser.Statement().(*ast.CreateTable)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok || len(stmt.TableDefinition.ColumnDefinitions) != 3 {
+		t.Fatalf("expected a table with three columns, got %+v", stmt)
+	}
+
+	expected := [][]string{
+		{"*ast.ColumnConstraint_PrimaryKey", "*ast.ColumnConstraint_NotNull"},
+		{"*ast.ColumnConstraint_Default", "*ast.ColumnConstraint_NotNull"},
+		{"*ast.ColumnConstraint_Default", "*ast.ColumnConstraint_Unique"},
+	}
+	for i, column := range stmt.TableDefinition.ColumnDefinitions {
+		var kinds []string
+		for _, constraint := range column.ColumnConstraints {
+			kinds = append(kinds, fmt.Sprintf("%T", constraint))
+		}
+		if !slices.Equal(kinds, expected[i]) {
+			t.Errorf("column %s: expected %v, got %v", column.ColumnName.Text, expected[i], kinds)
+		}
+	}
+
+	def := stmt.TableDefinition.ColumnDefinitions[2].ColumnConstraints[0].(*ast.ColumnConstraint_Default)
+	if lit, ok := def.Default.(*ast.LiteralString); !ok || lit.Value != "x" {
+		t.Errorf("expected the string literal x, got %+v", def.Default)
+	}
+}
+
+func TestTableUniqueConstraint(t *testing.T) {
+	parser := makeParser("CREATE TABLE t (a text, b text, CONSTRAINT t_ab UNIQUE (a COLLATE NOCASE, b DESC) ON CONFLICT REPLACE)")
+
+	stmt, ok := parser.Statement().(*ast.CreateTable)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok || len(stmt.TableDefinition.TableConstraints) != 1 {
+		t.Fatalf("expected a table with one table constraint, got %+v", stmt)
+	}
+
+	unique, ok := stmt.TableDefinition.TableConstraints[0].(*ast.TableConstraint_Unique)
+	if !ok {
+		t.Fatalf("expected a unique constraint, got %T", stmt.TableDefinition.TableConstraints[0])
+	}
+	if unique.Name == nil || unique.Name.Name.Text != "t_ab" {
+		t.Errorf("expected the constraint name t_ab, got %+v", unique.Name)
+	}
+	if len(unique.IndexedColumns) != 2 {
+		t.Fatalf("expected two columns, got %+v", unique.IndexedColumns)
+	}
+	if a := unique.IndexedColumns[0]; a.Collation == nil || a.Collation.Name.Text != "NOCASE" {
+		t.Errorf("expected the NOCASE collation, got %+v", a.Collation)
+	}
+	if b := unique.IndexedColumns[1]; b.Order == nil || b.Order.Kind != token.TokenKind_Keyword_DESC {
+		t.Errorf("expected DESC, got %+v", b.Order)
+	}
+	if unique.ConflictClause == nil {
+		t.Error("expected the conflict clause")
+	}
+}
+
 func TestParseIdentifier(t *testing.T) {
-	parser := makeParser("user_id [user_id] `user_id` \"user_id\"")
+	parser := makeParser("user_id [user_id] `user_id` \"user_id\" 'user_id'")
 
 	for !parser.EndOfFile() {
 		ident := parser.Identifier()
@@ -76,3 +166,443 @@ func TestParseIdentifier(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateVirtualTable(t *testing.T) {
+	parser := makeParser("CREATE VIRTUAL TABLE IF NOT EXISTS aux.docs USING fts5(title, body UNINDEXED, tokenize = 'porter unicode61', prefix = '2 3', content=(x))")
+
+	stmt, ok := parser.Statement().(*ast.CreateVirtualTable)
+	if !ok {
+		t.Fatalf("expected a virtual table, got %T", stmt)
+	}
+
+	if stmt.TableIdentifier.Canonical() != "aux.docs" || stmt.ModuleName.Text != "fts5" || stmt.IfNotExist == nil {
+		t.Errorf("unexpected virtual table %+v", stmt)
+	}
+
+	expectedArgs := []string{"title", "body UNINDEXED", "tokenize = 'porter unicode61'", "prefix = '2 3'", "content=(x)"}
+	if fmt.Sprint(stmt.ModuleArgs) != fmt.Sprint(expectedArgs) {
+		t.Errorf("expected arguments %q, got %q", expectedArgs, stmt.ModuleArgs)
+	}
+}
+
+func TestStringLiteralTableName(t *testing.T) {
+	parser := makeParser("CREATE TABLE 'docs_data'(id INTEGER PRIMARY KEY, block BLOB);")
+
+	stmt, ok := parser.Statement().(*ast.CreateTable)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok {
+		t.Fatalf("expected a table, got %+v", stmt)
+	}
+
+	if stmt.TableIdentifier.Canonical() != "docs_data" {
+		t.Errorf("expected the table docs_data, got %s", stmt.TableIdentifier.Canonical())
+	}
+}
+
+func TestCreateTemporaryStatement(t *testing.T) {
+	parser := makeParser(`
+		CREATE TEMP TABLE scratch (id integer);
+		CREATE TEMPORARY VIEW ids AS SELECT id FROM scratch;
+		CREATE TEMP TRIGGER IF NOT EXISTS log AFTER INSERT ON scratch BEGIN SELECT 1; END;
+		CREATE INDEX temp.scratch_id ON scratch (id);`)
+
+	statements := parser.Statements()
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if table, ok := statements[0].(*ast.CreateTable); !ok || table.Temporary == nil {
+		t.Errorf("expected a temporary table, got %+v", statements[0])
+	}
+	if view, ok := statements[1].(*ast.CreateView); !ok || view.Temporary == nil {
+		t.Errorf("expected a temporary view, got %+v", statements[1])
+	}
+	if trigger, ok := statements[2].(*ast.CreateTrigger); !ok || trigger.Temporary == nil || trigger.IfNotExists == nil {
+		t.Errorf("expected a temporary trigger, got %+v", statements[2])
+	}
+	for _, statement := range statements {
+		if !ast.IsTemporary(statement) {
+			t.Errorf("expected %T to be temporary", statement)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	parser := makeParser(`
+		PRAGMA foreign_keys = ON;
+		PRAGMA main.journal_mode(WAL);
+		PRAGMA cache_size = -2000;
+		BEGIN IMMEDIATE TRANSACTION;
+		SAVEPOINT seed;
+		DROP TABLE IF EXISTS old_users;
+		DROP INDEX aux.users_email;
+		DROP VIEW v;
+		DROP TRIGGER IF EXISTS t;
+		ALTER TABLE users RENAME TO people;
+		ALTER TABLE people RENAME COLUMN name TO full_name;
+		ALTER TABLE people ADD COLUMN age integer NOT NULL DEFAULT 0;
+		ALTER TABLE people DROP age;
+		INSERT OR IGNORE INTO people (id, full_name) VALUES (1, 'a;b') ON CONFLICT DO UPDATE SET full_name = 'c';
+		WITH ids AS (SELECT id FROM people) DELETE FROM temp.people WHERE id IN ids;
+		UPDATE OR REPLACE people SET full_name = CASE WHEN id = 1 THEN 'x' ELSE 'y' END;
+		REPLACE INTO people VALUES (2, 'b');
+		WITH ids AS (SELECT 1) SELECT * FROM ids;
+		SELECT 1;
+		RELEASE SAVEPOINT seed;
+		ROLLBACK TO seed;
+		END TRANSACTION;`)
+
+	statements := parser.Statements()
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	expected := []string{
+		"*ast.Pragma", "*ast.Pragma", "*ast.Pragma",
+		"*ast.BeginTransaction", "*ast.Savepoint",
+		"*ast.DropTable", "*ast.DropIndex", "*ast.DropView", "*ast.DropTrigger",
+		"*ast.AlterTable", "*ast.AlterTable", "*ast.AlterTable", "*ast.AlterTable",
+		"*ast.Insert", "*ast.Delete", "*ast.Update", "*ast.Insert",
+		"*ast.Select", "*ast.Select",
+		"*ast.ReleaseSavepoint", "*ast.RollbackTransaction", "*ast.CommitTransaction",
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(statements))
+	}
+	for i, statement := range statements {
+		if fmt.Sprintf("%T", statement) != expected[i] {
+			t.Errorf("statement %d: expected %s, got %T", i, expected[i], statement)
+		}
+	}
+
+	if pragma := statements[0].(*ast.Pragma); pragma.Value.(*ast.Identifier).Text != "ON" {
+		t.Errorf("unexpected pragma value %+v", pragma.Value)
+	}
+	if rename, ok := statements[10].(*ast.AlterTable).Alteration.(*ast.RenameColumn); !ok || rename.NewColumnName.Text != "full_name" {
+		t.Errorf("unexpected alteration %+v", statements[10].(*ast.AlterTable).Alteration)
+	}
+	if add, ok := statements[11].(*ast.AlterTable).Alteration.(*ast.AddColumn); !ok || add.ColumnKeyword == nil {
+		t.Errorf("unexpected alteration %+v", statements[11].(*ast.AlterTable).Alteration)
+	}
+	if insert := statements[13].(*ast.Insert); insert.Table.Canonical() != "people" || !strings.HasSuffix(ast.SourceText(insert.Tokens), "'c'") {
+		t.Errorf("unexpected insert into %s: %s", insert.Table.Canonical(), ast.SourceText(insert.Tokens))
+	}
+	if del := statements[14].(*ast.Delete); del.Table.Canonical() != "temp.people" {
+		t.Errorf("unexpected delete from %s", del.Table.Canonical())
+	}
+	if rollback := statements[20].(*ast.RollbackTransaction); rollback.Savepoint == nil || rollback.Savepoint.Text != "seed" {
+		t.Errorf("unexpected rollback %+v", rollback)
+	}
+}
+
+func TestEscapedStringLiteral(t *testing.T) {
+	parser := makeParser(`
+		CREATE TABLE notes (body text NOT NULL DEFAULT 'it''s');
+		INSERT INTO notes (body) VALUES ('don''t');`)
+
+	statements := parser.Statements()
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+
+	column := statements[0].(*ast.CreateTable).TableDefinition.ColumnDefinitions[0]
+	def, ok := column.ColumnConstraints[1].(*ast.ColumnConstraint_Default)
+	if !ok {
+		t.Fatalf("expected a default constraint, got %T", column.ColumnConstraints[1])
+	}
+	if lit, ok := def.Default.(*ast.LiteralString); !ok || lit.Value != "it's" {
+		t.Errorf("unexpected default %+v", def.Default)
+	}
+
+	if insert := statements[1].(*ast.Insert); !strings.HasSuffix(ast.SourceText(insert.Tokens), "('don''t')") {
+		t.Errorf("unexpected insert %s", ast.SourceText(insert.Tokens))
+	}
+}
+
+func TestSignedAndHexDefaults(t *testing.T) {
+	parser := makeParser("CREATE TABLE t (a integer DEFAULT -1, b integer DEFAULT +2.5, c integer DEFAULT 0x10, d integer DEFAULT (1+2))")
+
+	stmt, ok := parser.Statement().(*ast.CreateTable)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok || len(stmt.TableDefinition.ColumnDefinitions) != 4 {
+		t.Fatalf("expected a table with four columns, got %+v", stmt)
+	}
+
+	defaults := []ast.Expr{}
+	for _, column := range stmt.TableDefinition.ColumnDefinitions {
+		defaults = append(defaults, column.ColumnConstraints[0].(*ast.ColumnConstraint_Default).Default)
+	}
+
+	if op, ok := defaults[0].(*ast.UnaryOp); !ok || op.Operator.Kind != '-' || op.Rhs.(*ast.LiteralSignedInteger).Value != 1 {
+		t.Errorf("expected -1, got %+v", defaults[0])
+	}
+	if op, ok := defaults[1].(*ast.UnaryOp); !ok || op.Operator.Kind != '+' || op.Rhs.(*ast.LiteralFloat).Value != 2.5 {
+		t.Errorf("expected +2.5, got %+v", defaults[1])
+	}
+	if lit, ok := defaults[2].(*ast.LiteralUnsignedInteger); !ok || lit.Value != 16 {
+		t.Errorf("expected 0x10, got %+v", defaults[2])
+	}
+	if op, ok := defaults[3].(*ast.BinaryOp); !ok || op.Operator.Kind != '+' {
+		t.Errorf("expected 1+2, got %+v", defaults[3])
+	}
+}
+
+func TestInvalidNumericLiteral(t *testing.T) {
+	parser := makeParser("CREATE TABLE t (a integer DEFAULT 0x10000000000000000)")
+
+	parser.Statement()
+	errs := parser.ErrorsAsReportSlice()
+	if len(errs) != 1 {
+		t.Fatalf("expected an error for the literal, got %v", errs)
+	}
+	if errs[0].Message != "invalid numeric literal '0x10000000000000000'" || strings.Contains(fmt.Sprint(errs[0].Notes), "strconv") {
+		t.Errorf("expected a report of the literal, got %+v", errs[0])
+	}
+}
+
+func TestForeignKeyWithoutColumns(t *testing.T) {
+	parser := makeParser("CREATE TABLE t (a integer REFERENCES p DEFERRABLE INITIALLY DEFERRED, b integer, FOREIGN KEY (b) REFERENCES p ON DELETE CASCADE)")
+
+	stmt, ok := parser.Statement().(*ast.CreateTable)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok {
+		t.Fatalf("expected a table, got %+v", stmt)
+	}
+
+	column := stmt.TableDefinition.ColumnDefinitions[0].ColumnConstraints[0].(*ast.ColumnConstraint_ForeignKey)
+	if len(column.FkClause.ForeignColumns) != 0 || column.FkClause.Deferrable == nil {
+		t.Errorf("expected a deferrable key on the primary key of p, got %+v", column.FkClause)
+	}
+	table := stmt.TableDefinition.TableConstraints[0].(*ast.TableConstraint_ForeignKey)
+	if len(table.FkClause.ForeignColumns) != 0 || len(table.FkClause.Actions) != 1 {
+		t.Errorf("expected a key on the primary key of p with an action, got %+v", table.FkClause)
+	}
+}
+
+func TestIsNot(t *testing.T) {
+	parser := makeParser("CREATE INDEX i ON t (a) WHERE a IS NOT NULL AND NOT b IS 1")
+
+	stmt, ok := parser.Statement().(*ast.CreateIndex)
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !ok {
+		t.Fatalf("expected an index, got %+v", stmt)
+	}
+
+	and, ok := stmt.WhereExpr.(*ast.BinaryOp)
+	if !ok || and.Operator.Kind != token.TokenKind_Keyword_AND {
+		t.Fatalf("expected AND, got %+v", stmt.WhereExpr)
+	}
+	if isNot, ok := and.Lhs.(*ast.BinaryOp); !ok || isNot.Operator.Kind != token.TokenKind_Keyword_IS || isNot.Not == nil {
+		t.Errorf("expected a IS NOT NULL, got %+v", and.Lhs)
+	} else if _, ok := isNot.Rhs.(*ast.LiteralNull); !ok {
+		t.Errorf("expected IS NOT to compare with NULL, got %+v", isNot.Rhs)
+	}
+	if not, ok := and.Rhs.(*ast.UnaryOp); !ok || not.Operator.Kind != token.TokenKind_Keyword_NOT {
+		t.Errorf("expected NOT (b IS 1), got %+v", and.Rhs)
+	} else if is, ok := not.Rhs.(*ast.BinaryOp); !ok || is.Not != nil {
+		t.Errorf("expected b IS 1, got %+v", not.Rhs)
+	}
+}
+
+func TestUnsupportedStatements(t *testing.T) {
+	parser := makeParser(`
+		VACUUM;
+		ANALYZE main.users;
+		CREATE TABLE t (a integer);
+		CREATE SEQUENCE s;
+		DROP TABLE t;`)
+
+	statements := parser.Statements()
+
+	expected := []string{"*ast.OpaqueStatement", "*ast.OpaqueStatement", "*ast.CreateTable", "*ast.ParseError", "*ast.DropTable"}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(statements))
+	}
+	for i, statement := range statements {
+		if fmt.Sprintf("%T", statement) != expected[i] {
+			t.Errorf("statement %d: expected %s, got %T", i, expected[i], statement)
+		}
+	}
+
+	if text := ast.SourceText(statements[1].(*ast.OpaqueStatement).Tokens); text != "ANALYZE main.users" {
+		t.Errorf("unexpected opaque statement %q", text)
+	}
+	if warnings := parser.WarningsAsReportSlice(); len(warnings) != 2 {
+		t.Errorf("expected a warning for each opaque statement, got %d", len(warnings))
+	}
+	if errs := parser.ErrorsAsErrorSlice(); len(errs) != 1 {
+		t.Errorf("expected an error for the create sequence statement, got %d", len(errs))
+	}
+}
+
+func TestRecoversFromErrors(t *testing.T) {
+	parser := makeParser(`
+		CREATE TABLE a (id integer PRIMRY KEY, b_id integer NOT NUL REFRENCES b(id));
+		CREATE TABLE b (id integer, FORIEGN KEY (id) REFERENCES a(id));
+		CRATE TABEL c (id integer);
+		CREATE TABLE d (id integer;
+		CREATE TABLE e 1 2 (id integer);
+		CREATE TABLE f (id integer PRIMARY KEY);`)
+
+	statements := parser.Statements()
+	if len(statements) != 6 {
+		t.Fatalf("expected 6 statements, got %d", len(statements))
+	}
+	for i, statement := range statements {
+		if _, ok := statement.(*ast.CreateTable); !ok {
+			t.Errorf("statement %d: expected *ast.CreateTable, got %T", i, statement)
+		}
+	}
+
+	suggestions := []string{}
+	for _, rep := range parser.ErrorsAsReportSlice() {
+		for _, note := range rep.Notes {
+			if strings.HasPrefix(note, "did you mean") {
+				suggestions = append(suggestions, note)
+			}
+		}
+	}
+	slices.Sort(suggestions)
+
+	expected := []string{
+		"did you mean CREATE?",
+		"did you mean FOREIGN?",
+		"did you mean NULL?",
+		"did you mean PRIMARY?",
+		"did you mean REFERENCES?",
+		"did you mean TABLE?",
+	}
+	if !slices.Equal(suggestions, expected) {
+		t.Errorf("expected suggestions %v, got %v", expected, suggestions)
+	}
+
+	if errs := parser.ErrorsAsReportSlice(); len(errs) != len(expected)+2 {
+		t.Errorf("expected %d errors, got %d", len(expected)+2, len(errs))
+	}
+
+	if table := statements[4].(*ast.CreateTable); len(table.TableDefinition.ColumnDefinitions) != 1 {
+		t.Errorf("expected the stray tokens before the table definition to be skipped")
+	}
+	if table := statements[0].(*ast.CreateTable); len(table.TableDefinition.ColumnDefinitions[1].ColumnConstraints) != 2 {
+		t.Errorf("expected the misspelled constraints to be parsed, got %+v", table.TableDefinition.ColumnDefinitions[1].ColumnConstraints)
+	}
+}
+
+func TestErrorsInSourceOrder(t *testing.T) {
+	source := `
+		CREATE TABLE a (id integer PRIMRY KEY, b_id integer NOT NUL REFRENCES b(id));
+		CREATE TABLE b (id integer, FORIEGN KEY (id) REFERENCES a(id));
+		CRATE TABEL c (id integer);
+		CREATE TABLE d (id integer;`
+
+	var first []string
+	for range 10 {
+		parser := makeParser(source)
+		parser.Statements()
+
+		reports := parser.ErrorsAsReportSlice()
+		errs := parser.ErrorsAsErrorSlice()
+		if len(reports) < 2 || len(errs) != len(reports) {
+			t.Fatalf("expected several errors, got %d reports and %d errors", len(reports), len(errs))
+		}
+
+		starts := []int{}
+		rendered := []string{}
+		for i, rep := range reports {
+			starts = append(starts, rep.Labels[0].Range.Start)
+			rendered = append(rendered, errs[i].Error())
+		}
+		if !slices.IsSorted(starts) {
+			t.Fatalf("expected the errors in source order, got offsets %v", starts)
+		}
+
+		if first == nil {
+			first = rendered
+		} else if !slices.Equal(first, rendered) {
+			t.Fatal("expected the errors in the same order on every parse")
+		}
+	}
+}
+
+func TestKeywordSuggestions(t *testing.T) {
+	cases := []struct {
+		input      string
+		suggestion string
+	}{
+		{input: "DROP TABEL users;", suggestion: "TABLE"},
+		{input: "ALTER TABLE users ADDD COLUMN age integer;", suggestion: "ADD"},
+		{input: "CREATE INDEX users_email ONN users (email);", suggestion: "ON"},
+		{input: "CREATE TABLE users (id integer REFERNCES accounts (id));", suggestion: "REFERENCES"},
+		{input: "CREATE TEMP TRIGER t AFTER DELETE ON users BEGIN SELECT 1; END;", suggestion: "TRIGGER"},
+	}
+
+	for _, c := range cases {
+		parser := makeParser(c.input)
+		parser.Statements()
+
+		errs := parser.ErrorsAsReportSlice()
+		if len(errs) == 0 {
+			t.Errorf("%s: expected an error", c.input)
+			continue
+		}
+
+		rep := errs[0]
+		if !slices.Contains(rep.Notes, fmt.Sprintf("did you mean %s?", c.suggestion)) {
+			t.Errorf("%s: expected a suggestion of %s, got notes %v", c.input, c.suggestion, rep.Notes)
+		}
+		if !slices.ContainsFunc(rep.Labels, func(label report.Label) bool {
+			return label.Note == fmt.Sprintf("help: replace with %s", c.suggestion)
+		}) {
+			t.Errorf("%s: expected a replacement label, got %v", c.input, rep.Labels)
+		}
+	}
+
+	parser := makeParser(`DROP "tabel" users;`)
+	parser.Statements()
+	for _, rep := range parser.ErrorsAsReportSlice() {
+		if len(rep.Notes) > 0 {
+			t.Errorf("expected no suggestion for a quoted identifier, got %v", rep.Notes)
+		}
+	}
+}
+
+func TestSuggestedEdits(t *testing.T) {
+	cases := []struct {
+		input string
+		fixed string
+	}{
+		{input: "CREATE TABLE users (id integer PRIMRY KEY);", fixed: "CREATE TABLE users (id integer PRIMARY KEY);"},
+		{input: "CREATE TABLE users (id integer email text);", fixed: "CREATE TABLE users (id integer, email text);"},
+		{input: "CREATE TABLE users (id integer, key text);", fixed: `CREATE TABLE users (id integer, "key" text);`},
+	}
+
+	for _, c := range cases {
+		parser := makeParser(c.input)
+		parser.Statements()
+
+		edits := []report.Edit{}
+		for _, rep := range parser.ErrorsAsReportSlice() {
+			for _, label := range rep.Labels {
+				if label.Edit != nil {
+					edits = append(edits, *label.Edit)
+				}
+			}
+		}
+
+		fixed, _ := report.ApplyEdits([]rune(c.input), edits)
+		if string(fixed) != c.fixed {
+			t.Errorf("%s: expected %q, got %q", c.input, c.fixed, string(fixed))
+		}
+	}
+}
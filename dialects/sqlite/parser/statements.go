@@ -3,38 +3,80 @@ package parser
 import (
 	"errors"
 	"fmt"
-	"os"
-	"runtime/debug"
 	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/report"
 	"woodybriggs/justmigrate/frontend/token"
 )
 
+type ParserErrors struct {
+	Errs []error
+}
+
+func (e *ParserErrors) Error() string {
+	return fmt.Sprintf("parser has %d errors", len(e.Errs))
+}
+
+func (e *ParserErrors) Unwrap() []error {
+	return e.Errs
+}
+
+// Parse parses every statement in the source code.
+func Parse(source lexer.SourceCode) ([]ast.Statement, error) {
+	parser := NewSqliteParser(lexer.NewLexer(source))
+
+	nodes := parser.Statements()
+	errors := parser.ErrorsAsErrorSlice()
+	if len(errors) > 0 {
+		return nil, &ParserErrors{
+			Errs: errors,
+		}
+	}
+
+	return nodes, nil
+}
+
 func (p *SqliteParser) Statements() []ast.Statement {
 	statements := []ast.Statement{}
 
 	for !p.EndOfFile() {
 		func() {
+			start, parsed := p.Current(), len(statements)
 			defer func() {
 				if r := recover(); r != nil {
-					if err, isErr := r.(error); isErr && errors.Is(err, ErrNotImplemented) {
-						debug.PrintStack()
-						os.Exit(2)
+					parseError := p.recoverStatement(start, r)
+					if len(statements) == parsed {
+						statements = append(statements, parseError)
 					}
-					p.Synchronize([]token.TokenKind{';'})
+					p.statementRanges = append(p.statementRanges[:parsed], p.statementRange(start))
 				}
 			}()
 
 			statement := p.Statement()
+			if statement == nil {
+				statement = ast.MakeParseError(ErrInvalidStatement, start)
+			}
 			statements = append(statements, statement)
 
 			// if this fails/panics, the defer block above handles it too.
 			p.Expect(';')
+			p.statementRanges = append(p.statementRanges, p.statementRange(start))
 		}()
 	}
 
 	return statements
 }
 
+// StatementRanges are where the statements returned by Statements were
+// written, from their first token up to and including their semicolon.
+func (p *SqliteParser) StatementRanges() []token.TextRange {
+	return p.statementRanges
+}
+
+func (p *SqliteParser) statementRange(start token.Token) token.TextRange {
+	return token.TextRange{Start: start.SourceRange.Start, End: max(start.SourceRange.End, p.Previous().SourceRange.End)}
+}
+
 func (p *SqliteParser) Statement() ast.Statement {
 	p.PushParseContext("statement")
 	defer p.PopParseContext()
@@ -42,9 +84,242 @@ func (p *SqliteParser) Statement() ast.Statement {
 	switch p.Current().Kind {
 	case token.TokenKind_Keyword_CREATE:
 		return p.CreateStatement()
+	case token.TokenKind_Keyword_DROP:
+		return p.DropStatement()
+	case token.TokenKind_Keyword_ALTER:
+		return p.AlterTableStatement()
+	case token.TokenKind_Keyword_PRAMGA:
+		return p.PragmaStatement()
+	case token.TokenKind_Keyword_BEGIN:
+		return p.BeginStatement()
+	case token.TokenKind_Keyword_COMMIT, token.TokenKind_Keyword_END:
+		return p.CommitStatement()
+	case token.TokenKind_Keyword_ROLLBACK:
+		return p.RollbackStatement()
+	case token.TokenKind_Keyword_SELECT:
+		return p.SelectStatement()
+	case token.TokenKind_Keyword_REPLACE, token.TokenKind_Keyword_UPDATE, token.TokenKind_Keyword_DELETE:
+		return p.DataStatement()
+	}
+
+	switch {
+	case p.isWord("savepoint"):
+		return p.SavepointStatement()
+	case p.isWord("release"):
+		return p.ReleaseStatement()
+	case p.isWord("insert"), p.isWord("with"):
+		return p.DataStatement()
+	case p.isWord("values"):
+		return p.SelectStatement()
+	default:
+		if p.RepairKeyword(statementKeywords...) {
+			return p.Statement()
+		}
+		return p.OpaqueStatement()
+	}
+}
+
+// statementKeywords are the keywords that start a statement, an unknown
+// statement that is a misspelling of one of them is parsed as if it was not.
+var statementKeywords = []token.TokenKind{
+	token.TokenKind_Keyword_CREATE,
+	token.TokenKind_Keyword_DROP,
+	token.TokenKind_Keyword_ALTER,
+	token.TokenKind_Keyword_PRAMGA,
+	token.TokenKind_Keyword_BEGIN,
+	token.TokenKind_Keyword_COMMIT,
+	token.TokenKind_Keyword_ROLLBACK,
+	token.TokenKind_Keyword_SELECT,
+	token.TokenKind_Keyword_REPLACE,
+	token.TokenKind_Keyword_UPDATE,
+	token.TokenKind_Keyword_DELETE,
+}
+
+// recoverStatement turns a panic raised while parsing the statement starting
+// at start into a parse error and skips to the start of the next statement.
+// Reports are raised once they are already reported, anything else is
+// reported here.
+func (p *SqliteParser) recoverStatement(start token.Token, r any) *ast.ParseError {
+	var err error
+	switch r := r.(type) {
+	case *report.Report:
+		err = r
+	case report.Report:
+		err = &r
+	case error:
+		err = r
 	default:
-		fmt.Fprintf(os.Stderr, "unhandled statement")
-		os.Exit(1)
-		panic(ErrNotImplemented)
+		err = fmt.Errorf("%w: %v", ErrNotImplemented, r)
+	}
+
+	if rep := (*report.Report)(nil); !errors.As(err, &rep) {
+		rep = report.NewReport("parse error").
+			WithLocation(p.Current().FileLoc).
+			WithLabels(
+				report.LabelFromToken(start, "the statement starting here could not be parsed"),
+				report.LabelFromToken(p.Current(), err.Error()),
+			)
+		if !p.HasReportedError() {
+			p.ReportError(rep)
+		}
+		err = rep
+	}
+
+	p.Synchronize([]token.TokenKind{';'})
+	return ast.MakeParseError(err, start)
+}
+
+// OpaqueStatement keeps a statement that is not otherwise parsed as it was
+// written, up to the end of the statement. A warning is reported so that the
+// statement is not silently ignored.
+func (p *SqliteParser) OpaqueStatement() ast.Statement {
+	p.PushParseContext("statement")
+	defer p.PopParseContext()
+
+	p.ReportWarning(report.NewReport("unsupported statement").
+		WithLocation(p.Current().FileLoc).
+		WithLabels(report.LabelFromToken(p.Current(), "not understood, the statement is kept as it was written")),
+	)
+
+	return &ast.OpaqueStatement{Tokens: p.statementTokens()}
+}
+
+// PragmaStatement parses PRAGMA name, PRAGMA name = value and PRAGMA name(value).
+func (p *SqliteParser) PragmaStatement() ast.Statement {
+	p.PushParseContext("pragma statement")
+	defer p.PopParseContext()
+
+	pragma := &ast.Pragma{
+		PragmaKeyword: ast.Keyword(p.Expect(token.TokenKind_Keyword_PRAMGA)),
+		Name:          *p.CatalogObjectIdentifier(),
+	}
+
+	switch p.Current().Kind {
+	case '=':
+		p.Advance()
+		pragma.Value = p.PragmaValue()
+	case '(':
+		p.Advance()
+		pragma.Value = p.PragmaValue()
+		p.Expect(')')
+	}
+
+	return pragma
+}
+
+// PragmaValue is a signed number, a string or a name, names are often
+// keywords, such as ON or DELETE, which are kept as identifiers.
+func (p *SqliteParser) PragmaValue() ast.Expr {
+	switch p.Current().Kind {
+	case token.TokenKind_StringLiteral:
+		return p.Term()
+	case '+', '-',
+		token.TokenKind_IntegerNumericLiteral,
+		token.TokenKind_FloatNumericLiteral,
+		token.TokenKind_HexNumericLiteral,
+		token.TokenKind_BinaryNumericLiteral,
+		token.TokenKind_OctalNumericLiteral:
+		return p.SignedNumber().(ast.Expr)
+	case ';', ')', token.TokenKind_EOF:
+		p.ReportError(report.NewReport("parse error").WithLabels(
+			report.LabelFromToken(p.Current(), "expected a pragma value"),
+		))
+		return nil
+	default:
+		value := ast.Identifier(p.Current())
+		value.Kind = token.TokenKind_Identifier
+		p.Advance()
+		return &value
+	}
+}
+
+// BeginStatement parses BEGIN [DEFERRED | IMMEDIATE | EXCLUSIVE] [TRANSACTION].
+func (p *SqliteParser) BeginStatement() ast.Statement {
+	p.PushParseContext("begin statement")
+	defer p.PopParseContext()
+
+	begin := &ast.BeginTransaction{
+		BeginKeyword: ast.Keyword(p.Expect(token.TokenKind_Keyword_BEGIN)),
+	}
+
+	switch {
+	case p.Current().Kind == token.TokenKind_Keyword_DEFERRED,
+		p.Current().Kind == token.TokenKind_Keyword_IMMEDIATE,
+		p.isWord("exclusive"):
+		begin.Mode = ast.MakeKeyword(p.Current())
+		p.Advance()
+	}
+
+	begin.TransactionKeyword = p.maybeTransactionKeyword()
+	return begin
+}
+
+// CommitStatement parses COMMIT [TRANSACTION] and END [TRANSACTION].
+func (p *SqliteParser) CommitStatement() ast.Statement {
+	p.PushParseContext("commit statement")
+	defer p.PopParseContext()
+
+	commitKeyword := ast.Keyword(p.Current())
+	p.Advance()
+
+	return &ast.CommitTransaction{
+		CommitKeyword:      commitKeyword,
+		TransactionKeyword: p.maybeTransactionKeyword(),
+	}
+}
+
+// RollbackStatement parses ROLLBACK [TRANSACTION] [TO [SAVEPOINT] name].
+func (p *SqliteParser) RollbackStatement() ast.Statement {
+	p.PushParseContext("rollback statement")
+	defer p.PopParseContext()
+
+	rollback := &ast.RollbackTransaction{
+		RollbackKeyword:    ast.Keyword(p.Expect(token.TokenKind_Keyword_ROLLBACK)),
+		TransactionKeyword: p.maybeTransactionKeyword(),
+	}
+
+	if p.maybeWord("to") != nil {
+		p.maybeWord("savepoint")
+		savepoint := p.Identifier()
+		rollback.Savepoint = &savepoint
+	}
+
+	return rollback
+}
+
+func (p *SqliteParser) SavepointStatement() ast.Statement {
+	p.PushParseContext("savepoint statement")
+	defer p.PopParseContext()
+
+	savepointKeyword := ast.Keyword(p.Current())
+	p.Advance()
+
+	return &ast.Savepoint{
+		SavepointKeyword: savepointKeyword,
+		Name:             p.Identifier(),
+	}
+}
+
+// ReleaseStatement parses RELEASE [SAVEPOINT] name.
+func (p *SqliteParser) ReleaseStatement() ast.Statement {
+	p.PushParseContext("release statement")
+	defer p.PopParseContext()
+
+	releaseKeyword := ast.Keyword(p.Current())
+	p.Advance()
+	p.maybeWord("savepoint")
+
+	return &ast.ReleaseSavepoint{
+		ReleaseKeyword: releaseKeyword,
+		Name:           p.Identifier(),
+	}
+}
+
+func (p *SqliteParser) maybeTransactionKeyword() *ast.Keyword {
+	if p.Current().Kind != token.TokenKind_Keyword_TRANSACTION {
+		return nil
 	}
+	keyword := ast.MakeKeyword(p.Current())
+	p.Advance()
+	return keyword
 }
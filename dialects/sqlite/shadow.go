@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+// MigrationFiles lists the migration files in dir in the order they are
+// applied, which is the lexical order of their file names.
+func MigrationFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// NextMigrationFileName names the migration that follows the migrations in
+// dir, it is numbered one past the highest numbered migration so that gaps
+// left by removed migrations are not numbered again.
+func NextMigrationFileName(dir string, name string) (string, error) {
+	files, err := MigrationFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	highest := 0
+	for _, file := range files {
+		base := filepath.Base(file)
+		digits := len(base) - len(strings.TrimLeft(base, "0123456789"))
+		if number, err := strconv.Atoi(base[:digits]); err == nil {
+			highest = max(highest, number)
+		}
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%04d_%s.sql", highest+1, name)), nil
+}
+
+// NewShadowDatabase replays every migration in dir into an empty in-memory
+// database. The resulting "shadow" database has the schema that the
+// migrations produce, without needing access to a live database. An empty
+// in-memory database is attached for each of the attached schema names.
+func NewShadowDatabase(ctx context.Context, dir string, attached ...string) (*Sqlite, error) {
+	files, err := MigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	shadow, err := OpenInMemory(fmt.Sprintf("%s (shadow database)", dir))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schema := range attached {
+		if err := shadow.Attach(ctx, schema, ":memory:"); err != nil {
+			shadow.Close()
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			shadow.Close()
+			return nil, err
+		}
+
+		if err := shadow.Apply(ctx, string(migration)); err != nil {
+			shadow.Close()
+			return nil, fmt.Errorf("replaying migration %s: %w", file, err)
+		}
+	}
+
+	return shadow, nil
+}
+
+// ReplayMigrations parses every migration in dir and replays them through a
+// schema.Interpreter, it is NewShadowDatabase without a database. The result
+// is the CREATE statements of the objects that the migrations produce.
+func ReplayMigrations(dir string) ([]ast.Statement, error) {
+	files, err := MigrationFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	interpreter := &schema.Interpreter{}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		statements, err := parser.Parse(lexer.SourceCode{FileName: file, Raw: []rune(string(raw))})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, statement := range statements {
+			if err := interpreter.Apply(statement); err != nil {
+				return nil, fmt.Errorf("replaying migration %s: %w", file, err)
+			}
+		}
+	}
+
+	return interpreter.Statements(), nil
+}
@@ -0,0 +1,113 @@
+package sqlite_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite"
+)
+
+// writeMigrations writes the files into a new directory, by file name.
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMigrationFiles(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0002_posts.sql": "",
+		"0001_users.sql": "",
+		"README.md":      "",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "0003_dir.sql"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := sqlite.MigrationFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "0001_users.sql"), filepath.Join(dir, "0002_posts.sql")}
+	if !slices.Equal(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestNextMigrationFileName(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{name: "empty", files: map[string]string{}, expected: "0001_next.sql"},
+		{name: "consecutive", files: map[string]string{"0001_a.sql": "", "0002_b.sql": ""}, expected: "0003_next.sql"},
+		{name: "gap", files: map[string]string{"0001_a.sql": "", "0003_c.sql": ""}, expected: "0004_next.sql"},
+		{name: "unnumbered", files: map[string]string{"0007_a.sql": "", "seed.sql": ""}, expected: "0008_next.sql"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeMigrations(t, tc.files)
+			fileName, err := sqlite.NextMigrationFileName(dir, "next")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fileName != filepath.Join(dir, tc.expected) {
+				t.Errorf("expected %s, got %s", tc.expected, filepath.Base(fileName))
+			}
+		})
+	}
+}
+
+func TestNewShadowDatabase(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0001_users.sql": "CREATE TABLE users (id integer PRIMARY KEY, name text);",
+		"0002_posts.sql": "CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users (id));\nALTER TABLE users ADD COLUMN email text;",
+	})
+
+	shadow, err := sqlite.NewShadowDatabase(context.Background(), dir, "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shadow.Close()
+
+	definitions, err := shadow.ExportDataDefinitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"CREATE TABLE posts", "email text"} {
+		if !strings.Contains(definitions, expected) {
+			t.Errorf("expected the replayed schema to contain %q, got\n%s", expected, definitions)
+		}
+	}
+
+	databases, err := shadow.Databases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(databases, []string{"main", "archive"}) {
+		t.Errorf("expected the archive schema to be attached, got %v", databases)
+	}
+}
+
+func TestNewShadowDatabaseFailingMigration(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0001_users.sql": "CREATE TABLE users (id integer PRIMARY KEY);",
+		"0002_users.sql": "CREATE TABLE users (id integer PRIMARY KEY);",
+	})
+
+	_, err := sqlite.NewShadowDatabase(context.Background(), dir)
+	if err == nil || !strings.Contains(err.Error(), "0002_users.sql") {
+		t.Errorf("expected the failing migration to be named, got %v", err)
+	}
+}
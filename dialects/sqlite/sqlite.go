@@ -1,10 +1,24 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
 	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrBackupIncomplete    = errors.New("backup did not copy every page")
+	ErrForeignKeyViolation = errors.New("foreign key check failed")
 )
 
 type Sqlite struct {
@@ -24,34 +38,419 @@ type schemaRow struct {
 	Sql       sql.NullString
 }
 
+// Databases lists the schema names of the main database and every attached
+// database, in the order sqlite searches them. The temp schema only holds
+// objects of the current connection and is not listed.
+func (sqlite *Sqlite) Databases() ([]string, error) {
+	rows, err := sqlite.Query("select name from pragma_database_list;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name != "temp" {
+			names = append(names, name)
+		}
+	}
+
+	return names, rows.Err()
+}
+
+// Attach attaches the database file under the schema name. Attachments only
+// last as long as the connection, so the database is limited to a single
+// connection that is kept open.
+func (sqlite *Sqlite) Attach(ctx context.Context, schema string, fileName string) error {
+	sqlite.SetMaxOpenConns(1)
+	sqlite.SetConnMaxLifetime(0)
+	sqlite.SetConnMaxIdleTime(0)
+
+	_, err := sqlite.ExecContext(ctx, fmt.Sprintf("ATTACH DATABASE ? AS %s;", quoteIdentifier(schema)), fileName)
+	return err
+}
+
 func (sqlite *Sqlite) ExportDataDefinitions() (string, error) {
 	builder := strings.Builder{}
 
-	rows, err := sqlite.Query("select type, name, tbl_name, rootpage, sql from sqlite_schema;")
+	schemas, err := sqlite.Databases()
 	if err != nil {
 		return "", err
 	}
 
-	ok := rows.Next()
-	for ok {
-		row := &schemaRow{}
-		err := rows.Scan(&row.Type, &row.Name, &row.TableName, &row.RootPage, &row.Sql)
+	for _, schema := range schemas {
+		// the shadow tables a virtual table is stored in are created by its module
+		shadowTables, err := sqlite.shadowTables(schema)
 		if err != nil {
-			log.Panicln(err)
+			return "", err
 		}
 
-		if row.Sql.Valid {
-			builder.WriteString("/* ")
-			builder.WriteString(fmt.Sprintf("%s: %s", row.Type.String, row.Name.String))
-			builder.WriteString(" */\n")
-			builder.WriteString(row.Sql.String)
-			builder.WriteRune(';')
-			builder.WriteRune('\n')
-			builder.WriteRune('\n')
+		rows, err := sqlite.Query(fmt.Sprintf(
+			"select type, name, tbl_name, rootpage, sql from %s.sqlite_schema where name not like 'sqlite_%%';",
+			quoteIdentifier(schema),
+		))
+		if err != nil {
+			return "", err
 		}
 
-		ok = rows.Next()
+		for rows.Next() {
+			row := &schemaRow{}
+			err := rows.Scan(&row.Type, &row.Name, &row.TableName, &row.RootPage, &row.Sql)
+			if err != nil {
+				rows.Close()
+				return "", fmt.Errorf("reading the schema of %s: %w", schema, err)
+			}
+
+			if shadowTables[ast.CanonicalName(row.TableName.String)] {
+				continue
+			}
+
+			if row.Sql.Valid {
+				builder.WriteString("/* ")
+				builder.WriteString(fmt.Sprintf("%s: %s", row.Type.String, row.Name.String))
+				builder.WriteString(" */\n")
+				builder.WriteString(qualify(row.Sql.String, schema))
+				builder.WriteRune(';')
+				builder.WriteRune('\n')
+				builder.WriteRune('\n')
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return "", err
+		}
+		rows.Close()
 	}
 
 	return builder.String(), nil
 }
+
+// shadowTables returns the canonical names of the tables that modules store
+// the virtual tables of the schema in. sqlite only reports a shadow table as
+// such when its module is loaded, so the tables are also found by the names
+// the builtin modules give them.
+func (sqlite *Sqlite) shadowTables(schemaName string) (map[string]bool, error) {
+	rows, err := sqlite.Query(fmt.Sprintf(
+		"select list.name, list.type = 'shadow', definition.sql from pragma_table_list as list join %s.sqlite_schema as definition on definition.name = list.name where list.schema = ? and list.type in ('table', 'shadow', 'virtual');",
+		quoteIdentifier(schemaName),
+	), schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]bool{}
+	tables := []*schema.Table{}
+	virtualTables := []*schema.VirtualTable{}
+	for rows.Next() {
+		var name string
+		var isShadow bool
+		var definition sql.NullString
+		if err := rows.Scan(&name, &isShadow, &definition); err != nil {
+			return nil, err
+		}
+
+		if isShadow {
+			result[ast.CanonicalName(name)] = true
+			continue
+		}
+		tables = append(tables, &schema.Table{Schema: ast.CanonicalName(schemaName), Name: ast.CanonicalName(name)})
+
+		if !strings.HasPrefix(definition.String, "CREATE VIRTUAL TABLE ") {
+			continue
+		}
+		statements, err := parser.Parse(lexer.SourceCode{FileName: name, Raw: []rune(qualify(definition.String, schemaName) + ";")})
+		if err != nil {
+			return nil, fmt.Errorf("reading the definition of %s.%s: %w", schemaName, name, err)
+		}
+		for _, statement := range statements {
+			if vt, ok := statement.(*ast.CreateVirtualTable); ok {
+				virtualTables = append(virtualTables, schema.VirtualTableFromAst(vt))
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		if slices.ContainsFunc(virtualTables, func(vt *schema.VirtualTable) bool { return vt.IsShadowTable(table) }) {
+			result[table.Name] = true
+		}
+	}
+
+	return result, nil
+}
+
+// sqlite stores the definitions of objects without the schema they are in and
+// normalizes the start of the statement to one of these prefixes.
+var definitionPrefixes = []string{
+	"CREATE TABLE ",
+	"CREATE VIRTUAL TABLE ",
+	"CREATE INDEX ",
+	"CREATE UNIQUE INDEX ",
+	"CREATE VIEW ",
+	"CREATE TRIGGER ",
+}
+
+// qualify names the schema of an object defined in an attached database.
+func qualify(definition string, schema string) string {
+	if schema == "main" {
+		return definition
+	}
+
+	for _, prefix := range definitionPrefixes {
+		if rest, ok := strings.CutPrefix(definition, prefix); ok {
+			return prefix + quoteIdentifier(schema) + "." + rest
+		}
+	}
+	return definition
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// CloneInMemory copies the database into a private in-memory database using the
+// sqlite online backup api, so that a migration can be trialled against real data
+// without touching the original file. Attached databases are copied into
+// in-memory databases attached under the same names.
+func (sqlite *Sqlite) CloneInMemory(ctx context.Context) (*Sqlite, error) {
+	clone, err := OpenInMemory(fmt.Sprintf("%s (in-memory copy)", sqlite.FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := sqlite.Databases()
+	if err != nil {
+		clone.Close()
+		return nil, err
+	}
+
+	for _, schema := range schemas {
+		if schema != "main" {
+			if err := clone.Attach(ctx, schema, ":memory:"); err != nil {
+				clone.Close()
+				return nil, err
+			}
+		}
+
+		err = copyDatabase(ctx, sqlite.DB, clone.DB, schema)
+		if err != nil {
+			clone.Close()
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// OpenInMemory opens an empty in-memory database, name is only used to label
+// the database in reports.
+func OpenInMemory(name string) (*Sqlite, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// every connection to ":memory:" is a separate database
+	db.SetMaxOpenConns(1)
+
+	return &Sqlite{DB: db, FileName: name}, nil
+}
+
+func copyDatabase(ctx context.Context, src, dst *sql.DB, schema string) error {
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	return dstConn.Raw(func(dstDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			backup, err := dstDriverConn.(*sqlite3.SQLiteConn).Backup(schema, srcDriverConn.(*sqlite3.SQLiteConn), schema)
+			if err != nil {
+				return err
+			}
+
+			done, err := backup.Step(-1)
+			if err != nil {
+				backup.Finish()
+				return err
+			}
+			if !done {
+				backup.Finish()
+				return ErrBackupIncomplete
+			}
+
+			return backup.Finish()
+		})
+	})
+}
+
+// ForeignKeyViolationErr is returned when a `PRAGMA foreign_key_check` of a
+// migration finds rows that reference a missing parent row.
+type ForeignKeyViolationErr struct {
+	Violations []ForeignKeyViolation
+}
+
+// ForeignKeyViolation is a row of `PRAGMA foreign_key_check`, the row of
+// Table that references a missing row of Parent through its ForeignKey.
+type ForeignKeyViolation struct {
+	Table      string
+	RowID      sql.NullInt64
+	Parent     string
+	ForeignKey int
+}
+
+func (e *ForeignKeyViolationErr) Error() string {
+	return fmt.Sprintf("%s: %d rows reference missing rows", ErrForeignKeyViolation, len(e.Violations))
+}
+
+func (e *ForeignKeyViolationErr) Unwrap() []error {
+	errs := []error{ErrForeignKeyViolation}
+	for _, v := range e.Violations {
+		errs = append(errs, fmt.Errorf("row %d of %s references a missing row of %s", v.RowID.Int64, v.Table, v.Parent))
+	}
+	return errs
+}
+
+// Apply executes a migration script against the database, on a single
+// connection. Each `PRAGMA foreign_key_check` of the script is run as a query
+// that fails the migration when it finds any violation. When a statement
+// fails within a transaction of the script the transaction is rolled back,
+// leaving the database as it was before the transaction began.
+func (sqlite *Sqlite) Apply(ctx context.Context, migration string) error {
+	conn, err := sqlite.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, part := range splitForeignKeyChecks(migration) {
+		if part.check {
+			err = checkForeignKeys(ctx, conn, part.sql)
+		} else if strings.TrimSpace(part.sql) != "" {
+			_, err = conn.ExecContext(ctx, part.sql)
+		}
+		if err != nil {
+			return errors.Join(withModuleHint(err), rollback(conn))
+		}
+	}
+
+	return nil
+}
+
+// moduleBuildTags are the builtin modules that go-sqlite3 only compiles in
+// when it is built with a tag.
+var moduleBuildTags = map[string]string{
+	"fts5": "sqlite_fts5",
+}
+
+// withModuleHint names the build tag of a module that sqlite does not know.
+func withModuleHint(err error) error {
+	for module, tag := range moduleBuildTags {
+		if strings.Contains(err.Error(), "no such module: "+module) {
+			return fmt.Errorf("%w, build justmigrate with -tags %s to use %s", err, tag, module)
+		}
+	}
+	return err
+}
+
+// rollback rolls back the transaction left open by a failed statement, if
+// there is one.
+func rollback(conn *sql.Conn) error {
+	return conn.Raw(func(driverConn any) error {
+		c := driverConn.(*sqlite3.SQLiteConn)
+		if c.AutoCommit() {
+			return nil
+		}
+		_, err := c.Exec("ROLLBACK;", nil)
+		return err
+	})
+}
+
+type scriptPart struct {
+	sql   string
+	check bool
+}
+
+// splitForeignKeyChecks splits the script around its `PRAGMA
+// foreign_key_check` statements, which must be queried for their rows.
+func splitForeignKeyChecks(script string) []scriptPart {
+	raw := []rune(script)
+	lex := lexer.NewLexer(lexer.SourceCode{Raw: raw})
+
+	parts := []scriptPart{}
+	start := 0
+	statement := []token.Token{}
+	for {
+		tok := lex.NextToken()
+		if tok.Kind == token.TokenKind_EOF {
+			break
+		}
+		statement = append(statement, tok)
+		if tok.Kind != ';' {
+			continue
+		}
+
+		if isForeignKeyCheck(statement) {
+			first := statement[0].SourceRange.Start
+			parts = append(parts,
+				scriptPart{sql: string(raw[start:first])},
+				scriptPart{sql: string(raw[first:tok.SourceRange.End]), check: true},
+			)
+			start = tok.SourceRange.End
+		}
+		statement = statement[:0]
+	}
+
+	return append(parts, scriptPart{sql: string(raw[start:])})
+}
+
+// isForeignKeyCheck reports whether the tokens of the statement are
+// `PRAGMA [schema.]foreign_key_check[(table)];`.
+func isForeignKeyCheck(statement []token.Token) bool {
+	if len(statement) < 3 || statement[0].Kind != token.TokenKind_Keyword_PRAMGA {
+		return false
+	}
+	name := statement[1]
+	if len(statement) > 3 && statement[2].Kind == '.' {
+		name = statement[3]
+	}
+	return strings.EqualFold(name.Text, "foreign_key_check")
+}
+
+func checkForeignKeys(ctx context.Context, conn *sql.Conn, check string) error {
+	rows, err := conn.QueryContext(ctx, check)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	violations := []ForeignKeyViolation{}
+	for rows.Next() {
+		v := ForeignKeyViolation{}
+		if err := rows.Scan(&v.Table, &v.RowID, &v.Parent, &v.ForeignKey); err != nil {
+			return err
+		}
+		violations = append(violations, v)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &ForeignKeyViolationErr{Violations: violations}
+	}
+	return nil
+}
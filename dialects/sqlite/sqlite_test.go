@@ -0,0 +1,216 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func TestCloneInMemory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	conn, err := sql.Open("sqlite3", filepath.Join(dir, "main.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &sqlite.Sqlite{DB: conn, FileName: "main.db"}
+	defer db.Close()
+
+	if err := db.Attach(ctx, "aux", filepath.Join(dir, "aux.db")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Apply(ctx, `
+		CREATE TABLE users (id integer PRIMARY KEY);
+		INSERT INTO users VALUES (1), (2);
+		CREATE TABLE aux.logs (line text);
+		INSERT INTO aux.logs VALUES ('a');`); err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := db.CloneInMemory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clone.Close()
+
+	count := func(db *sqlite.Sqlite, table string) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRowContext(ctx, "select count(*) from "+table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if n := count(clone, "users"); n != 2 {
+		t.Errorf("expected the copy to have 2 users, got %d", n)
+	}
+	if n := count(clone, "aux.logs"); n != 1 {
+		t.Errorf("expected the copy to have 1 attached log, got %d", n)
+	}
+
+	if err := clone.Apply(ctx, "DELETE FROM users; DROP TABLE aux.logs;"); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(db, "users"); n != 2 {
+		t.Errorf("expected changes to the copy to leave the database alone, got %d users", n)
+	}
+	if n := count(db, "aux.logs"); n != 1 {
+		t.Errorf("expected changes to the copy to leave the attached database alone, got %d logs", n)
+	}
+}
+
+func TestApplyRollsBackFailedTransaction(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Apply(ctx, "CREATE TABLE users (id integer PRIMARY KEY, email text UNIQUE); INSERT INTO users VALUES (1, 'a');"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Apply(ctx, `
+		BEGIN;
+		CREATE TABLE posts (id integer PRIMARY KEY);
+		INSERT INTO users VALUES (2, 'a');
+		COMMIT;`)
+	if err == nil {
+		t.Fatal("expected the duplicate email to fail the migration")
+	}
+
+	var posts int
+	if err := db.QueryRowContext(ctx, "select count(*) from sqlite_schema where name = 'posts'").Scan(&posts); err != nil {
+		t.Fatal(err)
+	}
+	if posts != 0 {
+		t.Error("expected the failed transaction to be rolled back")
+	}
+
+	// the connection is usable again once the transaction is rolled back
+	if err := db.Apply(ctx, "BEGIN; INSERT INTO users VALUES (2, 'b'); COMMIT;"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyFailsOnForeignKeyViolation(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Apply(ctx, `
+		CREATE TABLE users (id integer PRIMARY KEY);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users (id));
+		BEGIN;
+		INSERT INTO posts VALUES (1, 7);
+		PRAGMA main.foreign_key_check;
+		COMMIT;`)
+
+	violation, ok := errors.AsType[*sqlite.ForeignKeyViolationErr](err)
+	if !ok || !errors.Is(err, sqlite.ErrForeignKeyViolation) {
+		t.Fatalf("expected a foreign key violation, got %v", err)
+	}
+	if len(violation.Violations) != 1 || violation.Violations[0].Table != "posts" || violation.Violations[0].Parent != "users" {
+		t.Errorf("unexpected violations %+v", violation.Violations)
+	}
+
+	var posts int
+	if err := db.QueryRowContext(ctx, "select count(*) from posts").Scan(&posts); err != nil {
+		t.Fatal(err)
+	}
+	if posts != 0 {
+		t.Error("expected the violating rows to be rolled back")
+	}
+}
+
+// hasFts5 reports whether go-sqlite3 was built with the sqlite_fts5 tag.
+func hasFts5(t *testing.T) bool {
+	t.Helper()
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec("CREATE VIRTUAL TABLE probe USING fts5(x);")
+	return err == nil
+}
+
+func TestIntrospectFts5Tables(t *testing.T) {
+	ctx := context.Background()
+	fileName := filepath.Join(t.TempDir(), "main.db")
+
+	conn, err := sql.Open("sqlite3", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &sqlite.Sqlite{DB: conn, FileName: "main.db"}
+	defer func() { db.Close() }()
+
+	if hasFts5(t) {
+		err = db.Apply(ctx, `
+			CREATE TABLE notes (id integer PRIMARY KEY, body text);
+			CREATE VIRTUAL TABLE docs USING fts5(title, body);
+			INSERT INTO docs VALUES ('hello', 'world');`)
+	} else {
+		// without the module sqlite does not know the shadow tables, the
+		// database is written the way the module would have written it
+		t.Log("fts5 is not built in, run the tests with -tags sqlite_fts5 to use the module")
+		err = db.Apply(ctx, `
+			CREATE TABLE notes (id integer PRIMARY KEY, body text);
+			CREATE TABLE 'docs_data'(id INTEGER PRIMARY KEY, block BLOB);
+			CREATE TABLE 'docs_idx'(segid, term, pgno, PRIMARY KEY(segid, term)) WITHOUT ROWID;
+			CREATE TABLE 'docs_content'(id INTEGER PRIMARY KEY, c0, c1);
+			CREATE TABLE 'docs_docsize'(id INTEGER PRIMARY KEY, sz BLOB);
+			CREATE TABLE 'docs_config'(k PRIMARY KEY, v) WITHOUT ROWID;
+			PRAGMA writable_schema = ON;
+			INSERT INTO sqlite_schema (type, name, tbl_name, rootpage, sql) VALUES ('table', 'docs', 'docs', 0, 'CREATE VIRTUAL TABLE docs USING fts5(title, body)');
+			PRAGMA writable_schema = OFF;`)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema is read again by a new connection
+	db.Close()
+	conn, err = sql.Open("sqlite3", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = &sqlite.Sqlite{DB: conn, FileName: "main.db"}
+
+	definitions, err := db.ExportDataDefinitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(definitions, "CREATE VIRTUAL TABLE docs") || strings.Contains(definitions, "docs_") {
+		t.Errorf("expected the virtual table without its shadow tables, got\n%s", definitions)
+	}
+	if _, err := parser.Parse(lexer.SourceCode{FileName: t.Name(), Raw: []rune(definitions)}); err != nil {
+		t.Errorf("expected the definitions to parse, got %v", err)
+	}
+
+	introspected, err := db.IntrospectSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := []string{}
+	for _, table := range introspected.Tables {
+		tables = append(tables, table.Name)
+	}
+	if !slices.Equal(tables, []string{"notes"}) {
+		t.Errorf("expected only the notes table, got %v", tables)
+	}
+}
@@ -0,0 +1,237 @@
+package verify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/backend/schema"
+)
+
+// MismatchErr is returned when the data definitions parsed from a database
+// don't describe the tables that sqlite reports, which points at a gap in
+// the parser.
+type MismatchErr struct {
+	Mismatches []string
+}
+
+func (e *MismatchErr) Error() string {
+	return fmt.Sprintf("parsed data definitions differ from the database:\n  %s", strings.Join(e.Mismatches, "\n  "))
+}
+
+// CrossCheck compares the model of the parsed data definitions against the
+// model introspected from the database, see sqlite.IntrospectSchema, on what
+// the introspected model reports. Any differences are returned as a
+// *MismatchErr.
+func CrossCheck(parsed, introspected *schema.Schema) error {
+	check := crossCheck{}
+
+	for _, table := range introspected.Tables {
+		parsedTable, ok := parsed.Table(qualifiedName(table.Schema, table.Name))
+		if !ok {
+			check.mismatch("table %s is missing from the definitions", qualifiedName(table.Schema, table.Name))
+			continue
+		}
+		check.table(parsedTable, table)
+	}
+
+	for _, index := range introspected.Indexes {
+		name := qualifiedName(index.Schema, index.Name)
+		i := slices.IndexFunc(parsed.Indexes, func(parsedIndex *schema.Index) bool {
+			return parsedIndex.Schema == index.Schema && parsedIndex.Name == index.Name
+		})
+		if i < 0 {
+			check.mismatch("index %s is missing from the definitions", name)
+			continue
+		}
+
+		parsedIndex := parsed.Indexes[i]
+		table, _ := parsed.Table(qualifiedName(parsedIndex.Schema, parsedIndex.Table))
+		if parsedIndex.Table != index.Table || parsedIndex.Unique != index.Unique ||
+			!indexedColumnsEq(table, parsedIndex.Columns, index.Columns) {
+			check.mismatch("index %s is %s in the definitions but %s in the database",
+				name, describeIndex(table, parsedIndex), describeIndex(table, index))
+		}
+	}
+
+	if len(check.mismatches) > 0 {
+		return &MismatchErr{Mismatches: check.mismatches}
+	}
+	return nil
+}
+
+type crossCheck struct {
+	mismatches []string
+}
+
+func (check *crossCheck) mismatch(format string, args ...any) {
+	check.mismatches = append(check.mismatches, fmt.Sprintf(format, args...))
+}
+
+func (check *crossCheck) table(parsed, introspected *schema.Table) {
+	name := qualifiedName(introspected.Schema, introspected.Name)
+
+	if parsed.Strict != introspected.Strict {
+		check.mismatch("table %s: STRICT is %v in the definitions but %v in the database", name, parsed.Strict, introspected.Strict)
+	}
+	if parsed.WithoutRowId != introspected.WithoutRowId {
+		check.mismatch("table %s: WITHOUT ROWID is %v in the definitions but %v in the database", name, parsed.WithoutRowId, introspected.WithoutRowId)
+	}
+
+	parsedColumns := columnNames(parsed.Columns)
+	if !slices.Equal(parsedColumns, columnNames(introspected.Columns)) {
+		check.mismatch("table %s has columns %v in the definitions but %v in the database", name, parsedColumns, columnNames(introspected.Columns))
+		return
+	}
+
+	for i, column := range introspected.Columns {
+		check.column(parsed, parsed.Columns[i], column)
+	}
+
+	if !primaryKeyEq(parsed, introspected) {
+		check.mismatch("table %s has primary key %s in the definitions but %s in the database",
+			name, describeKey(parsed, parsed.PrimaryKey), describeKey(parsed, introspected.PrimaryKey))
+	}
+
+	parsedUniques := []string{}
+	for _, unique := range parsed.Uniques {
+		parsedUniques = append(parsedUniques, describeColumns(parsed, unique.Columns))
+	}
+	uniques := []string{}
+	for _, unique := range introspected.Uniques {
+		uniques = append(uniques, describeColumns(parsed, unique.Columns))
+	}
+	if !sameElements(parsedUniques, uniques) {
+		check.mismatch("table %s has unique keys %v in the definitions but %v in the database", name, parsedUniques, uniques)
+	}
+
+	parsedForeignKeys := []string{}
+	for _, fk := range parsed.ForeignKeys {
+		parsedForeignKeys = append(parsedForeignKeys, describeForeignKey(fk))
+	}
+	foreignKeys := []string{}
+	for _, fk := range introspected.ForeignKeys {
+		foreignKeys = append(foreignKeys, describeForeignKey(fk))
+	}
+	if !sameElements(parsedForeignKeys, foreignKeys) {
+		check.mismatch("table %s has foreign keys %v in the definitions but %v in the database", name, parsedForeignKeys, foreignKeys)
+	}
+}
+
+func (check *crossCheck) column(table *schema.Table, parsed, introspected *schema.Column) {
+	name := qualifiedName(table.Schema, table.Name) + "." + introspected.Name
+
+	if declaredType(parsed.Type) != declaredType(introspected.Type) {
+		check.mismatch("column %s has type %q in the definitions but %q in the database", name, parsed.Type.String(), introspected.Type.String())
+	}
+
+	// the primary key of a STRICT or WITHOUT ROWID table is implicitly NOT NULL
+	notNull := parsed.NotNull || (introspected.NotNull && isKeyColumn(table, parsed.Name) && (table.Strict || table.WithoutRowId))
+	if notNull != introspected.NotNull {
+		check.mismatch("column %s: NOT NULL is %v in the definitions but %v in the database", name, parsed.NotNull, introspected.NotNull)
+	}
+
+	if (parsed.Generated == nil) != (introspected.Generated == nil) ||
+		(parsed.Generated != nil && parsed.Generated.Storage != introspected.Generated.Storage) {
+		check.mismatch("column %s is generated differently in the definitions and in the database", name)
+	}
+}
+
+// declaredType ignores the case and spacing of the declared type, sqlite
+// reports the type as it was written, except for some well known types.
+func declaredType(t *schema.Type) string {
+	return strings.ToUpper(strings.Join(strings.Fields(t.String()), ""))
+}
+
+func isKeyColumn(table *schema.Table, column string) bool {
+	return table.PrimaryKey != nil && slices.ContainsFunc(table.PrimaryKey.Columns, func(indexed schema.IndexedColumn) bool {
+		return indexed.Name == column
+	})
+}
+
+// primaryKeyEq compares the key columns, without an index sqlite doesn't
+// report the order or collation of a key, which is then taken as written.
+func primaryKeyEq(parsed, introspected *schema.Table) bool {
+	if parsed.PrimaryKey == nil || introspected.PrimaryKey == nil {
+		return parsed.PrimaryKey == introspected.PrimaryKey
+	}
+	return indexedColumnsEq(parsed, parsed.PrimaryKey.Columns, introspected.PrimaryKey.Columns)
+}
+
+func indexedColumnsEq(table *schema.Table, parsed, introspected []schema.IndexedColumn) bool {
+	return describeColumns(table, parsed) == describeColumns(table, introspected)
+}
+
+// collation is the collation an indexed column is compared with, which is
+// that of the column unless the index names one.
+func collation(table *schema.Table, indexed schema.IndexedColumn) string {
+	if indexed.Collate != "" {
+		return indexed.Collate
+	}
+	if table != nil {
+		if column, ok := table.Column(indexed.Name); ok && column.Collate != "" {
+			return column.Collate
+		}
+	}
+	return "BINARY"
+}
+
+func describeColumns(table *schema.Table, columns []schema.IndexedColumn) string {
+	described := []string{}
+	for _, column := range columns {
+		name := column.Name
+		// an expression is only reported as being an expression
+		if column.Expr != nil || name == "" {
+			name = "<expr>"
+		}
+
+		order := "ASC"
+		if column.Order == schema.OrderDescending {
+			order = "DESC"
+		}
+
+		described = append(described, fmt.Sprintf("%s COLLATE %s %s", name, collation(table, column), order))
+	}
+	return "(" + strings.Join(described, ", ") + ")"
+}
+
+func describeKey(table *schema.Table, key *schema.PrimaryKey) string {
+	if key == nil {
+		return "none"
+	}
+	return describeColumns(table, key.Columns)
+}
+
+func describeIndex(table *schema.Table, index *schema.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("%sON %s %s", unique, index.Table, describeColumns(table, index.Columns))
+}
+
+func describeForeignKey(fk *schema.ForeignKey) string {
+	return fmt.Sprintf("(%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s",
+		strings.Join(fk.Columns, ", "), fk.ForeignTable, strings.Join(fk.ForeignColumns, ", "), fk.OnDelete, fk.OnUpdate)
+}
+
+func columnNames(columns []*schema.Column) []string {
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func sameElements(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func qualifiedName(schemaName, name string) string {
+	if schemaName == "main" {
+		return name
+	}
+	return schemaName + "." + name
+}
@@ -0,0 +1,109 @@
+package verify_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func openDatabase(t *testing.T, source string) *sqlite.Sqlite {
+	t.Helper()
+
+	db, err := sqlite.OpenInMemory(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Apply(context.Background(), source); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestIntrospectCrossChecksDefinitions(t *testing.T) {
+	db := openDatabase(t, `
+		ATTACH DATABASE ':memory:' AS aux;
+		CREATE TABLE Users (
+			Id integer PRIMARY KEY AUTOINCREMENT,
+			email varchar( 255 ) NOT NULL UNIQUE COLLATE nocase,
+			price decimal(10,2) DEFAULT 1,
+			upper_email text GENERATED ALWAYS AS (upper(email)) STORED,
+			next_id int AS (Id + 1)
+		);
+		CREATE TABLE kv (
+			k text,
+			v int,
+			owner_id integer,
+			PRIMARY KEY (k DESC, v),
+			FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (v, k) REFERENCES users(id, email)
+		) WITHOUT ROWID;
+		CREATE INDEX kv_lower ON kv (lower(k), v DESC);
+		CREATE TABLE aux.items (id int PRIMARY KEY, name text COLLATE nocase) STRICT;
+		CREATE UNIQUE INDEX aux.items_name ON items (name);`)
+
+	statements, err := verify.Introspect(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	introspected, err := db.IntrospectSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kv, ok := introspected.Table("kv")
+	if !ok || !kv.WithoutRowId || len(kv.ForeignKeys) != 2 || kv.PrimaryKey.Columns[0].Order != schema.OrderDescending {
+		t.Errorf("unexpected introspected kv %+v", kv)
+	}
+	if items, ok := introspected.Table("aux.items"); !ok || !items.Strict {
+		t.Errorf("expected the strict table aux.items, got %+v", introspected.Tables)
+	}
+	if len(introspected.Indexes) != 2 {
+		t.Errorf("expected two indexes, got %+v", introspected.Indexes)
+	}
+	if len(statements) != 5 {
+		t.Errorf("expected five statements, got %d", len(statements))
+	}
+}
+
+func TestCrossCheckReportsMismatches(t *testing.T) {
+	db := openDatabase(t, `
+		CREATE TABLE t (a text NOT NULL, b integer REFERENCES t(a));
+		CREATE INDEX t_b ON t (b);`)
+
+	introspected, err := db.IntrospectSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: t.Name(),
+		Raw:      []rune(`CREATE TABLE t (a integer NOT NULL, b integer);`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = verify.CrossCheck(schema.FromStatements(statements), introspected)
+	mismatch, ok := errors.AsType[*verify.MismatchErr](err)
+	if !ok {
+		t.Fatalf("expected a *MismatchErr, got %v", err)
+	}
+
+	for _, want := range []string{"column t.a has type", "table t has foreign keys", "index t_b is missing"} {
+		if !strings.Contains(mismatch.Error(), want) {
+			t.Errorf("expected %q in %v", want, mismatch)
+		}
+	}
+	if len(mismatch.Mismatches) != 3 {
+		t.Errorf("expected three mismatches, got %v", mismatch.Mismatches)
+	}
+}
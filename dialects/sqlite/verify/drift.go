@@ -0,0 +1,34 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/frontend/ast"
+)
+
+var (
+	ErrDrifted = errors.New("the migrations do not produce the schema")
+)
+
+// Drift replays the migrations in dir without a database, see
+// sqlite.ReplayMigrations, and plans the migration from what they produce to
+// the target. When the migrations don't produce the target the result is the
+// missing migration and the error wraps ErrDrifted.
+func Drift(dir string, tgt []ast.Statement) (*Result, error) {
+	migrated, err := sqlite.ReplayMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := Plan(migrated, diff.WithoutTemporary(tgt))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Plan) > 0 {
+		return result, fmt.Errorf("%w: %s is missing %d operations", ErrDrifted, dir, len(diff.Flatten(result.Plan)))
+	}
+	return result, nil
+}
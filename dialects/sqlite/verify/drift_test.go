@@ -0,0 +1,81 @@
+package verify_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+func parse(t *testing.T, source string) []ast.Statement {
+	t.Helper()
+
+	statements, err := parser.Parse(lexer.SourceCode{FileName: t.Name(), Raw: []rune(source)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statements
+}
+
+func TestDriftReplaysGeneratedMigrations(t *testing.T) {
+	initial := `
+		CREATE TABLE users (id integer PRIMARY KEY, name text, age int);
+		CREATE INDEX users_name ON users (name);
+		CREATE VIRTUAL TABLE docs USING fts4(title, body);`
+	target := `
+		CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL, email text);
+		CREATE INDEX users_name ON users (name);
+		CREATE TABLE posts (id integer PRIMARY KEY, user_id integer REFERENCES users(id));
+		CREATE VIRTUAL TABLE docs USING fts4(title, body, tags);
+		CREATE TEMP TABLE scratch (a text);`
+
+	migration, err := verify.Plan(parse(t, initial), parse(t, target))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, script := range map[string]string{"0001_initial.sql": initial, "0002_target.sql": migration.Script} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := verify.Drift(dir, parse(t, target)); err != nil {
+		t.Fatalf("expected the migrations to produce the target, got %v\n%s", err, migration.Script)
+	}
+
+	// the replayed definitions describe what sqlite makes of the migrations
+	shadow, err := sqlite.NewShadowDatabase(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shadow.Close()
+
+	introspected, err := verify.Introspect(shadow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := sqlite.ReplayMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := verify.Plan(introspected, replayed); err != nil || len(result.Plan) > 0 {
+		t.Errorf("expected the replayed definitions to match the shadow database, got %v\n%s", err, result.Script)
+	}
+
+	result, err := verify.Drift(dir, parse(t, target+"CREATE INDEX posts_user ON posts (user_id);"))
+	if !errors.Is(err, verify.ErrDrifted) {
+		t.Fatalf("expected the migrations to have drifted, got %v", err)
+	}
+	if len(diff.Flatten(result.Plan)) != 1 {
+		t.Errorf("expected the missing index to be planned, got %+v", result.Plan)
+	}
+}
@@ -0,0 +1,148 @@
+// Package verify checks that generated migrations take a database to the
+// target schema, by applying them to a scratch database and diffing the result.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"woodybriggs/justmigrate/backend/diff"
+	"woodybriggs/justmigrate/backend/schema"
+	"woodybriggs/justmigrate/dialects/sqlite"
+	"woodybriggs/justmigrate/dialects/sqlite/generator"
+	"woodybriggs/justmigrate/dialects/sqlite/parser"
+	"woodybriggs/justmigrate/frontend/ast"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+var (
+	ErrApplyFailed = errors.New("failed to apply the migration")
+)
+
+// NotConvergedErr is returned when the migrated database still differs from
+// the target schema.
+type NotConvergedErr struct {
+	Remaining []diff.Op
+}
+
+func (e *NotConvergedErr) Error() string {
+	return fmt.Sprintf("migration does not converge to the schema, %d operations remain", len(e.Remaining))
+}
+
+func (e *NotConvergedErr) Unwrap() []error {
+	errs := []error{}
+	for _, op := range e.Remaining {
+		errs = append(errs, fmt.Errorf("remaining operation %T %+v", op, op))
+	}
+	return errs
+}
+
+type Result struct {
+	Plan   []diff.Op
+	Script string
+}
+
+// Introspect parses the data definitions of the database, and cross checks
+// them against the tables the database reports, a *MismatchErr is returned
+// when the parser has missed part of a definition.
+func Introspect(db *sqlite.Sqlite) ([]ast.Statement, error) {
+	source, err := db.ExportDataDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := parser.Parse(lexer.SourceCode{
+		FileName: db.Url(),
+		Raw:      []rune(source),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	introspected, err := db.IntrospectSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return statements, CrossCheck(schema.FromStatements(statements), introspected)
+}
+
+// Plan diffs src against tgt without prompting and renders the planned migration.
+func Plan(src, tgt []ast.Statement) (*Result, error) {
+	differ := diff.Diff{Unattended: true}
+	ops, err := differ.DiffSchema(src, tgt)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.SqliteFormatter{}
+	plan, err := gen.Plan(src, tgt, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := generator.Generate(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Plan: plan, Script: script}, nil
+}
+
+// Converge applies the migration script to the database, then re-introspects
+// the database and diffs it against the target, any remaining operations are
+// returned as a *NotConvergedErr. Types are compared as they were when the
+// migration was planned.
+func Converge(ctx context.Context, db *sqlite.Sqlite, tgt []ast.Statement, script string, types diff.TypeComparison) error {
+	if err := db.Apply(ctx, script); err != nil {
+		return fmt.Errorf("%w: %w", ErrApplyFailed, err)
+	}
+
+	migrated, err := Introspect(db)
+	if err != nil {
+		return err
+	}
+
+	differ := diff.Diff{Unattended: true, TypeComparison: types}
+	remaining, err := differ.DiffSchema(migrated, tgt)
+	if err != nil {
+		return err
+	}
+
+	if len(remaining) > 0 {
+		return &NotConvergedErr{Remaining: remaining}
+	}
+
+	return nil
+}
+
+// RoundTrip creates an empty database from the source data definitions,
+// migrates it to the target and checks that the migration converges.
+func RoundTrip(ctx context.Context, source, target lexer.SourceCode) (*Result, error) {
+	tgt, err := parser.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlite.OpenInMemory(source.FileName)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if err := db.Apply(ctx, string(source.Raw)); err != nil {
+		return nil, err
+	}
+
+	src, err := Introspect(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := Plan(src, tgt)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, Converge(ctx, db, tgt, result.Script, diff.TypeComparisonStrict)
+}
@@ -0,0 +1,37 @@
+// Package verifytest holds the helpers tests use to check migrations, it is
+// kept apart from verify so that the testing package isn't linked into the
+// command.
+package verifytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"woodybriggs/justmigrate/dialects/sqlite/verify"
+	"woodybriggs/justmigrate/frontend/lexer"
+)
+
+// AssertRoundTrip fails the test when the migration from source to target
+// can not be applied, or does not converge to target.
+func AssertRoundTrip(t testing.TB, source, target string) *verify.Result {
+	t.Helper()
+
+	result, err := verify.RoundTrip(
+		context.Background(),
+		lexer.SourceCode{FileName: t.Name() + "/source.sql", Raw: []rune(source)},
+		lexer.SourceCode{FileName: t.Name() + "/target.sql", Raw: []rune(target)},
+	)
+	if err != nil {
+		if result != nil {
+			t.Logf("migration:\n%s", result.Script)
+		}
+		if notConverged, ok := errors.AsType[*verify.NotConvergedErr](err); ok {
+			for _, remaining := range notConverged.Unwrap() {
+				t.Log(remaining)
+			}
+		}
+		t.Fatalf("round trip failed: %v", err)
+	}
+
+	return result
+}
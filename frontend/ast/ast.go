@@ -23,7 +23,25 @@ func MakeParseError(err error, consumedTokens ...token.Token) *ParseError {
 type Identifier token.Token
 
 func (node *Identifier) String() string {
-	return fmt.Sprintf("%c%s%c", node.OpenQuote, node.Text, node.CloseQuote)
+	return token.Token(*node).Quoted()
+}
+
+// Canonical is the name that the identifier refers to. Identifiers are case
+// insensitive and quoting doesn't change the name, so "Users", users, [users]
+// and `USERS` all canonically name users.
+func (node *Identifier) Canonical() string {
+	return CanonicalName(node.Text)
+}
+
+// CanonicalName folds the unquoted text of an identifier to its canonical form,
+// like sqlite only ASCII letters are case insensitive.
+func CanonicalName(text string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, text)
 }
 
 func MakeIdentifier(token token.Token) *Identifier {
@@ -56,10 +74,33 @@ type IfExists struct {
 }
 
 type DropTable struct {
+	DropKeyword     Keyword
+	TableKeyword    Keyword
 	IfExists        *IfExists
 	TableIdentifier CatalogObjectIdentifier
 }
 
+type DropIndex struct {
+	DropKeyword     Keyword
+	IndexKeyword    Keyword
+	IfExists        *IfExists
+	IndexIdentifier CatalogObjectIdentifier
+}
+
+type DropView struct {
+	DropKeyword    Keyword
+	ViewKeyword    Keyword
+	IfExists       *IfExists
+	ViewIdentifier CatalogObjectIdentifier
+}
+
+type DropTrigger struct {
+	DropKeyword       Keyword
+	TriggerKeyword    Keyword
+	IfExists          *IfExists
+	TriggerIdentifier CatalogObjectIdentifier
+}
+
 type AlterTable struct {
 	AlterKeyword    Keyword
 	TableKeyword    Keyword
@@ -79,16 +120,103 @@ type DropColumn struct {
 	ColumnName    Identifier
 }
 
+type RenameTable struct {
+	RenameKeyword Keyword
+	ToKeyword     Keyword
+	NewTableName  Identifier
+}
+
+type RenameColumn struct {
+	RenameKeyword Keyword
+	ColumnKeyword *Keyword
+	ColumnName    Identifier
+	ToKeyword     Keyword
+	NewColumnName Identifier
+}
+
+// Pragma is PRAGMA name, PRAGMA name = value or PRAGMA name(value), a value
+// that is a keyword, such as ON or WAL, is kept as an identifier.
 type Pragma struct {
-	Name  CatalogObjectIdentifier
-	Value Expr
+	PragmaKeyword Keyword
+	Name          CatalogObjectIdentifier
+	Value         Expr
+}
+
+type BeginTransaction struct {
+	BeginKeyword Keyword
+	// Mode is DEFERRED, IMMEDIATE or EXCLUSIVE
+	Mode               *Keyword
+	TransactionKeyword *Keyword
+}
+
+// CommitTransaction is COMMIT or its alias END.
+type CommitTransaction struct {
+	CommitKeyword      Keyword
+	TransactionKeyword *Keyword
+}
+
+type RollbackTransaction struct {
+	RollbackKeyword    Keyword
+	TransactionKeyword *Keyword
+	// Savepoint is the savepoint rolled back to by ROLLBACK TO, if any
+	Savepoint *Identifier
+}
+
+type Savepoint struct {
+	SavepointKeyword Keyword
+	Name             Identifier
+}
+
+type ReleaseSavepoint struct {
+	ReleaseKeyword Keyword
+	Name           Identifier
 }
 
-type BeginTransaction struct{}
+// Insert, Update and Delete change the data of a table rather than the
+// schema, they are kept as the tokens they were written with, from the WITH,
+// INSERT, REPLACE, UPDATE or DELETE keyword up to the end of the statement,
+// and only the table they change is parsed.
+type Insert struct {
+	Table  CatalogObjectIdentifier
+	Tokens []token.Token
+}
 
-type CommitTransaction struct{}
+type Update struct {
+	Table  CatalogObjectIdentifier
+	Tokens []token.Token
+}
+
+type Delete struct {
+	Table  CatalogObjectIdentifier
+	Tokens []token.Token
+}
 
-type Select struct{}
+// Select is kept as the tokens it was written with, from the SELECT, VALUES
+// or WITH keyword up to the end of the statement.
+type Select struct {
+	Tokens []token.Token
+}
+
+func MakeSelect(tokens []token.Token) *Select {
+	return &Select{
+		Tokens: tokens,
+	}
+}
+
+// OpaqueStatement is a statement the parser does not understand, it is kept
+// as the tokens it was written with so that it is written back unchanged.
+type OpaqueStatement struct {
+	Tokens []token.Token
+}
+
+// SourceText returns the source code spanned by the tokens, exactly as it was written.
+func SourceText(tokens []token.Token) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	first, last := tokens[0], tokens[len(tokens)-1]
+	return string(first.SourceCode.Raw[first.SourceRange.Start:last.SourceRange.End])
+}
 
 type CreateTable struct {
 	CreateKeyword   Keyword
@@ -98,6 +226,12 @@ type CreateTable struct {
 	TableIdentifier *CatalogObjectIdentifier
 	TableDefinition *TableDefinition
 	TableOptions    *TableOptions
+
+	// AsSelect is the select a table created with CREATE TABLE ... AS is
+	// filled from, its columns are only known to sqlite so the table
+	// definition is empty.
+	AsKeyword *Keyword
+	AsSelect  *Select
 }
 
 func MakeCreateTable(
@@ -121,10 +255,38 @@ func MakeCreateTable(
 }
 
 type CreateVirtualTable struct {
+	CreateKeyword   Keyword
+	VirtualKeyword  Keyword
+	TableKeyword    Keyword
 	IfNotExist      *IfNotExists
 	TableIdentifier CatalogObjectIdentifier
+	UsingKeyword    Keyword
 	ModuleName      Identifier
-	ModuleArgs      []string
+	// ModuleArgs are the arguments of the module as they were written, sqlite
+	// passes them to the module without interpreting them.
+	ModuleArgs []string
+}
+
+func MakeCreateVirtualTable(
+	createKeyword Keyword,
+	virtualKeyword Keyword,
+	tableKeyword Keyword,
+	ifNotExists *IfNotExists,
+	tableIdentifier *CatalogObjectIdentifier,
+	usingKeyword Keyword,
+	moduleName Identifier,
+	moduleArgs []string,
+) *CreateVirtualTable {
+	return &CreateVirtualTable{
+		CreateKeyword:   createKeyword,
+		VirtualKeyword:  virtualKeyword,
+		TableKeyword:    tableKeyword,
+		IfNotExist:      ifNotExists,
+		TableIdentifier: *tableIdentifier,
+		UsingKeyword:    usingKeyword,
+		ModuleName:      moduleName,
+		ModuleArgs:      moduleArgs,
+	}
 }
 
 func (node *CreateVirtualTable) nodeStatement() {}
@@ -157,6 +319,7 @@ func MakeCreateIndex(
 	return &CreateIndex{
 		CreateKeyword:   createKeyword,
 		UniqueKeyword:   uniqueKeyword,
+		IndexKeyword:    indexKeyword,
 		IfNotExists:     ifNotExists,
 		IndexIdentifier: *indexIdentifier,
 		OnTable:         tableName,
@@ -183,7 +346,37 @@ type CreateTrigger struct {
 	TriggerIdentifier CatalogObjectIdentifier
 	TriggerTime       TriggerTime
 	TriggerEvent      TriggerEvent
+	OnKeyword         Keyword
 	OnTable           CatalogObjectIdentifier
+	// Body holds the tokens following the table, the optional FOR EACH ROW
+	// and WHEN clauses through to the END of the trigger, as they were written.
+	Body []token.Token
+}
+
+func MakeCreateTrigger(
+	createKeyword Keyword,
+	temporary *Keyword,
+	triggerKeyword Keyword,
+	ifNotExists *IfNotExists,
+	triggerIdentifier *CatalogObjectIdentifier,
+	triggerTime TriggerTime,
+	triggerEvent TriggerEvent,
+	onKeyword Keyword,
+	onTable *CatalogObjectIdentifier,
+	body []token.Token,
+) *CreateTrigger {
+	return &CreateTrigger{
+		CreateKeyword:     createKeyword,
+		Temporary:         temporary,
+		TriggerKeyword:    triggerKeyword,
+		IfNotExists:       ifNotExists,
+		TriggerIdentifier: *triggerIdentifier,
+		TriggerTime:       triggerTime,
+		TriggerEvent:      triggerEvent,
+		OnKeyword:         onKeyword,
+		OnTable:           *onTable,
+		Body:              body,
+	}
 }
 
 type TriggerTimeBefore struct {
@@ -238,10 +431,36 @@ type IndexedColumn struct {
 }
 
 type CreateView struct {
+	CreateKeyword  Keyword
+	Temporary      *Keyword
+	ViewKeyword    Keyword
 	IfNotExists    *IfNotExists
 	ViewIdentifier CatalogObjectIdentifier
 	Columns        []Identifier
-	AsSelect       Statement
+	AsKeyword      Keyword
+	AsSelect       *Select
+}
+
+func MakeCreateView(
+	createKeyword Keyword,
+	temporary *Keyword,
+	viewKeyword Keyword,
+	ifNotExists *IfNotExists,
+	viewIdentifier *CatalogObjectIdentifier,
+	columns []Identifier,
+	asKeyword Keyword,
+	asSelect *Select,
+) *CreateView {
+	return &CreateView{
+		CreateKeyword:  createKeyword,
+		Temporary:      temporary,
+		ViewKeyword:    viewKeyword,
+		IfNotExists:    ifNotExists,
+		ViewIdentifier: *viewIdentifier,
+		Columns:        columns,
+		AsKeyword:      asKeyword,
+		AsSelect:       asSelect,
+	}
 }
 
 func (node *CreateView) nodeStatement() {}
@@ -280,6 +499,38 @@ func (node *CatalogObjectIdentifier) String() string {
 	return sb.String()
 }
 
+// Canonical is the canonical name of the object qualified by the canonical name
+// of its schema. Objects in the main schema are never qualified, so that
+// "main"."Users" and users name the same object.
+func (node *CatalogObjectIdentifier) Canonical() string {
+	return QualifiedName(node.SchemaName, node.ObjectName.Text)
+}
+
+// InTempSchema reports whether the object is qualified by the temp schema,
+// which is where sqlite keeps temporary objects.
+func (node *CatalogObjectIdentifier) InTempSchema() bool {
+	return node.SchemaName != nil && node.SchemaName.Canonical() == "temp"
+}
+
+// InSchema qualifies the identifier by schema, unless it is already qualified.
+// Objects can only refer to tables in their own schema, so the name of a table
+// referenced by an object is resolved in the schema of that object.
+func (node *CatalogObjectIdentifier) InSchema(schema *Identifier) *CatalogObjectIdentifier {
+	if node.SchemaName != nil {
+		return node
+	}
+	return MakeCatalogObjectIdentifier(schema, node.ObjectName)
+}
+
+// QualifiedName is the canonical name of the object called name in schema,
+// a nil schema is the main schema.
+func QualifiedName(schema *Identifier, name string) string {
+	if schema == nil || schema.Canonical() == "main" {
+		return CanonicalName(name)
+	}
+	return schema.Canonical() + "." + CanonicalName(name)
+}
+
 func MakeCatalogObjectIdentifier(
 	schemaName *Identifier,
 	objectName Identifier,
@@ -454,6 +705,33 @@ func MakeTableConstraintPrimaryKey(
 	}
 }
 
+type TableConstraint_Unique struct {
+	Name           *ConstraintName
+	UniqueKeyword  Keyword
+	LParen         token.Token
+	IndexedColumns []IndexedColumn
+	RParen         token.Token
+	ConflictClause *ConflictClause
+}
+
+func MakeTableConstraintUnique(
+	constraintName *ConstraintName,
+	uniqueKeyword Keyword,
+	lParen token.Token,
+	indexedColumns []IndexedColumn,
+	rParen token.Token,
+	conflictClause *ConflictClause,
+) *TableConstraint_Unique {
+	return &TableConstraint_Unique{
+		Name:           constraintName,
+		UniqueKeyword:  uniqueKeyword,
+		LParen:         lParen,
+		IndexedColumns: indexedColumns,
+		RParen:         rParen,
+		ConflictClause: conflictClause,
+	}
+}
+
 type TableConstraint_ForeignKey struct {
 	Name           *ConstraintName
 	ForeignKeyword Keyword
@@ -493,7 +771,11 @@ func (node *TableConstraint_ForeignKey) pairColumns() []IdentifierPair {
 	pairs := make([]IdentifierPair, 0, len(node.Columns))
 
 	for i, localCol := range node.Columns {
-		foreignCol := node.FkClause.ForeignColumns[i]
+		// a key without foreign columns references the primary key
+		var foreignCol Identifier
+		if i < len(node.FkClause.ForeignColumns) {
+			foreignCol = node.FkClause.ForeignColumns[i]
+		}
 		pairs = append(pairs, IdentifierPair{
 			A: localCol,
 			B: foreignCol,
@@ -814,11 +1096,15 @@ func TokenToLiteral(tok token.Token) (Expr, error) {
 		}
 	case token.TokenKind_StringLiteral:
 		{
-			return MakeLiteralString(tok, tok.Text), nil
+			return MakeLiteralString(tok, StringLiteralValue(tok)), nil
 		}
-	// allow literals to become string literals where a literal is needed
+	// bare words, such as CURRENT_TIMESTAMP, are kept as they are, quoted
+	// names become string literals where a literal is needed
 	case token.TokenKind_Identifier:
 		{
+			if tok.OpenQuote == 0 {
+				return MakeLiteralKeyword(tok), nil
+			}
 			return MakeLiteralString(tok, tok.Text), nil
 		}
 	case token.TokenKind_Keyword_TRUE:
@@ -843,10 +1129,15 @@ func TokenToLiteral(tok token.Token) (Expr, error) {
 			if err != nil {
 				if errors.Is(err, strconv.ErrRange) {
 					uval, err := strconv.ParseUint(tok.Text, 10, 64)
+					if err == nil {
+						return MakeLiteralUnsignedInteger(tok, uval), nil
+					}
+					// sqlite reads an integer too large for 64 bits as a real
+					fval, err := strconv.ParseFloat(tok.Text, 64)
 					if err != nil {
 						return nil, err
 					}
-					return MakeLiteralUnsignedInteger(tok, uval), nil
+					return MakeLiteralFloat(tok, fval), nil
 				}
 				return nil, err
 			}
@@ -854,7 +1145,8 @@ func TokenToLiteral(tok token.Token) (Expr, error) {
 		}
 	case token.TokenKind_BinaryNumericLiteral:
 		{
-			uval, err := strconv.ParseUint(tok.Text, 2, 64)
+			// the text keeps its 0b prefix, base 0 reads it
+			uval, err := strconv.ParseUint(tok.Text, 0, 64)
 			if err != nil {
 				return nil, err
 			}
@@ -870,7 +1162,8 @@ func TokenToLiteral(tok token.Token) (Expr, error) {
 		}
 	case token.TokenKind_HexNumericLiteral:
 		{
-			uval, err := strconv.ParseUint(tok.Text, 16, 64)
+			// the text keeps its 0x prefix, base 0 reads it
+			uval, err := strconv.ParseUint(tok.Text, 0, 64)
 			if err != nil {
 				return nil, err
 			}
@@ -951,11 +1244,54 @@ func MakeLiteralString(token token.Token, value string) *LiteralString {
 	}
 }
 
+// LiteralKeyword is a bare word where a literal is expected, such as
+// CURRENT_TIMESTAMP. Sqlite gives these words their own meaning, so they are
+// kept apart from string literals and written back unquoted.
+type LiteralKeyword struct {
+	Token token.Token
+}
+
+func MakeLiteralKeyword(token token.Token) *LiteralKeyword {
+	return &LiteralKeyword{
+		Token: token,
+	}
+}
+
+// IsCurrentTimeKeyword reports whether the token is one of the bare words
+// sqlite evaluates to the current date or time.
+func IsCurrentTimeKeyword(tok token.Token) bool {
+	if tok.Kind != token.TokenKind_Identifier || tok.OpenQuote != 0 {
+		return false
+	}
+	switch strings.ToUpper(tok.Text) {
+	case "CURRENT_TIME", "CURRENT_DATE", "CURRENT_TIMESTAMP":
+		return true
+	default:
+		return false
+	}
+}
+
+// StringLiteralValue returns the value of a string literal token, with its
+// doubled ” escapes collapsed to a single quote.
+func StringLiteralValue(tok token.Token) string {
+	return strings.ReplaceAll(tok.Text, "''", "'")
+}
+
 type UnaryOp struct {
 	Operator token.Token
 	Rhs      Expr
 }
 
+func MakeUnaryOpExpr(
+	op token.Token,
+	rhs Expr,
+) *UnaryOp {
+	return &UnaryOp{
+		Operator: op,
+		Rhs:      rhs,
+	}
+}
+
 type FunctionCall struct {
 	Name Identifier
 	Args ExprList
@@ -974,8 +1310,10 @@ type BindingPower struct {
 
 type BinaryOp struct {
 	Operator token.Token
-	Lhs      Expr
-	Rhs      Expr
+	// Not is set for IS NOT, the negated form of the IS operator
+	Not *Keyword
+	Lhs Expr
+	Rhs Expr
 }
 
 func MakeBinaryOpExpr(
@@ -1015,3 +1353,22 @@ func MakeCollation(
 		Name:           name,
 	}
 }
+
+// IsTemporary reports whether the statement creates a temporary object, one
+// that was created with TEMPORARY or in the temp schema. Temporary objects
+// only exist for the connection that created them.
+func IsTemporary(statement Statement) bool {
+	switch stmt := statement.(type) {
+	case *CreateTable:
+		return stmt.Temporary != nil || stmt.TableIdentifier.InTempSchema()
+	case *CreateView:
+		return stmt.Temporary != nil || stmt.ViewIdentifier.InTempSchema()
+	case *CreateTrigger:
+		return stmt.Temporary != nil || stmt.TriggerIdentifier.InTempSchema()
+	case *CreateIndex:
+		return stmt.IndexIdentifier.InTempSchema()
+	case *CreateVirtualTable:
+		return stmt.TableIdentifier.InTempSchema()
+	}
+	return false
+}
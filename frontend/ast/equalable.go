@@ -3,6 +3,9 @@ package ast
 import (
 	"maps"
 	"reflect"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/token"
 )
 
 type Equalable interface {
@@ -54,7 +57,7 @@ func (node *Identifier) Eq(otherAny any) bool {
 		return false
 	}
 
-	if node.Text != other.Text {
+	if node.Canonical() != other.Canonical() {
 		return false
 	}
 
@@ -66,7 +69,198 @@ func (node *ParseError) Eq(otherAny any) bool {
 }
 
 func (node *CreateIndex) Eq(otherAny any) bool {
-	return false
+	other, ok := As[CreateIndex](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.IndexIdentifier, &other.IndexIdentifier) {
+		return false
+	}
+
+	if (node.UniqueKeyword == nil) != (other.UniqueKeyword == nil) {
+		return false
+	}
+
+	if !Check(&node.OnTable, &other.OnTable) {
+		return false
+	}
+
+	if len(node.IndexedColumns) != len(other.IndexedColumns) {
+		return false
+	}
+
+	for i := range len(node.IndexedColumns) {
+		if !Check(&node.IndexedColumns[i], &other.IndexedColumns[i]) {
+			return false
+		}
+	}
+
+	if !CheckPtr(node.WhereExpr, other.WhereExpr) {
+		return false
+	}
+
+	return true
+}
+
+func (node *CreateView) Eq(otherAny any) bool {
+	other, ok := As[CreateView](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.ViewIdentifier, &other.ViewIdentifier) {
+		return false
+	}
+
+	if len(node.Columns) != len(other.Columns) {
+		return false
+	}
+
+	for i := range len(node.Columns) {
+		if !Check(&node.Columns[i], &other.Columns[i]) {
+			return false
+		}
+	}
+
+	return CheckPtr(node.AsSelect, other.AsSelect)
+}
+
+func (node *Select) Eq(otherAny any) bool {
+	other, ok := As[Select](otherAny)
+	if !ok {
+		return false
+	}
+
+	return tokensEq(node.Tokens, other.Tokens)
+}
+
+func (node *CreateTrigger) Eq(otherAny any) bool {
+	other, ok := As[CreateTrigger](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.TriggerIdentifier, &other.TriggerIdentifier) {
+		return false
+	}
+
+	if !triggerTimeEq(node.TriggerTime, other.TriggerTime) {
+		return false
+	}
+
+	if !triggerEventEq(node.TriggerEvent, other.TriggerEvent) {
+		return false
+	}
+
+	if !Check(&node.OnTable, &other.OnTable) {
+		return false
+	}
+
+	return tokensEq(node.Body, other.Body)
+}
+
+// Eq compares the module arguments verbatim, ignoring the space around them,
+// as only the module knows what they mean.
+func (node *CreateVirtualTable) Eq(otherAny any) bool {
+	other, ok := As[CreateVirtualTable](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.TableIdentifier, &other.TableIdentifier) {
+		return false
+	}
+
+	if !Check(&node.ModuleName, &other.ModuleName) {
+		return false
+	}
+
+	return slices.EqualFunc(node.ModuleArgs, other.ModuleArgs, func(a, b string) bool {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	})
+}
+
+// triggerTimeEq compares trigger times, a trigger without a time runs BEFORE.
+func triggerTimeEq(a, b TriggerTime) bool {
+	if a == nil {
+		a = &TriggerTimeBefore{}
+	}
+	if b == nil {
+		b = &TriggerTimeBefore{}
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func triggerEventEq(a, b TriggerEvent) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+
+	if a, ok := a.(*TriggerEventUpdateOf); ok {
+		b := b.(*TriggerEventUpdateOf)
+		if len(a.Columns) != len(b.Columns) {
+			return false
+		}
+		for i := range len(a.Columns) {
+			if !Check(&a.Columns[i], &b.Columns[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// tokensEq compares tokens that are kept verbatim, ignoring whitespace,
+// comments and the case of keywords.
+func tokensEq(a, b []token.Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range len(a) {
+		if a[i].Kind != b[i].Kind {
+			return false
+		}
+
+		if a[i].Kind >= token.TokenKindOffset_Keywords {
+			continue
+		}
+
+		if a[i].Text != b[i].Text {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (node *DropIndex) Eq(otherAny any) bool {
+	other, ok := As[DropIndex](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.IndexIdentifier, &other.IndexIdentifier)
+}
+
+func (node *DropView) Eq(otherAny any) bool {
+	other, ok := As[DropView](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.ViewIdentifier, &other.ViewIdentifier)
+}
+
+func (node *DropTrigger) Eq(otherAny any) bool {
+	other, ok := As[DropTrigger](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.TriggerIdentifier, &other.TriggerIdentifier)
 }
 
 func (node *DropTable) Eq(otherAny any) bool {
@@ -78,6 +272,105 @@ func (node *DropTable) Eq(otherAny any) bool {
 	return Check(&node.TableIdentifier, &other.TableIdentifier)
 }
 
+func (node *Pragma) Eq(otherAny any) bool {
+	other, ok := As[Pragma](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.Name, &other.Name) {
+		return false
+	}
+
+	return CheckPtr(node.Value, other.Value)
+}
+
+// Eq compares the transactions, DEFERRED is the mode of a transaction begun
+// without one.
+func (node *BeginTransaction) Eq(otherAny any) bool {
+	other, ok := As[BeginTransaction](otherAny)
+	if !ok {
+		return false
+	}
+
+	return transactionMode(node.Mode) == transactionMode(other.Mode)
+}
+
+func transactionMode(mode *Keyword) string {
+	if mode == nil {
+		return "DEFERRED"
+	}
+	return strings.ToUpper(mode.Text)
+}
+
+func (node *CommitTransaction) Eq(otherAny any) bool {
+	_, ok := As[CommitTransaction](otherAny)
+	return ok
+}
+
+func (node *RollbackTransaction) Eq(otherAny any) bool {
+	other, ok := As[RollbackTransaction](otherAny)
+	if !ok {
+		return false
+	}
+
+	return CheckPtr(node.Savepoint, other.Savepoint)
+}
+
+func (node *Savepoint) Eq(otherAny any) bool {
+	other, ok := As[Savepoint](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.Name, &other.Name)
+}
+
+func (node *ReleaseSavepoint) Eq(otherAny any) bool {
+	other, ok := As[ReleaseSavepoint](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.Name, &other.Name)
+}
+
+func (node *Insert) Eq(otherAny any) bool {
+	other, ok := As[Insert](otherAny)
+	if !ok {
+		return false
+	}
+
+	return tokensEq(node.Tokens, other.Tokens)
+}
+
+func (node *Update) Eq(otherAny any) bool {
+	other, ok := As[Update](otherAny)
+	if !ok {
+		return false
+	}
+
+	return tokensEq(node.Tokens, other.Tokens)
+}
+
+func (node *Delete) Eq(otherAny any) bool {
+	other, ok := As[Delete](otherAny)
+	if !ok {
+		return false
+	}
+
+	return tokensEq(node.Tokens, other.Tokens)
+}
+
+func (node *OpaqueStatement) Eq(otherAny any) bool {
+	other, ok := As[OpaqueStatement](otherAny)
+	if !ok {
+		return false
+	}
+
+	return tokensEq(node.Tokens, other.Tokens)
+}
+
 func (node *AlterTable) Eq(otherAny any) bool {
 	other, ok := As[AlterTable](otherAny)
 	if !ok {
@@ -113,6 +406,28 @@ func (node *AddColumn) Eq(otherAny any) bool {
 	return Check(&node.ColumnDefinition, &other.ColumnDefinition)
 }
 
+func (node *RenameTable) Eq(otherAny any) bool {
+	other, ok := As[RenameTable](otherAny)
+	if !ok {
+		return false
+	}
+
+	return Check(&node.NewTableName, &other.NewTableName)
+}
+
+func (node *RenameColumn) Eq(otherAny any) bool {
+	other, ok := As[RenameColumn](otherAny)
+	if !ok {
+		return false
+	}
+
+	if !Check(&node.ColumnName, &other.ColumnName) {
+		return false
+	}
+
+	return Check(&node.NewColumnName, &other.NewColumnName)
+}
+
 func (node *CreateTable) Eq(otherAny any) bool {
 	other, ok := As[CreateTable](otherAny)
 	if !ok {
@@ -131,7 +446,7 @@ func (node *CreateTable) Eq(otherAny any) bool {
 		return false
 	}
 
-	return true
+	return CheckPtr(node.AsSelect, other.AsSelect)
 }
 
 func (node *CatalogObjectIdentifier) Eq(otherAny any) bool {
@@ -145,15 +460,7 @@ func (node *CatalogObjectIdentifier) Eq(otherAny any) bool {
 		return false
 	}
 
-	if !CheckPtr(node.SchemaName, other.SchemaName) {
-		return false
-	}
-
-	if !Check(&node.ObjectName, &other.ObjectName) {
-		return false
-	}
-
-	return true
+	return node.Canonical() == other.Canonical()
 }
 
 func (node *TableDefinition) Eq(otherAny any) bool {
@@ -277,6 +584,25 @@ func (node *TableConstraint_PrimaryKey) Eq(otherAny any) bool {
 	return true
 }
 
+func (node *TableConstraint_Unique) Eq(otherAny any) bool {
+	other, ok := As[TableConstraint_Unique](otherAny)
+	if !ok {
+		return false
+	}
+
+	if len(node.IndexedColumns) != len(other.IndexedColumns) {
+		return false
+	}
+
+	for i := range len(node.IndexedColumns) {
+		if !Check(&node.IndexedColumns[i], &other.IndexedColumns[i]) {
+			return false
+		}
+	}
+
+	return CheckPtr(node.ConflictClause, other.ConflictClause)
+}
+
 func (node *TableConstraint_ForeignKey) Eq(otherAny any) bool {
 	other, ok := As[TableConstraint_ForeignKey](otherAny)
 	if !ok {
@@ -313,15 +639,11 @@ func (node *IndexedColumn) Eq(otherAny any) bool {
 		return false
 	}
 
-	if !Check(node.Collation, other.Collation) {
-		return false
-	}
-
 	if !CheckPtr(node.Collation, other.Collation) {
 		return false
 	}
 
-	if CheckPtr(node.Order, other.Order) {
+	if !CheckPtr(node.Order, other.Order) {
 		return false
 	}
 
@@ -644,6 +966,19 @@ func (node *CaseExpression) Eq(otherAny any) bool {
 	return true
 }
 
+func (node *UnaryOp) Eq(otherAny any) bool {
+	other, ok := As[UnaryOp](otherAny)
+	if !ok {
+		return false
+	}
+
+	if node.Operator.Kind != other.Operator.Kind {
+		return false
+	}
+
+	return Check(node.Rhs, other.Rhs)
+}
+
 func (node *BinaryOp) Eq(otherAny any) bool {
 	other, ok := As[BinaryOp](otherAny)
 	if !ok {
@@ -654,6 +989,10 @@ func (node *BinaryOp) Eq(otherAny any) bool {
 		return false
 	}
 
+	if (node.Not == nil) != (other.Not == nil) {
+		return false
+	}
+
 	if !Check(node.Lhs, other.Lhs) {
 		return false
 	}
@@ -768,6 +1107,14 @@ func (node *LiteralNull) Eq(otherAny any) bool {
 	return ok
 }
 
+func (node *LiteralKeyword) Eq(otherAny any) bool {
+	other, ok := As[LiteralKeyword](otherAny)
+	if !ok {
+		return false
+	}
+	return strings.EqualFold(node.Token.Text, other.Token.Text)
+}
+
 func (this IdentifierPair) Eq(otherAny any) bool {
 	other, ok := otherAny.(IdentifierPair)
 	if !ok {
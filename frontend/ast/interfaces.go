@@ -6,14 +6,25 @@ type Statement interface {
 	nodeStatement()
 }
 
-func (node *Pragma) nodeStatement()            {}
-func (node *BeginTransaction) nodeStatement()  {}
-func (node *CommitTransaction) nodeStatement() {}
-func (node *Select) nodeStatement()            {}
-func (node *CreateTable) nodeStatement()       {}
-func (node *AlterTable) nodeStatement()        {}
-func (node *DropTable) nodeStatement()         {}
-func (node *CreateTrigger) nodeStatement()     {}
+func (node *Pragma) nodeStatement()              {}
+func (node *BeginTransaction) nodeStatement()    {}
+func (node *CommitTransaction) nodeStatement()   {}
+func (node *RollbackTransaction) nodeStatement() {}
+func (node *Savepoint) nodeStatement()           {}
+func (node *ReleaseSavepoint) nodeStatement()    {}
+func (node *Insert) nodeStatement()              {}
+func (node *Update) nodeStatement()              {}
+func (node *Delete) nodeStatement()              {}
+func (node *Select) nodeStatement()              {}
+func (node *CreateTable) nodeStatement()         {}
+func (node *AlterTable) nodeStatement()          {}
+func (node *DropTable) nodeStatement()           {}
+func (node *DropIndex) nodeStatement()           {}
+func (node *DropView) nodeStatement()            {}
+func (node *DropTrigger) nodeStatement()         {}
+func (node *CreateTrigger) nodeStatement()       {}
+func (node *OpaqueStatement) nodeStatement()     {}
+func (node *ParseError) nodeStatement()          {}
 
 type TableAlteration interface {
 	Equalable
@@ -21,10 +32,10 @@ type TableAlteration interface {
 	tableAlteration()
 }
 
-func (node *AddColumn) tableAlteration()  {}
-func (node *DropColumn) tableAlteration() {}
-
-// func (node *CreateView) nodeStatement()        {}
+func (node *AddColumn) tableAlteration()    {}
+func (node *DropColumn) tableAlteration()   {}
+func (node *RenameTable) tableAlteration()  {}
+func (node *RenameColumn) tableAlteration() {}
 
 type TableConstraint interface {
 	Equalable
@@ -34,6 +45,7 @@ type TableConstraint interface {
 
 func (node *TableConstraint_Check) nodeTableConstraint()      {}
 func (node *TableConstraint_PrimaryKey) nodeTableConstraint() {}
+func (node *TableConstraint_Unique) nodeTableConstraint()     {}
 func (node *TableConstraint_ForeignKey) nodeTableConstraint() {}
 func (node *ParseError) nodeTableConstraint()                 {}
 
@@ -92,6 +104,7 @@ func (node *LiteralSignedInteger) nodeExpression()   {}
 func (node *LiteralUnsignedInteger) nodeExpression() {}
 func (node *LiteralString) nodeExpression()          {}
 func (node *LiteralNull) nodeExpression()            {}
+func (node *LiteralKeyword) nodeExpression()         {}
 func (node *ParseError) nodeExpression()             {}
 
 type NumericLiteral interface {
@@ -9,17 +9,38 @@ type Visitor interface {
 	VisitParseError(*ParseError)
 
 	VisitDropTable(*DropTable)
+	VisitDropIndex(*DropIndex)
+	VisitDropView(*DropView)
+	VisitDropTrigger(*DropTrigger)
 
 	VisitCreateTable(*CreateTable)
 	VisitCreateIndex(*CreateIndex)
 	VisitCreateView(*CreateView)
+	VisitCreateTrigger(*CreateTrigger)
+	VisitCreateVirtualTable(*CreateVirtualTable)
+	VisitSelect(*Select)
 	VisitAlterTable(*AlterTable)
 
+	VisitPragma(*Pragma)
+	VisitBeginTransaction(*BeginTransaction)
+	VisitCommitTransaction(*CommitTransaction)
+	VisitRollbackTransaction(*RollbackTransaction)
+	VisitSavepoint(*Savepoint)
+	VisitReleaseSavepoint(*ReleaseSavepoint)
+
+	VisitInsert(*Insert)
+	VisitUpdate(*Update)
+	VisitDelete(*Delete)
+	VisitOpaqueStatement(*OpaqueStatement)
+
 	VisitTableAlterationAddColumn(*AddColumn)
 	VisitTableAlterationDropColumn(*DropColumn)
+	VisitTableAlterationRenameTable(*RenameTable)
+	VisitTableAlterationRenameColumn(*RenameColumn)
 
 	VisitTableConstraintCheck(*TableConstraint_Check)
 	VisitTableConstraintPrimaryKey(*TableConstraint_PrimaryKey)
+	VisitTableConstraintUnique(*TableConstraint_Unique)
 	VisitTableConstraintForeignKey(*TableConstraint_ForeignKey)
 
 	VisitColumnConstraintPrimaryKey(*ColumnConstraint_PrimaryKey)
@@ -48,9 +69,11 @@ type Visitor interface {
 	VisitLiteralUnsignedInteger(*LiteralUnsignedInteger)
 	VisitLiteralFloat(*LiteralFloat)
 	VisitLiteralNull(*LiteralNull)
+	VisitLiteralKeyword(*LiteralKeyword)
 
 	VisitFunctionCall(*FunctionCall)
 	VisitColumnName(*ColumnName)
+	VisitUnaryOp(*UnaryOp)
 	VisitBinaryOp(*BinaryOp)
 	VisitCaseExpression(*CaseExpression)
 
@@ -75,10 +98,30 @@ func (node *DropColumn) Accept(v Visitor) {
 	v.VisitTableAlterationDropColumn(node)
 }
 
+func (node *RenameTable) Accept(v Visitor) {
+	v.VisitTableAlterationRenameTable(node)
+}
+
+func (node *RenameColumn) Accept(v Visitor) {
+	v.VisitTableAlterationRenameColumn(node)
+}
+
 func (node *DropTable) Accept(v Visitor) {
 	v.VisitDropTable(node)
 }
 
+func (node *DropIndex) Accept(v Visitor) {
+	v.VisitDropIndex(node)
+}
+
+func (node *DropView) Accept(v Visitor) {
+	v.VisitDropView(node)
+}
+
+func (node *DropTrigger) Accept(v Visitor) {
+	v.VisitDropTrigger(node)
+}
+
 func (node *CreateTable) Accept(v Visitor) {
 	v.VisitCreateTable(node)
 }
@@ -87,6 +130,18 @@ func (node *CreateView) Accept(v Visitor) {
 	v.VisitCreateView(node)
 }
 
+func (node *CreateTrigger) Accept(v Visitor) {
+	v.VisitCreateTrigger(node)
+}
+
+func (node *CreateVirtualTable) Accept(v Visitor) {
+	v.VisitCreateVirtualTable(node)
+}
+
+func (node *Select) Accept(v Visitor) {
+	v.VisitSelect(node)
+}
+
 func (node *CreateIndex) Accept(v Visitor) {
 	v.VisitCreateIndex(node)
 }
@@ -95,6 +150,46 @@ func (node *AlterTable) Accept(v Visitor) {
 	v.VisitAlterTable(node)
 }
 
+func (node *Pragma) Accept(v Visitor) {
+	v.VisitPragma(node)
+}
+
+func (node *BeginTransaction) Accept(v Visitor) {
+	v.VisitBeginTransaction(node)
+}
+
+func (node *CommitTransaction) Accept(v Visitor) {
+	v.VisitCommitTransaction(node)
+}
+
+func (node *RollbackTransaction) Accept(v Visitor) {
+	v.VisitRollbackTransaction(node)
+}
+
+func (node *Savepoint) Accept(v Visitor) {
+	v.VisitSavepoint(node)
+}
+
+func (node *ReleaseSavepoint) Accept(v Visitor) {
+	v.VisitReleaseSavepoint(node)
+}
+
+func (node *Insert) Accept(v Visitor) {
+	v.VisitInsert(node)
+}
+
+func (node *Update) Accept(v Visitor) {
+	v.VisitUpdate(node)
+}
+
+func (node *Delete) Accept(v Visitor) {
+	v.VisitDelete(node)
+}
+
+func (node *OpaqueStatement) Accept(v Visitor) {
+	v.VisitOpaqueStatement(node)
+}
+
 func (node *TableConstraint_Check) Accept(v Visitor) {
 	v.VisitTableConstraintCheck(node)
 }
@@ -103,6 +198,10 @@ func (node *TableConstraint_PrimaryKey) Accept(v Visitor) {
 	v.VisitTableConstraintPrimaryKey(node)
 }
 
+func (node *TableConstraint_Unique) Accept(v Visitor) {
+	v.VisitTableConstraintUnique(node)
+}
+
 func (node *TableConstraint_ForeignKey) Accept(v Visitor) {
 	v.VisitTableConstraintForeignKey(node)
 }
@@ -171,6 +270,10 @@ func (node *LiteralString) Accept(v Visitor) {
 	v.VisitLiteralString(node)
 }
 
+func (node *LiteralKeyword) Accept(v Visitor) {
+	v.VisitLiteralKeyword(node)
+}
+
 func (node *Identifier) Accept(v Visitor) {
 	v.VisitIdentifier(node)
 }
@@ -183,6 +286,10 @@ func (node *ColumnName) Accept(v Visitor) {
 	v.VisitColumnName(node)
 }
 
+func (node *UnaryOp) Accept(v Visitor) {
+	v.VisitUnaryOp(node)
+}
+
 func (node *BinaryOp) Accept(v Visitor) {
 	v.VisitBinaryOp(node)
 }
@@ -227,6 +334,12 @@ type BaseVisitor struct {
 	Debug bool
 }
 
+func (v *BaseVisitor) VisitParseError(*ParseError) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitParseError")
+	}
+}
+
 func (v *BaseVisitor) VisitForeignKeyActionNoAction(*NoAction) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitForeignKeyActionNoAction")
@@ -264,6 +377,21 @@ func (v *BaseVisitor) VisitDropTable(*DropTable) {
 		fmt.Fprintf(os.Stderr, "VisitDropTable")
 	}
 }
+func (v *BaseVisitor) VisitDropIndex(*DropIndex) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitDropIndex")
+	}
+}
+func (v *BaseVisitor) VisitDropView(*DropView) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitDropView")
+	}
+}
+func (v *BaseVisitor) VisitDropTrigger(*DropTrigger) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitDropTrigger")
+	}
+}
 func (v *BaseVisitor) VisitCreateTable(*CreateTable) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitCreateTable")
@@ -279,11 +407,76 @@ func (v *BaseVisitor) VisitCreateView(*CreateView) {
 		fmt.Fprintf(os.Stderr, "VisitCreateView")
 	}
 }
+func (v *BaseVisitor) VisitCreateTrigger(*CreateTrigger) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitCreateTrigger")
+	}
+}
+func (v *BaseVisitor) VisitCreateVirtualTable(*CreateVirtualTable) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitCreateVirtualTable")
+	}
+}
+func (v *BaseVisitor) VisitSelect(*Select) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitSelect")
+	}
+}
 func (v *BaseVisitor) VisitAlterTable(*AlterTable) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitAlterTable")
 	}
 }
+func (v *BaseVisitor) VisitPragma(*Pragma) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitPragma")
+	}
+}
+func (v *BaseVisitor) VisitBeginTransaction(*BeginTransaction) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitBeginTransaction")
+	}
+}
+func (v *BaseVisitor) VisitCommitTransaction(*CommitTransaction) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitCommitTransaction")
+	}
+}
+func (v *BaseVisitor) VisitRollbackTransaction(*RollbackTransaction) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitRollbackTransaction")
+	}
+}
+func (v *BaseVisitor) VisitSavepoint(*Savepoint) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitSavepoint")
+	}
+}
+func (v *BaseVisitor) VisitReleaseSavepoint(*ReleaseSavepoint) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitReleaseSavepoint")
+	}
+}
+func (v *BaseVisitor) VisitInsert(*Insert) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitInsert")
+	}
+}
+func (v *BaseVisitor) VisitUpdate(*Update) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitUpdate")
+	}
+}
+func (v *BaseVisitor) VisitDelete(*Delete) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitDelete")
+	}
+}
+func (v *BaseVisitor) VisitOpaqueStatement(*OpaqueStatement) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitOpaqueStatement")
+	}
+}
 func (v *BaseVisitor) VisitTableAlterationAddColumn(*AddColumn) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitTableAlterationAddColumn")
@@ -294,6 +487,16 @@ func (v *BaseVisitor) VisitTableAlterationDropColumn(*DropColumn) {
 		fmt.Fprintf(os.Stderr, "VisitTableAlterationDropColumn")
 	}
 }
+func (v *BaseVisitor) VisitTableAlterationRenameTable(*RenameTable) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitTableAlterationRenameTable")
+	}
+}
+func (v *BaseVisitor) VisitTableAlterationRenameColumn(*RenameColumn) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitTableAlterationRenameColumn")
+	}
+}
 func (v *BaseVisitor) VisitTableConstraintCheck(*TableConstraint_Check) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitTableConstraintCheck")
@@ -304,6 +507,11 @@ func (v *BaseVisitor) VisitTableConstraintPrimaryKey(*TableConstraint_PrimaryKey
 		fmt.Fprintf(os.Stderr, "VisitTableConstraintPrimaryKey")
 	}
 }
+func (v *BaseVisitor) VisitTableConstraintUnique(*TableConstraint_Unique) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitTableConstraintUnique")
+	}
+}
 func (v *BaseVisitor) VisitTableConstraintForeignKey(*TableConstraint_ForeignKey) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitTableConstraintForeignKey")
@@ -399,6 +607,11 @@ func (v *BaseVisitor) VisitLiteralNull(*LiteralNull) {
 		fmt.Fprintf(os.Stderr, "VisitLiteralNull")
 	}
 }
+func (v *BaseVisitor) VisitLiteralKeyword(*LiteralKeyword) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitLiteralKeyword")
+	}
+}
 func (v *BaseVisitor) VisitFunctionCall(*FunctionCall) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitFunctionCall")
@@ -409,6 +622,11 @@ func (v *BaseVisitor) VisitColumnName(*ColumnName) {
 		fmt.Fprintf(os.Stderr, "VisitColumnName")
 	}
 }
+func (v *BaseVisitor) VisitUnaryOp(*UnaryOp) {
+	if v.Debug {
+		fmt.Fprintf(os.Stderr, "VisitUnaryOp")
+	}
+}
 func (v *BaseVisitor) VisitBinaryOp(*BinaryOp) {
 	if v.Debug {
 		fmt.Fprintf(os.Stderr, "VisitBinaryOp")
@@ -76,6 +76,38 @@ func (t Lexer) peekRune() (rune, error) {
 	return t.SourceCode.Raw[t.Cur], nil
 }
 
+// quotedIdentifier lexes an identifier between the quotes, a doubled closing
+// quote is an escaped quote. The text of the token is the unescaped name, so
+// "a""b" is the identifier a"b.
+func (t *Lexer) quotedIdentifier(tok token.Token, open rune, close rune) token.Token {
+	// eat the opening quote
+	t.eat()
+	sb := strings.Builder{}
+	prev := rune(0)
+	for !t.Eof() {
+		if t.currentRune() == close && prev != '\\' {
+			if p, err := t.peekRune(); err != io.EOF && p == close {
+				t.eat()
+				prev = t.eat()
+				sb.WriteRune(close)
+				continue
+			}
+			break
+		}
+		prev = t.eat()
+		sb.WriteRune(prev)
+	}
+	// eat the closing quote, an identifier left open runs to the end
+	if !t.Eof() {
+		t.eat()
+	}
+	tok.Kind = token.TokenKind_Identifier
+	tok.Text = sb.String()
+	tok.OpenQuote = open
+	tok.CloseQuote = close
+	return tok
+}
+
 func (t *Lexer) eat() rune {
 	current := t.SourceCode.Raw[t.Cur]
 	t.Cur++
@@ -236,15 +268,16 @@ func (t *Lexer) decimalNumeric() (string, bool) {
 		t.eat()
 	}
 
-	// check if we have a period
+	// check if we have an exponent
 	if !hasExpon && (t.currentRune() == 'e' || t.currentRune() == 'E') {
 		t.eat()
 		hasExpon = true
-	}
 
-	// check for signs
-	if t.currentRune() == '+' || t.currentRune() == '-' {
-		t.eat()
+		// check for the sign of the exponent, a sign anywhere else is an
+		// operator
+		if t.currentRune() == '+' || t.currentRune() == '-' {
+			t.eat()
+		}
 	}
 
 	// eat as many digits as we can
@@ -334,7 +367,7 @@ func (t *Lexer) NextToken() (tok token.Token) {
 
 	tok.SourceRange.Start = t.Cur
 	switch t.currentRune() {
-	case ';', ',', '(', ')', '=', '+', '-', '*', '/':
+	case ';', ',', '(', ')', '+', '-', '*', '/':
 		{
 			r := t.currentRune()
 			t.eat()
@@ -342,6 +375,30 @@ func (t *Lexer) NextToken() (tok token.Token) {
 			tok.Text = string(r)
 			return tok
 		}
+	case '=':
+		{
+			t.eat()
+			tok.Kind = '='
+			tok.Text = "="
+			if t.currentRune() == '=' {
+				t.eat()
+				tok.Text = "=="
+			}
+			return tok
+		}
+	case '|':
+		{
+			t.eat()
+			if t.currentRune() == '|' {
+				t.eat()
+				tok.Kind = token.TokenKind_concat
+				tok.Text = "||"
+				return tok
+			}
+			tok.Kind = '|'
+			tok.Text = "|"
+			return tok
+		}
 	case '!':
 		{
 			t.eat()
@@ -375,73 +432,24 @@ func (t *Lexer) NextToken() (tok token.Token) {
 				t.eat()
 				tok.Kind = token.TokenKind_lte
 				tok.Text = "<="
+				return tok
+			}
+			if t.currentRune() == '>' {
+				t.eat()
+				tok.Kind = token.TokenKind_neq
+				tok.Text = "<>"
+				return tok
 			}
 			tok.Kind = token.TokenKind_lt
 			tok.Text = "<"
 			return tok
 		}
 	case '"':
-		{
-			// eat the first "
-			t.eat()
-			start := t.Cur
-			prev := rune(0)
-			for !t.Eof() {
-				if t.currentRune() == '"' && prev != '\\' {
-					break
-				}
-				prev = t.eat()
-			}
-			end := t.Cur
-			// eat the last "
-			t.eat()
-			tok.Kind = token.TokenKind_Identifier
-			tok.Text = string(t.Raw[start:end])
-			tok.OpenQuote = '"'
-			tok.CloseQuote = '"'
-			return tok
-		}
+		return t.quotedIdentifier(tok, '"', '"')
 	case '[':
-		{
-			// eat the first [
-			t.eat()
-			start := t.Cur
-			for !t.Eof() {
-				if t.currentRune() == ']' {
-					break
-				}
-				t.eat()
-			}
-			end := t.Cur
-			// eat the last ]
-			t.eat()
-			tok.Kind = token.TokenKind_Identifier
-			tok.Text = string(t.Raw[start:end])
-			tok.OpenQuote = '['
-			tok.CloseQuote = ']'
-			return tok
-		}
+		return t.quotedIdentifier(tok, '[', ']')
 	case '`':
-		{
-			// eat the first `
-			t.eat()
-			start := t.Cur
-			prev := rune(0)
-			for !t.Eof() {
-				if t.currentRune() == '`' && prev != '\\' {
-					break
-				}
-				prev = t.eat()
-			}
-			end := t.Cur
-			// eat the last `
-			t.eat()
-			tok.Kind = token.TokenKind_Identifier
-			tok.Text = string(t.Raw[start:end])
-			tok.OpenQuote = '`'
-			tok.CloseQuote = '`'
-			return tok
-		}
+		return t.quotedIdentifier(tok, '`', '`')
 	case '\'':
 		{
 			// eat the first '
@@ -451,13 +459,19 @@ func (t *Lexer) NextToken() (tok token.Token) {
 			prev := rune(0)
 			for !t.Eof() {
 				if t.currentRune() == '\'' && prev != '\\' {
+					// a doubled '' is an escaped quote, not the end of the literal
+					if p, err := t.peekRune(); err != io.EOF && p == '\'' {
+						t.eat()
+						prev = t.eat()
+						continue
+					}
 					break
 				}
 				prev = t.eat()
 			}
+			end := t.Cur
 			// eat the last '
 			t.eat()
-			end := t.Cur
 			tok.Text = string(t.Raw[start:end])
 			tok.OpenQuote = '\''
 			tok.CloseQuote = '\''
@@ -526,5 +540,10 @@ func (t *Lexer) NextToken() (tok token.Token) {
 		return tok
 	}
 
+	// any other operator is passed through as a single rune token,
+	// statements that are kept verbatim may contain them
+	r := t.eat()
+	tok.Kind = token.TokenKind(r)
+	tok.Text = string(r)
 	return tok
 }
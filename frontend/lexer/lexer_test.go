@@ -22,6 +22,10 @@ func TestNumericLiteral(t *testing.T) {
 		{input: "1.1e+7", expectedText: "1.1e+7", expectedKind: token.TokenKind_FloatNumericLiteral},
 		{input: "1.1e-7", expectedText: "1.1e-7", expectedKind: token.TokenKind_FloatNumericLiteral},
 		{input: ".1e7", expectedText: ".1e7", expectedKind: token.TokenKind_FloatNumericLiteral},
+		{input: "0x10", expectedText: "0x10", expectedKind: token.TokenKind_HexNumericLiteral},
+		// a sign that does not follow an exponent is an operator
+		{input: "1+2", expectedText: "1", expectedKind: token.TokenKind_IntegerNumericLiteral},
+		{input: "1.5-2", expectedText: "1.5", expectedKind: token.TokenKind_FloatNumericLiteral},
 	}
 
 	for _, cas := range cases {
@@ -37,3 +41,56 @@ func TestNumericLiteral(t *testing.T) {
 		}
 	}
 }
+
+func TestStringLiteral(t *testing.T) {
+
+	cases := []Case{
+		{input: "'abc'", expectedText: "abc", expectedKind: token.TokenKind_StringLiteral},
+		{input: "''", expectedText: "", expectedKind: token.TokenKind_StringLiteral},
+		{input: "'it''s'", expectedText: "it''s", expectedKind: token.TokenKind_StringLiteral},
+		{input: "''''", expectedText: "''", expectedKind: token.TokenKind_StringLiteral},
+	}
+
+	for _, cas := range cases {
+		lex := NewLexer(SourceCode{FileName: cas.input, Raw: []rune(cas.input)})
+		result := lex.NextToken()
+		if result.Kind != cas.expectedKind {
+			t.Errorf("lexing %q expected kind %v got kind %v", cas.input, cas.expectedKind.DebugString(), result.Kind.DebugString())
+		}
+		if cas.expectedText != result.Text {
+			t.Errorf("lexing %q expected text %q got text %q", cas.input, cas.expectedText, result.Text)
+		}
+		if next := lex.NextToken(); next.Kind != token.TokenKind_EOF {
+			t.Errorf("lexing %q expected the literal to end the input, got %v", cas.input, next.Kind.DebugString())
+		}
+	}
+}
+
+func TestQuotedIdentifier(t *testing.T) {
+
+	cases := []Case{
+		{input: `"abc"`, expectedText: "abc", expectedKind: token.TokenKind_Identifier},
+		{input: `"a""b"`, expectedText: `a"b`, expectedKind: token.TokenKind_Identifier},
+		{input: `""""`, expectedText: `"`, expectedKind: token.TokenKind_Identifier},
+		{input: "`a``b`", expectedText: "a`b", expectedKind: token.TokenKind_Identifier},
+		{input: "[a]]b]", expectedText: "a]b", expectedKind: token.TokenKind_Identifier},
+		{input: `[a""b]`, expectedText: `a""b`, expectedKind: token.TokenKind_Identifier},
+	}
+
+	for _, cas := range cases {
+		lex := NewLexer(SourceCode{FileName: cas.input, Raw: []rune(cas.input)})
+		result := lex.NextToken()
+		if result.Kind != cas.expectedKind {
+			t.Errorf("lexing %q expected kind %v got kind %v", cas.input, cas.expectedKind.DebugString(), result.Kind.DebugString())
+		}
+		if cas.expectedText != result.Text {
+			t.Errorf("lexing %q expected text %q got text %q", cas.input, cas.expectedText, result.Text)
+		}
+		if result.Quoted() != cas.input {
+			t.Errorf("lexing %q expected to quote it as it was written, got %q", cas.input, result.Quoted())
+		}
+		if next := lex.NextToken(); next.Kind != token.TokenKind_EOF {
+			t.Errorf("lexing %q expected the identifier to end the input, got %v", cas.input, next.Kind.DebugString())
+		}
+	}
+}
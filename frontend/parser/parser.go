@@ -1,10 +1,10 @@
 package parser
 
 import (
+	"cmp"
 	"fmt"
 	"maps"
 	"slices"
-	"strings"
 	"woodybriggs/justmigrate/datastructures"
 	"woodybriggs/justmigrate/frontend/ast"
 	"woodybriggs/justmigrate/frontend/lexer"
@@ -19,9 +19,10 @@ type ParseContext struct {
 }
 
 type Parser struct {
-	lexer        *lexer.Lexer
-	currentToken token.Token
-	peekedToken  token.Token
+	lexer         *lexer.Lexer
+	previousToken token.Token
+	currentToken  token.Token
+	peekedToken   token.Token
 
 	errors   map[token.TextRange]report.Report
 	warnings map[token.TextRange]report.Report
@@ -45,19 +46,43 @@ func NewParser(lexer *lexer.Lexer) *Parser {
 	return result
 }
 
+// ErrorsAsReportSlice returns the errors in the order of the source they are
+// reported at.
 func (p *Parser) ErrorsAsReportSlice() []report.Report {
-	return slices.Collect(maps.Values(p.errors))
+	return inSourceOrder(p.errors)
 }
 
+// WarningsAsReportSlice returns the warnings in the order of the source they
+// are reported at.
+func (p *Parser) WarningsAsReportSlice() []report.Report {
+	return inSourceOrder(p.warnings)
+}
+
+// ErrorsAsErrorSlice returns the errors in the order of the source they are
+// reported at.
 func (p *Parser) ErrorsAsErrorSlice() []error {
-	result := make([]error, 0, len(p.errors))
-	for _, value := range p.errors {
-		result = append(result, &value)
+	reports := inSourceOrder(p.errors)
+	result := make([]error, 0, len(reports))
+	for i := range reports {
+		result = append(result, &reports[i])
+	}
+	return result
+}
+
+func inSourceOrder(reports map[token.TextRange]report.Report) []report.Report {
+	ranges := slices.SortedFunc(maps.Keys(reports), func(a, b token.TextRange) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(a.End, b.End))
+	})
+
+	result := make([]report.Report, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, reports[r])
 	}
-	return []error(result)
+	return result
 }
 
 func (p *Parser) Advance() {
+	p.previousToken = p.currentToken
 	p.currentToken = p.lexer.NextToken()
 	p.peekedToken = p.lexer.PeekToken()
 }
@@ -73,59 +98,57 @@ func (p *Parser) Expect(kind token.TokenKind) token.Token {
 		panic(err)
 	}
 
-	parseContext, ok := p.parseContext.Top()
+	// a misspelled keyword is reported as such and taken as the keyword
+	if kind.IsKeyword() && p.RepairKeyword(kind) {
+		token := p.currentToken
+		p.Advance()
+		return token
+	}
 
-	if ok {
-		p.ReportError(
-			report.
-				NewReport(
-					"parse error",
-				).
-				WithLabels(
-					report.LabelFromToken(p.currentToken, fmt.Sprintf("expected '%s' got '%s'", kind.DebugString(), p.currentToken.DebugString())),
-				).
-				WithNotes(
-					fmt.Sprintf("attempting to parse %s", parseContext.Name),
-				),
+	rep := report.
+		NewReport(
+			"parse error",
+		).
+		WithLocation(p.currentToken.FileLoc).
+		WithLabels(
+			report.LabelFromToken(p.currentToken, fmt.Sprintf("expected '%s' got '%s'", kind.DebugString(), p.currentToken.DebugString())),
 		)
-	} else {
-		p.ReportError(
-			report.
-				NewReport(
-					"parse error",
-				).
-				WithLabels(
-					report.LabelFromToken(p.currentToken, fmt.Sprintf("expected '%s' got '%s'", kind.DebugString(), p.currentToken.DebugString())),
-				),
+
+	if parseContext, ok := p.parseContext.Top(); ok {
+		rep.WithNotes(
+			fmt.Sprintf("attempting to parse %s", parseContext.Name),
 		)
 	}
 
-	if p.peekedToken.Kind == kind {
-		builder := strings.Builder{}
-		builder.WriteString(p.currentToken.String())
-		p.Advance()
-		builder.WriteString(p.currentToken.LeadingTrivia)
-		p.currentToken.LeadingTrivia = builder.String()
-		realToken := p.currentToken
-		p.Advance() // Consume '('
-		return realToken
+	if kind.IsKeyword() {
+		rep = WithKeywordSuggestion(rep, p.currentToken)
 	}
 
+	p.ReportError(rep)
+
 	costSynthesis := token.Token{Kind: kind}.InsertionCost()
 	costDeletion := p.currentToken.DeletionCost()
 
-	if costSynthesis <= costDeletion {
-		// p.Advance()
+	// skip to the expected token if it is close by and skipping is no more
+	// expensive than pretending it was there and skipping the current token,
+	// a single stray token is always skipped.
+	if n, costSkip, ok := p.skipCost(kind); ok && (n == 1 || costSkip <= costSynthesis+costDeletion) {
+		p.skip(n)
+		realToken := p.currentToken
+		p.Advance()
+		return realToken
+	}
+
+	// the end of the statement is never skipped, the statements that follow
+	// are parsed on their own
+
+	if costSynthesis <= costDeletion || p.currentToken.Kind == ';' {
 		return token.Token{
 			Kind: kind,
 		}
 	}
 
-	builder := strings.Builder{}
-	builder.WriteString(p.currentToken.String())
-	p.Advance()
-	builder.WriteString(p.currentToken.LeadingTrivia)
-	p.currentToken.LeadingTrivia = builder.String()
+	p.skip(1)
 	return p.currentToken
 }
 
@@ -133,10 +156,26 @@ func (p *Parser) Current() token.Token {
 	return p.currentToken
 }
 
+// Previous is the token that was consumed last.
+func (p *Parser) Previous() token.Token {
+	return p.previousToken
+}
+
 func (p *Parser) Peeked() token.Token {
 	return p.peekedToken
 }
 
+// PeekAhead returns the token n tokens past the current token without
+// consuming anything, PeekAhead(1) is the peeked token.
+func (p *Parser) PeekAhead(n int) token.Token {
+	lexer := p.lexer.Clone()
+	tok := p.currentToken
+	for range n {
+		tok = lexer.NextToken()
+	}
+	return tok
+}
+
 func (p *Parser) ReportError(report *report.Report) {
 
 	if _, has := p.errors[p.currentToken.SourceRange]; has {
@@ -146,6 +185,13 @@ func (p *Parser) ReportError(report *report.Report) {
 	p.errors[p.currentToken.SourceRange] = *report
 }
 
+// HasReportedError reports whether an error is already reported at the
+// current token, ReportError panics on a second report at the same token.
+func (p *Parser) HasReportedError() bool {
+	_, has := p.errors[p.currentToken.SourceRange]
+	return has
+}
+
 func (p *Parser) ReportWarning(report *report.Report) {
 	p.warnings[p.currentToken.SourceRange] = *report
 }
@@ -186,6 +232,15 @@ func (p *Parser) CatalogObjectIdentifier() *ast.CatalogObjectIdentifier {
 	p.PushParseContext("catalog object identifier")
 	defer p.PopParseContext()
 
+	// the temp schema is named by the TEMP keyword
+	if p.Current().Kind == token.TokenKind_Keyword_TEMPORARY && p.Peeked().Kind == token.TokenKind_Period {
+		schemaName := ast.Identifier(p.Current())
+		schemaName.Kind = token.TokenKind_Identifier
+		p.Advance()
+		p.Advance()
+		return ast.MakeCatalogObjectIdentifier(&schemaName, p.Identifier())
+	}
+
 	schemaOrTable := p.Identifier()
 
 	if p.Current().Kind != token.TokenKind_Period {
@@ -206,6 +261,34 @@ func (p *Parser) CatalogObjectIdentifier() *ast.CatalogObjectIdentifier {
 func (p *Parser) Identifier() ast.Identifier {
 	p.PushParseContext("identifier")
 	defer p.PopParseContext()
+
+	// a keyword used as a name has to be quoted, it is taken as the name
+	if p.currentToken.Kind.IsKeyword() && !p.HasReportedError() {
+		keyword := p.currentToken
+		p.ReportError(
+			report.NewReport("parse error").
+				WithLocation(keyword.FileLoc).
+				WithLabels(
+					report.LabelFromToken(keyword, fmt.Sprintf("'%s' is a keyword", keyword.Text)),
+					report.ReplacementLabel(keyword, fmt.Sprintf("\"%s\"", keyword.Text)),
+				).
+				WithNotes("quote the name to use a keyword as the name of a table, column or other object"),
+		)
+		p.Advance()
+		keyword.Kind = token.TokenKind_Identifier
+		return ast.Identifier(keyword)
+	}
+
+	// sqlite takes a string literal where a name is expected, the modules of
+	// virtual tables name the tables they create that way, 'docs_data'
+	if p.currentToken.Kind == token.TokenKind_StringLiteral {
+		name := p.currentToken
+		p.Advance()
+		name.Kind = token.TokenKind_Identifier
+		name.Text = ast.StringLiteralValue(name)
+		return ast.Identifier(name)
+	}
+
 	return ast.Identifier(p.Expect(token.TokenKind_Identifier))
 }
 
@@ -215,6 +298,7 @@ func (p *Parser) MaybeCollation() *ast.Collation {
 		return nil
 	}
 	collateKeyword := ast.Keyword(p.Current())
+	p.Advance()
 	name := p.Identifier()
 
 	return ast.MakeCollation(
@@ -246,6 +330,13 @@ func (p *Parser) Expr(
 		op := p.Current()
 		p.Advance()
 
+		// IS NOT is one operator, the right hand side is not a NOT expression
+		var not *ast.Keyword
+		if op.Kind == token.TokenKind_Keyword_IS && p.Current().Kind == token.TokenKind_Keyword_NOT {
+			not = ast.MakeKeyword(p.Current())
+			p.Advance()
+		}
+
 		rhs := p.Expr(bp.R, prattParser)
 
 		binaryOp := ast.MakeBinaryOpExpr(
@@ -253,6 +344,7 @@ func (p *Parser) Expr(
 			op,
 			rhs,
 		)
+		binaryOp.Not = not
 
 		lhs = binaryOp
 	}
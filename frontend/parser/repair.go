@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"woodybriggs/justmigrate/frontend/report"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// maxRepairLookahead is how many tokens Expect looks past the current token
+// for the token it expects before it gives up on skipping to it.
+const maxRepairLookahead = 3
+
+// editDistance is the optimal string alignment distance between a and b, the
+// number of insertions, deletions, substitutions and transpositions of
+// adjacent characters that turn a into b, ignoring case.
+func editDistance(a, b string) int {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// maxTypoDistance is the largest edit distance at which word is taken to be
+// a misspelling, very short words are too close to everything to tell.
+func maxTypoDistance(word string) int {
+	switch n := len([]rune(word)); {
+	case n < 3:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// MisspelledKeyword finds the keyword among kinds that tok is most likely a
+// misspelling of. Only unquoted identifiers are considered, quoting an
+// identifier is a deliberate choice of name.
+func MisspelledKeyword(tok token.Token, kinds ...token.TokenKind) (token.TokenKind, bool) {
+	if tok.Kind != token.TokenKind_Identifier || tok.OpenQuote != 0 {
+		return 0, false
+	}
+
+	best, bestDistance := token.TokenKind(0), maxTypoDistance(tok.Text)+1
+	for _, kind := range kinds {
+		keyword, ok := token.KeywordIndex.GetKey(kind)
+		if !ok {
+			continue
+		}
+		if distance := editDistance(tok.Text, keyword); distance < bestDistance {
+			best, bestDistance = kind, distance
+		}
+	}
+
+	return best, best != 0
+}
+
+// SuggestKeyword finds the keyword that tok is most likely a misspelling of
+// among every keyword, for when an identifier appears where a keyword is
+// expected but it is not known which one.
+func SuggestKeyword(tok token.Token) (string, bool) {
+	if tok.Kind != token.TokenKind_Identifier || tok.OpenQuote != 0 {
+		return "", false
+	}
+
+	keywords := slices.Sorted(token.KeywordIndex.Keys())
+
+	best, bestDistance := "", maxTypoDistance(tok.Text)+1
+	for _, keyword := range keywords {
+		if distance := editDistance(tok.Text, keyword); distance < bestDistance {
+			best, bestDistance = keyword, distance
+		}
+	}
+
+	return best, best != ""
+}
+
+// WithKeywordSuggestion adds a "did you mean" note and a label suggesting the
+// replacement to rep when tok looks like a misspelled keyword.
+func WithKeywordSuggestion(rep *report.Report, tok token.Token) *report.Report {
+	if keyword, ok := SuggestKeyword(tok); ok {
+		return withSuggestion(rep, tok, keyword)
+	}
+	return rep
+}
+
+func withSuggestion(rep *report.Report, tok token.Token, keyword string) *report.Report {
+	keyword = strings.ToUpper(keyword)
+	return rep.
+		WithNotes(fmt.Sprintf("did you mean %s?", keyword)).
+		WithLabels(report.ReplacementLabel(tok, keyword))
+}
+
+// RepairKeyword reports the current token as a misspelling of one of the
+// keywords and parses on as if the keyword was spelled correctly.
+func (p *Parser) RepairKeyword(kinds ...token.TokenKind) bool {
+	kind, ok := MisspelledKeyword(p.currentToken, kinds...)
+	if !ok {
+		return false
+	}
+
+	if !p.HasReportedError() {
+		keyword, _ := token.KeywordIndex.GetKey(kind)
+		p.ReportError(
+			withSuggestion(
+				report.NewReport("parse error").
+					WithLocation(p.currentToken.FileLoc).
+					WithLabels(report.LabelFromToken(p.currentToken, fmt.Sprintf("unknown keyword '%s'", p.currentToken.Text))),
+				p.currentToken,
+				keyword,
+			),
+		)
+	}
+
+	p.currentToken.Kind = kind
+	return true
+}
+
+// skip skips n tokens, they are kept as leading trivia of the token that
+// follows them so that the source text is not lost.
+func (p *Parser) skip(n int) {
+	builder := strings.Builder{}
+	for range n {
+		builder.WriteString(p.currentToken.String())
+		p.Advance()
+	}
+	builder.WriteString(p.currentToken.LeadingTrivia)
+	p.currentToken.LeadingTrivia = builder.String()
+}
+
+// skipCost is the number of tokens before the next token of kind and the cost
+// of skipping them, the token has to be within maxRepairLookahead tokens and
+// in the same statement.
+func (p *Parser) skipCost(kind token.TokenKind) (int, token.Cost, bool) {
+	if p.currentToken.Kind == ';' {
+		return 0, 0, false
+	}
+
+	cost := p.currentToken.DeletionCost()
+	for n := 1; n <= maxRepairLookahead; n++ {
+		tok := p.PeekAhead(n)
+		if tok.Kind == kind {
+			return n, cost, true
+		}
+		if tok.Kind == token.TokenKind_EOF || tok.Kind == ';' {
+			break
+		}
+		cost += tok.DeletionCost()
+	}
+	return 0, 0, false
+}
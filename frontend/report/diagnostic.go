@@ -0,0 +1,99 @@
+package report
+
+import (
+	"strings"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// Level is how severe a report is, the terminal renderer only shows the level
+// a report carries itself while the machine readable formats always do.
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNote    Level = "note"
+)
+
+// LevelOr is the level of the report, or level if the report has none.
+func (report *Report) LevelOr(level Level) Level {
+	if report.Level != "" {
+		return report.Level
+	}
+	return level
+}
+
+// Span is a range of a source file as lines and columns, both counted from
+// one. Columns count characters and End is just past the last character.
+type Span struct {
+	FileName  string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	// Start and End are the character offsets of the range in the file
+	Start int
+	End   int
+}
+
+func spanOf(src lexer.SourceCode, tr token.TextRange) Span {
+	start := rangeToLineInfo(src, tr)
+	end := rangeToLineInfo(src, TextRangeAt(tr.End))
+	return Span{
+		FileName:  src.FileName,
+		StartLine: start.Line,
+		StartCol:  start.Col + 1,
+		EndLine:   end.Line,
+		EndCol:    end.Col + 1,
+		Start:     tr.Start,
+		End:       tr.End,
+	}
+}
+
+// Span is the range of the source the label points at.
+func (label Label) Span() Span {
+	return spanOf(label.Source, label.Range)
+}
+
+// PrimarySpan is the range the report is located at, the first label that
+// starts at the location of the report or the location itself.
+func (report *Report) PrimarySpan() Span {
+	for _, label := range report.Labels {
+		span := label.Span()
+		if span.FileName == report.Location.FileName && span.StartLine == report.Location.Line && span.StartCol == report.Location.Col {
+			return span
+		}
+	}
+
+	if report.Location.FileName == "" && len(report.Labels) > 0 {
+		return report.Labels[0].Span()
+	}
+
+	return Span{
+		FileName:  report.Location.FileName,
+		StartLine: report.Location.Line,
+		StartCol:  report.Location.Col,
+		EndLine:   report.Location.Line,
+		EndCol:    report.Location.Col,
+		Start:     -1,
+		End:       -1,
+	}
+}
+
+// Description is the message of the report followed by its notes, for
+// formats that show a report as a single message. Reports without a message
+// are described by their first label.
+func (report *Report) Description() string {
+	lines := []string{}
+	switch {
+	case report.Message != "":
+		lines = append(lines, report.Message)
+	case len(report.Labels) > 0 && report.Labels[0].Note != "":
+		lines = append(lines, report.Labels[0].Note)
+	default:
+		lines = append(lines, report.Kind)
+	}
+	lines = append(lines, report.Notes...)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+// Edit is a change to the source that fixes the problem a report describes,
+// Replacement is written in place of the text in Range. An empty range
+// inserts the replacement.
+type Edit struct {
+	Range       token.TextRange
+	Replacement string
+}
+
+func (edit Edit) IsInsertion() bool {
+	return edit.Range.Start == edit.Range.End
+}
+
+// ReplacementLabel suggests writing replacement in place of the token.
+func ReplacementLabel(token token.Token, replacement string) Label {
+	label := LabelFromToken(token, fmt.Sprintf("help: replace with %s", replacement))
+	label.Edit = &Edit{Range: token.SourceRange, Replacement: replacement}
+	return label
+}
+
+// InsertionLabel suggests inserting text at offset, the label points at the
+// token the insertion is made next to.
+func InsertionLabel(token token.Token, offset int, insertion string, note string) Label {
+	label := LabelFromToken(token, fmt.Sprintf("help: %s", note))
+	label.Edit = &Edit{Range: TextRangeAt(offset), Replacement: insertion}
+	return label
+}
+
+func TextRangeAt(offset int) token.TextRange {
+	return token.TextRange{Start: offset, End: offset}
+}
+
+// Edits are the edits the report suggests to the source file named fileName.
+func (report *Report) Edits(fileName string) []Edit {
+	edits := []Edit{}
+	for _, label := range report.Labels {
+		if label.Edit != nil && label.Source.FileName == fileName {
+			edits = append(edits, *label.Edit)
+		}
+	}
+	return edits
+}
+
+// Collect returns the reports wrapped by err, following every error that
+// wraps other errors.
+func Collect(err error) []*Report {
+	if err == nil {
+		return nil
+	}
+
+	if report, ok := err.(*Report); ok {
+		return []*Report{report}
+	}
+
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		reports := []*Report{}
+		for _, err := range wrapped.Unwrap() {
+			reports = append(reports, Collect(err)...)
+		}
+		return reports
+	case interface{ Unwrap() error }:
+		return Collect(wrapped.Unwrap())
+	default:
+		return nil
+	}
+}
+
+// ApplyEdits applies the edits to raw. An edit that overlaps an edit before
+// it is left out and an edit made twice is applied once, the number of edits
+// applied is returned with the result.
+func ApplyEdits(raw []rune, edits []Edit) ([]rune, int) {
+	edits = slices.SortedStableFunc(slices.Values(edits), func(a, b Edit) int {
+		return cmp.Compare(a.Range.Start, b.Range.Start)
+	})
+
+	result := make([]rune, 0, len(raw))
+	applied, offset := 0, 0
+	seen := map[Edit]bool{}
+	for _, edit := range edits {
+		if seen[edit] {
+			continue
+		}
+		seen[edit] = true
+		if edit.Range.Start < offset || edit.Range.End > len(raw) || edit.Range.Start > edit.Range.End {
+			continue
+		}
+		// two insertions at the same offset are kept in the order given
+		result = append(result, raw[offset:edit.Range.Start]...)
+		result = append(result, []rune(edit.Replacement)...)
+		offset = edit.Range.End
+		applied++
+	}
+	result = append(result, raw[offset:]...)
+
+	return result, applied
+}
@@ -0,0 +1,18 @@
+package report
+
+import "testing"
+
+func TestApplyEditsAppliesDuplicateOnce(t *testing.T) {
+	raw := []rune("CREATE TABLE t (id integer);")
+	add := Edit{Range: TextRangeAt(16), Replacement: "code text, "}
+	other := Edit{Range: TextRangeAt(16), Replacement: "name text, "}
+
+	result, applied := ApplyEdits(raw, []Edit{add, other, add})
+	if applied != 2 {
+		t.Errorf("expected two edits, got %d", applied)
+	}
+	expected := "CREATE TABLE t (code text, name text, id integer);"
+	if string(result) != expected {
+		t.Errorf("expected %q, got %q", expected, string(result))
+	}
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"encoding/json"
+)
+
+type jsonEdit struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Replacement string `json:"replacement"`
+}
+
+type jsonLabel struct {
+	FileName string    `json:"file"`
+	Line     int       `json:"line"`
+	Col      int       `json:"col"`
+	EndLine  int       `json:"endLine"`
+	EndCol   int       `json:"endCol"`
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+	Note     string    `json:"note"`
+	Edit     *jsonEdit `json:"edit,omitempty"`
+}
+
+type jsonReport struct {
+	Level    Level       `json:"level"`
+	Kind     string      `json:"kind"`
+	Message  string      `json:"message"`
+	FileName string      `json:"file"`
+	Line     int         `json:"line"`
+	Col      int         `json:"col"`
+	Labels   []jsonLabel `json:"labels"`
+	Notes    []string    `json:"notes"`
+}
+
+// JSONRenderer renders a report as a single line of JSON, a stream of
+// reports is one report per line.
+type JSONRenderer struct {
+	Level Level
+}
+
+func (r *JSONRenderer) Render(report Report) string {
+	level := r.Level
+	if level == "" {
+		level = LevelError
+	}
+
+	out := jsonReport{
+		Level:    level,
+		Kind:     report.Kind,
+		Message:  report.Description(),
+		FileName: report.Location.FileName,
+		Line:     report.Location.Line,
+		Col:      report.Location.Col,
+		Labels:   []jsonLabel{},
+		Notes:    []string{},
+	}
+	out.Notes = append(out.Notes, report.Notes...)
+
+	for _, label := range report.Labels {
+		span := label.Span()
+		jl := jsonLabel{
+			FileName: span.FileName,
+			Line:     span.StartLine,
+			Col:      span.StartCol,
+			EndLine:  span.EndLine,
+			EndCol:   span.EndCol,
+			Start:    span.Start,
+			End:      span.End,
+			Note:     label.Note,
+		}
+		if label.Edit != nil {
+			jl.Edit = &jsonEdit{
+				Start:       label.Edit.Range.Start,
+				End:         label.Edit.Range.End,
+				Replacement: label.Edit.Replacement,
+			}
+		}
+		out.Labels = append(out.Labels, jl)
+	}
+
+	line, err := json.Marshal(out)
+	if err != nil {
+		// every field is a plain value, marshalling can't fail
+		panic(err)
+	}
+	return string(line) + "\n"
+}
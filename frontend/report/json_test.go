@@ -0,0 +1,100 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"woodybriggs/justmigrate/frontend/lexer"
+	"woodybriggs/justmigrate/frontend/token"
+)
+
+func makeReport() Report {
+	source := lexer.SourceCode{
+		FileName: "schema.sql",
+		Raw:      []rune("CREATE TABLE users (\n  id integer PRIMRY KEY\n);"),
+	}
+
+	tok := token.Token{Text: "PRIMRY", SourceRange: token.TextRange{Start: 34, End: 40}}
+	tok.SourceCode.FileName = source.FileName
+	tok.SourceCode.Raw = source.Raw
+
+	return *NewReport("parse error").
+		WithLocation(token.Location{FileName: "schema.sql", Line: 2, Col: 14}).
+		WithLabels(
+			LabelFromToken(tok, "unknown keyword 'PRIMRY'"),
+			ReplacementLabel(tok, "PRIMARY"),
+		).
+		WithNotes("did you mean PRIMARY?")
+}
+
+func TestJSONRenderer(t *testing.T) {
+	renderer := JSONRenderer{Level: LevelWarning}
+	line := renderer.Render(makeReport())
+
+	if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("expected a single line, got %q", line)
+	}
+
+	var out jsonReport
+	if err := json.Unmarshal([]byte(line), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Level != LevelWarning || out.Kind != "parse error" || out.Line != 2 || out.Col != 14 {
+		t.Errorf("unexpected report %+v", out)
+	}
+	if len(out.Labels) != 2 || len(out.Notes) != 1 {
+		t.Fatalf("expected 2 labels and 1 note, got %+v", out)
+	}
+	if rep := makeReport(); out.Message != rep.Description() || out.Message == "" {
+		t.Errorf("expected the message to describe the report as sarif does, got %q", out.Message)
+	}
+
+	label := out.Labels[1]
+	if label.Line != 2 || label.Col != 14 || label.EndLine != 2 || label.EndCol != 20 {
+		t.Errorf("unexpected label position %+v", label)
+	}
+	if label.Edit == nil || label.Edit.Replacement != "PRIMARY" || label.Edit.Start != 34 || label.Edit.End != 40 {
+		t.Errorf("unexpected label edit %+v", label.Edit)
+	}
+}
+
+func TestSarifRenderer(t *testing.T) {
+	renderer := SarifRenderer{ToolName: "justmigrate"}
+	renderer.Add(LevelError, makeReport())
+	renderer.Add(LevelError, makeReport())
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(renderer.Render()), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].Id != "parse error" {
+		t.Errorf("expected a single rule for the kind, got %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+
+	result := run.Results[0]
+	if result.Message.Text != "unknown keyword 'PRIMRY'\ndid you mean PRIMARY?" {
+		t.Errorf("unexpected message %q", result.Message.Text)
+	}
+
+	region := result.Locations[0].PhysicalLocation.Region
+	if region == nil || region.StartLine != 2 || region.StartColumn != 14 || region.EndColumn != 20 {
+		t.Errorf("unexpected region %+v", region)
+	}
+	if result.Locations[0].PhysicalLocation.ArtifactLocation.Uri != "schema.sql" {
+		t.Errorf("unexpected uri %q", result.Locations[0].PhysicalLocation.ArtifactLocation.Uri)
+	}
+
+	if len(result.Fixes) != 1 || result.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text != "PRIMARY" {
+		t.Errorf("expected a fix replacing the keyword, got %+v", result.Fixes)
+	}
+}
@@ -3,6 +3,7 @@ package report
 import (
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"woodybriggs/justmigrate/frontend/lexer"
 	"woodybriggs/justmigrate/frontend/token"
@@ -38,7 +39,11 @@ func (r *Renderer) Render(report Report) string {
 	var out strings.Builder
 	var tmp strings.Builder
 	// header
-	fmt.Fprintf(&out, " ┌─ %s\n", report.Kind)
+	if report.Level != "" {
+		fmt.Fprintf(&out, " ┌─ %s[%s]\n", report.Level, report.Kind)
+	} else {
+		fmt.Fprintf(&out, " ┌─ %s\n", report.Kind)
+	}
 	fmt.Fprintf(&out, " │\n")
 
 	// labels can be from different sources, so we group
@@ -144,9 +149,59 @@ func (r *Renderer) Render(report Report) string {
 		}
 	}
 
+	for _, label := range report.Labels {
+		if label.Edit != nil {
+			r.renderEdit(&out, label)
+		}
+	}
+
 	return out.String()
 }
 
+// renderEdit shows the source with the suggested edit of the label applied,
+// marking inserted text with '+' and replaced text with '~'.
+func (r *Renderer) renderEdit(out *strings.Builder, label Label) {
+	info := rangeToLineInfo(label.Source, label.Edit.Range)
+	line := []rune(info.Content)
+
+	start := min(info.Col, len(line))
+	end := min(start+label.Edit.Range.End-label.Edit.Range.Start, len(line))
+	replacement := []rune(label.Edit.Replacement)
+
+	marker := '~'
+	if label.Edit.IsInsertion() {
+		marker = '+'
+	}
+
+	edited := append(append(slices.Clone(line[:start]), replacement...), line[end:]...)
+	lines, markers := []string{}, []string{}
+	content, marks := []rune{}, []rune{}
+	for i, c := range edited {
+		if c == '\n' {
+			lines, markers = append(lines, string(content)), append(markers, strings.TrimRight(string(marks), " "))
+			content, marks = content[:0], marks[:0]
+			continue
+		}
+		content = append(content, c)
+		if i >= start && i < start+len(replacement) {
+			marks = append(marks, marker)
+		} else {
+			marks = append(marks, ' ')
+		}
+	}
+	lines, markers = append(lines, string(content)), append(markers, strings.TrimRight(string(marks), " "))
+
+	r.gutterWidth = max(r.gutterWidth, len(fmt.Sprintf("%d", info.Line+len(lines)))+1)
+
+	fmt.Fprintf(out, " │%s %s\n", r.inGutter("="), label.Note)
+	for i := range lines {
+		fmt.Fprintf(out, " │%s │ %s\n", r.inGutter(fmt.Sprint(info.Line+i)), lines[i])
+		if markers[i] != "" {
+			fmt.Fprintf(out, " ┆%s ┆ %s\n", r.inGutter(""), markers[i])
+		}
+	}
+}
+
 func (r *Renderer) inGutter(s string) string {
 	return fmt.Sprintf("%*s", r.gutterWidth, s)
 }
@@ -11,6 +11,8 @@ type Label struct {
 	Source lexer.SourceCode
 	Range  token.TextRange
 	Note   string
+	// Edit is the change to the source that the label suggests, if any.
+	Edit *Edit
 }
 
 func LabelFromToken(token token.Token, note string) Label {
@@ -34,7 +36,10 @@ func (label Label) String() string {
 }
 
 type Report struct {
-	Kind     string
+	Kind string
+	// Level is how severe the report is, a report without a level is shown
+	// at the level it is shown with, see LevelOr.
+	Level    Level
 	Location token.Location
 	Message  string
 	Labels   []Label
@@ -57,6 +62,11 @@ func (report *Report) WithLocation(location token.Location) *Report {
 	return report
 }
 
+func (report *Report) WithLevel(level Level) *Report {
+	report.Level = level
+	return report
+}
+
 func (report *Report) WithMessage(message string) *Report {
 	report.Message = message
 	return report
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId           string          `json:"ruleId"`
+	RuleIndex        int             `json:"ruleIndex"`
+	Level            Level           `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	Id               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int  `json:"startLine,omitempty"`
+	StartColumn int  `json:"startColumn,omitempty"`
+	EndLine     int  `json:"endLine,omitempty"`
+	EndColumn   int  `json:"endColumn,omitempty"`
+	CharOffset  *int `json:"charOffset,omitempty"`
+	CharLength  *int `json:"charLength,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// SarifRenderer collects reports into a SARIF 2.1 log, unlike the other
+// renderers the reports make up one document that is rendered once they
+// have all been added.
+type SarifRenderer struct {
+	ToolName       string
+	InformationUri string
+
+	rules   []sarifRule
+	results []sarifResult
+}
+
+// Add adds the report to the log as a result of the given level, the kind of
+// the report is the rule of the result.
+func (r *SarifRenderer) Add(level Level, report Report) {
+	ruleIndex := -1
+	for i, rule := range r.rules {
+		if rule.Id == report.Kind {
+			ruleIndex = i
+			break
+		}
+	}
+	if ruleIndex == -1 {
+		ruleIndex = len(r.rules)
+		r.rules = append(r.rules, sarifRule{
+			Id:               report.Kind,
+			Name:             report.Kind,
+			ShortDescription: sarifMessage{Text: report.Kind},
+		})
+	}
+
+	result := sarifResult{
+		RuleId:    report.Kind,
+		RuleIndex: ruleIndex,
+		Level:     level,
+		Message:   sarifMessage{Text: report.Description()},
+		Locations: []sarifLocation{{PhysicalLocation: sarifPhysical(report.PrimarySpan())}},
+	}
+
+	for i, label := range report.Labels {
+		id := i
+		result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+			Id:               &id,
+			PhysicalLocation: sarifPhysical(label.Span()),
+			Message:          &sarifMessage{Text: label.Note},
+		})
+
+		if label.Edit == nil {
+			continue
+		}
+		if deleted := sarifPhysical(spanOf(label.Source, label.Edit.Range)); deleted.Region != nil {
+			result.Fixes = append(result.Fixes, sarifFix{
+				Description: sarifMessage{Text: label.Note},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: deleted.ArtifactLocation,
+					Replacements: []sarifReplacement{{
+						DeletedRegion:   *deleted.Region,
+						InsertedContent: sarifMessage{Text: label.Edit.Replacement},
+					}},
+				}},
+			})
+		}
+	}
+
+	r.results = append(r.results, result)
+}
+
+// Render renders the log of every report added so far.
+func (r *SarifRenderer) Render() string {
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           r.ToolName,
+				InformationUri: r.InformationUri,
+				Rules:          append([]sarifRule{}, r.rules...),
+			}},
+			// columns are counted in characters rather than utf-16 code units
+			ColumnKind: "unicodeCodePoints",
+			Results:    append([]sarifResult{}, r.results...),
+		}},
+	}
+
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		// every field is a plain value, marshalling can't fail
+		panic(err)
+	}
+	return string(out) + "\n"
+}
+
+func sarifPhysical(span Span) sarifPhysicalLocation {
+	location := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{Uri: sarifUri(span.FileName)},
+	}
+	if span.StartLine == 0 {
+		// reports about a whole file, like a database, have no region
+		return location
+	}
+
+	region := &sarifRegion{
+		StartLine:   span.StartLine,
+		StartColumn: span.StartCol,
+		EndLine:     span.EndLine,
+		EndColumn:   span.EndCol,
+	}
+	if span.Start >= 0 {
+		offset, length := span.Start, span.End-span.Start
+		region.CharOffset, region.CharLength = &offset, &length
+	}
+	location.Region = region
+	return location
+}
+
+// sarifUri is the uri of a file name, relative file names stay relative so
+// that they resolve against the root of the repository.
+func sarifUri(fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(fileName)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(fileName)}).String()
+}
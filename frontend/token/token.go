@@ -2,7 +2,9 @@ package token
 
 import (
 	"fmt"
-	"os"
+	"iter"
+	"maps"
+	"strings"
 )
 
 type TokenKind int
@@ -22,6 +24,7 @@ var TokenKindDebugString = map[TokenKind]string{
 	'<':                             "less-than",
 	'!':                             "not",
 	TokenKind_neq:                   "not-equal",
+	TokenKind_concat:                "concat",
 	TokenKind_gte:                   "greater-than-equal",
 	TokenKind_lte:                   "less-than-equal",
 	TokenKind_Identifier:            "identifier",
@@ -80,6 +83,7 @@ const (
 	TokenKind_neq TokenKind = iota + 1 + TokenKindOffset_Misc
 	TokenKind_gte
 	TokenKind_lte
+	TokenKind_concat
 )
 
 const (
@@ -163,6 +167,12 @@ const (
 
 	TokenKind_Keyword_ADD
 	TokenKind_Keyword_DROP
+
+	TokenKind_Keyword_AND
+	TokenKind_Keyword_OR
+	TokenKind_Keyword_IS
+	TokenKind_Keyword_LIKE
+	TokenKind_Keyword_GLOB
 )
 
 const (
@@ -237,6 +247,11 @@ const (
 	Keyword_USING         string = "using"
 	Keyword_WHERE         string = "where"
 	Keyword_SELECT        string = "select"
+	Keyword_AND           string = "and"
+	Keyword_OR            string = "or"
+	Keyword_IS            string = "is"
+	Keyword_LIKE          string = "like"
+	Keyword_GLOB          string = "glob"
 )
 
 type MapIndex[TKey comparable, TVal comparable] struct {
@@ -267,6 +282,10 @@ func (i *MapIndex[TKey, TVal]) GetKey(value TVal) (TKey, bool) {
 	return res, ok
 }
 
+func (i *MapIndex[TKey, TVal]) Keys() iter.Seq[TKey] {
+	return maps.Keys(i.kv)
+}
+
 var KeywordIndex = NewIndex[string, TokenKind]().
 	Add(Keyword_DROP, TokenKind_Keyword_DROP).
 	Add(Keyword_ADD, TokenKind_Keyword_ADD).
@@ -335,7 +354,12 @@ var KeywordIndex = NewIndex[string, TokenKind]().
 	Add(Keyword_ELSE, TokenKind_Keyword_ELSE).
 	Add(Keyword_END, TokenKind_Keyword_END).
 	Add(Keyword_USING, TokenKind_Keyword_USING).
-	Add(Keyword_WHERE, TokenKind_Keyword_WHERE)
+	Add(Keyword_WHERE, TokenKind_Keyword_WHERE).
+	Add(Keyword_AND, TokenKind_Keyword_AND).
+	Add(Keyword_OR, TokenKind_Keyword_OR).
+	Add(Keyword_IS, TokenKind_Keyword_IS).
+	Add(Keyword_LIKE, TokenKind_Keyword_LIKE).
+	Add(Keyword_GLOB, TokenKind_Keyword_GLOB)
 
 var ConstaintKeywords = map[TokenKind]bool{
 	TokenKind_Keyword_CONSTRAINT: true,
@@ -378,11 +402,17 @@ type Token struct {
 }
 
 func (t Token) String() string {
-	return fmt.Sprintf("%s%c%s%c%s", t.LeadingTrivia, t.OpenQuote, t.Text, t.CloseQuote, t.TrailingTrivia)
+	return t.LeadingTrivia + t.Quoted() + t.TrailingTrivia
 }
 
+// Quoted is the token between its quotes. The text of a quoted identifier is
+// unescaped, so its closing quote is doubled again.
 func (t Token) Quoted() string {
-	return fmt.Sprintf("%c%s%c", t.OpenQuote, t.Text, t.CloseQuote)
+	text := t.Text
+	if t.Kind == TokenKind_Identifier && t.CloseQuote != 0 {
+		text = strings.ReplaceAll(text, string(t.CloseQuote), string(t.CloseQuote)+string(t.CloseQuote))
+	}
+	return fmt.Sprintf("%c%s%c", t.OpenQuote, text, t.CloseQuote)
 }
 
 func (t Token) DebugString() string {
@@ -404,11 +434,34 @@ const (
 	CostProhibit Cost = 100
 )
 
+func (k TokenKind) IsKeyword() bool {
+	return k > TokenKindOffset_Keywords
+}
+
+func (k TokenKind) IsLiteral() bool {
+	return k > TokenKind_Identifier && k <= TokenKind_StringLiteral
+}
+
+func (k TokenKind) IsOperator() bool {
+	switch k {
+	case '=', '+', '-', '*', '/', '%', '>', '<', '!', '|', '&', '~',
+		TokenKind_neq, TokenKind_gte, TokenKind_lte, TokenKind_concat:
+		return true
+	default:
+		return false
+	}
+}
+
+// InsertionCost is the cost of pretending that a missing token of this kind
+// was written. Punctuation that is easily forgotten is cheap, keywords that
+// only ever follow another keyword, such as the KEY of PRIMARY KEY, cost less
+// than those that change the meaning of a statement and inventing a value is
+// expensive. The end of the file can never be inserted.
 func (t Token) InsertionCost() Cost {
 	switch t.Kind {
 	case ',', ';', ')':
 		return CostLow
-	case '(':
+	case '(', '.':
 		return CostMid
 	case TokenKind_Keyword_CREATE,
 		TokenKind_Keyword_PRAMGA,
@@ -416,22 +469,49 @@ func (t Token) InsertionCost() Cost {
 		TokenKind_Keyword_COMMIT,
 		TokenKind_Keyword_SELECT,
 		TokenKind_Keyword_KEY,
-		TokenKind_Keyword_NULL:
+		TokenKind_Keyword_NULL,
+		TokenKind_Keyword_TABLE,
+		TokenKind_Keyword_INDEX,
+		TokenKind_Keyword_VIEW,
+		TokenKind_Keyword_TRIGGER,
+		TokenKind_Keyword_EXISTS,
+		TokenKind_Keyword_TRANSACTION,
+		TokenKind_Keyword_CONFLICT,
+		TokenKind_Keyword_ACTION,
+		TokenKind_Keyword_THEN,
+		TokenKind_Keyword_END,
+		TokenKind_Keyword_ON,
+		TokenKind_Keyword_AS:
 		return CostMid
 	case TokenKind_Identifier:
 		return CostMid
-	default:
+	case TokenKind_EOF, TokenKind_Error:
+		return CostProhibit
+	}
 
-		fmt.Fprintf(os.Stderr, "insertion cost not defined for '%s'", t.String())
-		os.Exit(1)
-		return 0
+	switch {
+	case t.Kind.IsOperator(), t.Kind.IsLiteral(), t.Kind.IsKeyword():
+		return CostHigh
+	case t.Kind > TokenKind_EOF && t.Kind < TokenKindOffset_Atoms:
+		// any other single character the lexer passes through
+		return CostHigh
+	default:
+		return CostProhibit
 	}
 }
 
+// DeletionCost is the cost of skipping over a token of this kind as if it
+// was never written. Stray punctuation is cheap to skip, a token the lexer
+// could not make sense of is the cheapest, and the end of the file can never
+// be skipped.
 func (t Token) DeletionCost() Cost {
 	switch t.Kind {
+	case TokenKind_Error:
+		return CostLow
 	case ',', ';', ')':
 		return CostLow
+	case '(', '.':
+		return CostMid
 	case TokenKind_Keyword_CREATE,
 		TokenKind_Keyword_PRAMGA,
 		TokenKind_Keyword_BEGIN,
@@ -441,7 +521,19 @@ func (t Token) DeletionCost() Cost {
 		return CostMid
 	case TokenKind_Identifier:
 		return CostMid
+	case TokenKind_EOF:
+		return CostProhibit
+	}
+
+	switch {
+	case t.Kind.IsOperator(), t.Kind.IsLiteral():
+		return CostMid
+	case t.Kind.IsKeyword():
+		return CostHigh
+	case t.Kind > TokenKind_EOF && t.Kind < TokenKindOffset_Atoms:
+		// any other single character the lexer passes through
+		return CostLow
 	default:
-		panic(fmt.Sprintf("insertion cost not defined for '%s'", t.String()))
+		return CostProhibit
 	}
 }
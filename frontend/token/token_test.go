@@ -0,0 +1,33 @@
+package token
+
+import "testing"
+
+func TestCostsDefinedForEveryKind(t *testing.T) {
+	kinds := []TokenKind{TokenKind_Error, '*', '/', '|', '%'}
+	for kind := range TokenKindDebugString {
+		kinds = append(kinds, kind)
+	}
+	for _, kind := range KeywordIndex.kv {
+		kinds = append(kinds, kind)
+	}
+
+	for _, kind := range kinds {
+		tok := Token{Kind: kind}
+		insertion, deletion := tok.InsertionCost(), tok.DeletionCost()
+
+		switch kind {
+		case TokenKind_EOF:
+			if insertion != CostProhibit || deletion != CostProhibit {
+				t.Errorf("the end of the file must never be inserted or deleted")
+			}
+		case TokenKind_Error:
+			if insertion != CostProhibit || deletion != CostLow {
+				t.Errorf("an error token must never be inserted and be cheap to delete")
+			}
+		default:
+			if insertion >= CostProhibit || deletion >= CostProhibit {
+				t.Errorf("%s: insertion cost %d, deletion cost %d", kind.DebugString(), insertion, deletion)
+			}
+		}
+	}
+}